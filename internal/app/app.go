@@ -22,6 +22,7 @@ import (
 	"github.com/EstebanForge/daedalus/internal/project"
 	"github.com/EstebanForge/daedalus/internal/providers"
 	"github.com/EstebanForge/daedalus/internal/quality"
+	"github.com/EstebanForge/daedalus/internal/secrets"
 	daedalusworktree "github.com/EstebanForge/daedalus/internal/worktree"
 )
 
@@ -57,6 +58,12 @@ type tuiState struct {
 	planEnabled     bool
 	reviewEnabled   bool
 	compoundEnabled bool
+
+	// Agent permission requests waiting for an answer, oldest first.
+	permissions []*tuiPermissionPrompt
+	// Provider the running loop fell back to, and the one it replaced.
+	fallbackFrom string
+	fallbackTo   string
 }
 
 type tuiSnapshot struct {
@@ -82,11 +89,21 @@ type tuiSnapshot struct {
 	planEnabled     bool
 	reviewEnabled   bool
 	compoundEnabled bool
+	// permission is the oldest pending permission request, if any.
+	permission      *providers.PermissionRequest
+	permissionQueue int
+	fallbackFrom    string
+	fallbackTo      string
 }
 
 func (s *tuiState) snapshot() tuiSnapshot {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	var permission *providers.PermissionRequest
+	if len(s.permissions) > 0 {
+		request := s.permissions[0].request
+		permission = &request
+	}
 	return tuiSnapshot{
 		selectedPRD:     s.selectedPRD,
 		view:            s.view,
@@ -110,6 +127,10 @@ func (s *tuiState) snapshot() tuiSnapshot {
 		planEnabled:     s.planEnabled,
 		reviewEnabled:   s.reviewEnabled,
 		compoundEnabled: s.compoundEnabled,
+		permission:      permission,
+		permissionQueue: len(s.permissions),
+		fallbackFrom:    s.fallbackFrom,
+		fallbackTo:      s.fallbackTo,
 	}
 }
 
@@ -208,6 +229,15 @@ func (s *tuiState) setProvider(provider string) {
 	}
 }
 
+// setProviderFallback records that the running loop switched providers. Empty
+// values clear it.
+func (s *tuiState) setProviderFallback(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallbackFrom = from
+	s.fallbackTo = to
+}
+
 func (s *tuiState) setPauseRequested(value bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -401,6 +431,11 @@ type runOptions struct {
 	PushOnCompleteSet   bool
 	AutoPROnComplete    bool
 	AutoPROnCompleteSet bool
+
+	UntilDone         bool
+	MaxStories        int
+	ContinueOnFailure bool
+	Parallel          int
 }
 
 func New(version string) App {
@@ -456,6 +491,12 @@ func (a App) Run(ctx context.Context, args []string) error {
 		return a.runDoctor(ctx, cfg, global, remainingArgs[1:])
 	case "sessions", "session":
 		return a.runSessions(baseDir, remainingArgs[1:])
+	case "usage":
+		return a.runUsage(store, cfg, baseDir, remainingArgs[1:])
+	case "pr-body":
+		return a.runPRBody(store, cfg, baseDir, remainingArgs[1:])
+	case "review":
+		return a.runReview(cfg, configPath, baseDir, remainingArgs[1:])
 	case "run":
 		return a.runLoop(ctx, store, cfg, global, baseDir, remainingArgs[1:], nil)
 	case "plugin":
@@ -524,8 +565,24 @@ func (a App) runStatus(store prd.Store, args []string) error {
 	a.writef("  in-progress: %d\n", doc.CountInProgress())
 	a.writef("  pending: %d\n", len(doc.UserStories)-doc.CountComplete()-doc.CountInProgress())
 
+	blocked := doc.BlockedStories()
+	if len(blocked) > 0 {
+		a.writef("Blocked: %d\n", len(blocked))
+		for _, story := range doc.UserStories {
+			blockers, ok := blocked[story.ID]
+			if !ok {
+				continue
+			}
+			a.writef("  %s - %s (waiting on %s)\n", story.ID, story.Title, strings.Join(blockers, ", "))
+		}
+	}
+
 	next := doc.NextStory()
 	if next == nil {
+		if len(blocked) > 0 {
+			a.writeLine("Next: none (remaining stories are blocked)")
+			return nil
+		}
 		a.writeLine("Next: none (all complete)")
 		return nil
 	}
@@ -715,6 +772,18 @@ type LoopOverrides struct {
 	CompoundEnabled *bool
 	// PhaseReporter is called when the loop enters a new phase (planning, reviewing, etc.).
 	PhaseReporter func(phase, description string)
+	// ShouldStop is polled between stories in until-done mode.
+	ShouldStop func() bool
+	// OnStoryFinished is called after each story attempted in until-done mode.
+	OnStoryFinished func(outcome loop.StoryOutcome)
+	// OnDrainFinished is called with the summary once an until-done run ends.
+	OnDrainFinished func(summary loop.DrainSummary)
+	// Approver answers agent permission requests. Nil applies the configured
+	// [permissions] policy.
+	Approver providers.Approver
+	// OnProviderSwitch is called when the loop falls back to another provider.
+	// Nil prints the switch.
+	OnProviderSwitch func(from, to string, reason error)
 }
 
 func (a App) runLoop(ctx context.Context, store prd.Store, cfg config.Config, global globalOptions, baseDir string, args []string, overrides *LoopOverrides) error {
@@ -763,6 +832,13 @@ func (a App) runLoop(ctx context.Context, store prd.Store, cfg config.Config, gl
 	if err != nil {
 		return err
 	}
+	prBodyTemplate := ""
+	if completionCfg.AutoPROnComplete {
+		prBodyTemplate, err = loadPRBodyTemplate(cfg, baseDir)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Resolve effective feature flags: overrides take precedence over config.
 	planEnabled := cfg.Plan.Enabled
@@ -783,25 +859,130 @@ func (a App) runLoop(ctx context.Context, store prd.Store, cfg config.Config, gl
 	// Build reviewer if review is enabled.
 	var reviewer quality.Reviewer
 	if reviewEnabled && len(cfg.Review.Perspectives) > 0 {
-		reviewer = quality.NewParallelReviewer(provider)
+		configPath, pathErr := config.ResolvePath(global.ConfigPath)
+		if pathErr != nil {
+			return pathErr
+		}
+		definitions, resolveErr := quality.ResolvePerspectives(reviewPerspectiveDirs(baseDir, configPath), cfg.Review.Perspectives)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		reviewProvider := provider
+		if route, ok, routeErr := resolvePhaseRoute(registry, cfg, config.PhaseReview, provider); routeErr != nil {
+			return routeErr
+		} else if ok && route.Provider != nil {
+			reviewProvider = route.Provider
+		}
+		reviewer = quality.NewPerspectiveReviewer(reviewProvider, cfg.Review.FailOn, definitions, func(key string) (providers.Provider, error) {
+			return registry.Resolve(key, cfg)
+		})
+	}
+
+	timeouts, qualityTimeout, err := resolveTimeouts(cfg.Timeouts)
+	if err != nil {
+		return err
+	}
+	qualityChecks, qualityConcurrency, err := resolveQualityChecks(cfg.Quality)
+	if err != nil {
+		return err
+	}
+	committer, err := resolveCommitter(cfg.Secrets)
+	if err != nil {
+		return err
 	}
 
 	manager := loop.NewManager(store, provider, loop.RetryPolicy{
 		MaxRetries: maxRetries,
 		Delays:     retryDelays,
-	}, resolveIterationOptions(cfg, provider.Name()), quality.NewRunner(), cfg.Quality.Commands, daedalusgit.NewCommitter(),
+	}, resolvePhaseOptions(cfg, config.PhaseWork, provider.Name()), quality.NewRunner().WithCommandTimeout(qualityTimeout).WithConcurrency(qualityConcurrency), cfg.Quality.Commands, committer,
 		loop.CompletionPolicy{
 			PushOnComplete:   completionCfg.PushOnComplete,
 			AutoPROnComplete: completionCfg.AutoPROnComplete,
-		}, daedalusgit.NewCommitter(),
+			PRBodyTemplate:   prBodyTemplate,
+			PRDraft:          cfg.Completion.PRDraft,
+			PRLabels:         cfg.Completion.PRLabels,
+			PRReviewers:      cfg.Completion.PRReviewers,
+		}, daedalusgit.NewCommitter().WithForge(daedalusgit.ForgeOptions{
+			Type:       cfg.Forge.Type,
+			URL:        cfg.Forge.URL,
+			TokenEnv:   cfg.Forge.TokenEnv,
+			BaseBranch: cfg.Forge.BaseBranch,
+		}),
 		planEnabled,
 		reviewer,
 		cfg.Review.Perspectives,
 		compoundEnabled,
 	)
+	manager.SetQualityRepairRounds(cfg.Quality.RepairRounds)
+	manager.SetQualityChecks(qualityChecks)
+	manager.SetQualityScope(daedalusgit.NewCommitter(), cfg.Quality.FullEvery)
+	manager.SetCoverageGate(quality.CoverageGate{
+		Profiles: cfg.Quality.Coverage.Profiles,
+		Min:      cfg.Quality.Coverage.Min,
+		MaxDrop:  cfg.Quality.Coverage.MaxDrop,
+	})
+	manager.SetReviewRemediationRounds(cfg.Review.RemediationRounds)
+	for _, phase := range []string{config.PhasePlan, config.PhaseReview} {
+		route, ok, routeErr := resolvePhaseRoute(registry, cfg, phase, provider)
+		if routeErr != nil {
+			return routeErr
+		}
+		if ok {
+			manager.SetPhaseRoute(phase, route)
+		}
+	}
 	if overrides != nil && overrides.PhaseReporter != nil {
 		manager.SetPhaseReporter(overrides.PhaseReporter)
 	}
+	if fallbacks := a.resolveFallbackProviders(registry, cfg, provider.Name()); len(fallbacks) > 0 {
+		manager.SetFallbackProviders(fallbacks)
+		if overrides != nil && overrides.OnProviderSwitch != nil {
+			manager.SetProviderSwitchReporter(overrides.OnProviderSwitch)
+		} else {
+			manager.SetProviderSwitchReporter(func(from, to string, reason error) {
+				a.writef("Provider %q failed (%v); falling back to %q.\n", from, reason, to)
+			})
+		}
+	}
+	manager.SetBudget(cfg.Budget.MaxUSD)
+	manager.SetTimeouts(timeouts)
+	manager.SetRollback(loop.RollbackPolicy(strings.ToLower(strings.TrimSpace(cfg.Rollback.OnFailure))), daedalusgit.NewCommitter())
+	if overrides != nil && overrides.Approver != nil {
+		manager.SetApprover(overrides.Approver)
+	} else {
+		manager.SetApprover(providers.NewPolicyApprover(providers.PermissionPolicyFromConfig(cfg.Permissions)))
+	}
+
+	if run.UntilDone || run.MaxStories > 0 || run.Parallel > 1 {
+		drainOpts := loop.DrainOptions{
+			MaxStories:        run.MaxStories,
+			ContinueOnFailure: run.ContinueOnFailure,
+			Parallel:          run.Parallel,
+		}
+		if run.Parallel > 1 {
+			manager.SetStoryWorkspaces(daedalusworktree.NewManager())
+			a.writef("Running up to %d stories in parallel.\n", run.Parallel)
+		}
+		if overrides != nil {
+			drainOpts.ShouldStop = overrides.ShouldStop
+			drainOpts.OnStoryFinished = overrides.OnStoryFinished
+		}
+		summary, err := manager.RunUntilDone(ctx, name, baseDir, execDir, drainOpts)
+		if overrides != nil && overrides.OnDrainFinished != nil {
+			overrides.OnDrainFinished(summary)
+		}
+		a.writeLine("Run summary:")
+		a.writeLine(summary.String())
+		if err != nil {
+			return err
+		}
+		if len(summary.Failed) > 0 {
+			return fmt.Errorf("%d story(s) failed", len(summary.Failed))
+		}
+		a.writef("Run completed with provider %q.\n", provider.Name())
+		return nil
+	}
+
 	if err := manager.RunOnce(ctx, name, baseDir, execDir); err != nil {
 		return err
 	}
@@ -832,7 +1013,10 @@ func (a App) printHelp() {
 	a.writeLine("  validate [name]     Validate PRD JSON")
 	a.writeLine("  doctor [provider]   Probe ACP provider health")
 	a.writeLine("  sessions [cmd]      ACP session cache observability")
-	a.writeLine("  run [name]          Run one iteration (supports --worktree)")
+	a.writeLine("  usage [name]        Show token usage and cost per story and provider")
+	a.writeLine("  pr-body [name]      Print the pull request body for a PRD")
+	a.writeLine("  review list         Show resolved review perspectives and their sources")
+	a.writeLine("  run [name]          Run one iteration (supports --worktree, --until-done, --max-stories <n>, --continue-on-failure, --parallel <n>)")
 	a.writeLine("  plugin run [name]   Plugin adapter: run one iteration and emit JSON result")
 	a.writeLine("  edit [name]         Open prd.md in editor")
 	a.writeLine("  help                Show help")
@@ -1028,7 +1212,15 @@ func (a App) runTUICommandLoop(
 		switch cmd {
 		case "?", "help":
 			a.writeLine("Views: d/dashboard, u/stories, l/logs, diff, picker, h/help, ,/settings")
-			a.writeLine("Actions: s/run, p/pause, x/stop, xx/stop-now, v/validate, n/use <name>, 1-9 switch PRD tab, provider <name>, providers, list, status, doctor [provider], sessions [list|status] [provider], f/filter <event|all>, tail <n>, q/quit")
+			a.writeLine("Actions: s/run, p/pause, x/stop, xx/stop-now, v/validate, n/use <name>, 1-9 switch PRD tab, provider <name>, providers, list, status, usage, doctor [provider], sessions [list|status] [provider], f/filter <event|all>, tail <n>, allow|deny [session], q/quit")
+		case "allow", "deny":
+			remember := len(args) == 1 && strings.EqualFold(args[0], "session")
+			request, ok := state.answerPermission(cmd == "allow", remember)
+			if !ok {
+				a.writeLine("No permission request is pending.")
+				continue
+			}
+			a.writef("%s: %s\n", cmd, request.Subject())
 		case "d", "dashboard":
 			state.setView("dashboard")
 			state.setActivity("Dashboard view.")
@@ -1117,6 +1309,26 @@ func (a App) runTUICommandLoop(
 				state.setActivity("ACP session observability failed.")
 				a.writef("Error: %v\n", err)
 			}
+		case "usage":
+			target := snap.selectedPRD
+			if len(args) == 1 {
+				target = args[0]
+			}
+			state.setActivity("Showing token usage.")
+			if err := a.runUsage(store, cfg, baseDir, []string{target}); err != nil {
+				state.setActivity("Failed to read token usage.")
+				a.writef("Error: %v\n", err)
+			}
+		case "pr-body":
+			target := snap.selectedPRD
+			if len(args) == 1 {
+				target = args[0]
+			}
+			state.setActivity("Showing the pull request body.")
+			if err := a.runPRBody(store, cfg, baseDir, []string{target}); err != nil {
+				state.setActivity("Failed to render the pull request body.")
+				a.writef("Error: %v\n", err)
+			}
 		case "v", "validate":
 			target := snap.selectedPRD
 			if len(args) == 1 {
@@ -1205,88 +1417,107 @@ func (a App) runTUILoopWorker(
 ) {
 	defer controller.stopRunning()
 
-	for {
-		pause, stop := controller.checkRequests()
-		if stop {
+	snap := state.snapshot()
+	runArgs := []string{"--until-done"}
+	if strings.TrimSpace(snap.selectedPRD) != "" {
+		runArgs = append(runArgs, snap.selectedPRD)
+	}
+	if strings.TrimSpace(snap.provider) != "" {
+		runArgs = append(runArgs, "--provider", strings.TrimSpace(snap.provider))
+	}
+	state.setActivity(fmt.Sprintf("Running iteration %d with provider %s.", snap.iterations+1, snap.provider))
+
+	// Build per-run overrides from TUI runtime flags. Pause and stop requests
+	// are honoured by the loop between stories.
+	state.setProviderFallback("", "")
+	var summary loop.DrainSummary
+	overrides := &LoopOverrides{
+		Approver: newTUIApprover(providers.PermissionPolicyFromConfig(cfg.Permissions), state),
+		OnProviderSwitch: func(from, to string, reason error) {
+			state.setProviderFallback(from, to)
+			state.setActivity(fmt.Sprintf("Provider %s failed; switched to %s.", from, to))
+		},
+		PhaseReporter: func(phase, description string) {
+			state.setActivity(fmt.Sprintf("[%s] %s", phase, description))
+		},
+		PlanEnabled:     &snap.planEnabled,
+		ReviewEnabled:   &snap.reviewEnabled,
+		CompoundEnabled: &snap.compoundEnabled,
+		ShouldStop: func() bool {
+			pause, stop := controller.checkRequests()
+			return pause || stop
+		},
+		OnStoryFinished: func(outcome loop.StoryOutcome) {
+			if !outcome.Passed {
+				return
+			}
+			state.setError(nil)
+			state.markIterationSuccess(snap.provider)
+			current := state.snapshot()
+			state.setActivity(fmt.Sprintf("Iteration %d completed.", current.iterations))
+		},
+		OnDrainFinished: func(finished loop.DrainSummary) {
+			summary = finished
+		},
+	}
+
+	err := a.runLoop(ctx, store, cfg, global, baseDir, runArgs, overrides)
+	pause, stop := controller.checkRequests()
+	if err != nil {
+		if stop && errors.Is(err, context.Canceled) {
 			state.setStopRequested(false)
-			state.setPauseRequested(false)
 			state.setLoopState("stopped")
-			state.setActivity("Loop stopped.")
-			a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop stopped")
-			return
-		}
-		if pause {
-			state.setPauseRequested(false)
-			state.setLoopState("paused")
-			state.setActivity("Loop paused.")
-			a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop paused")
+			state.setError(nil)
+			state.setActivity("Loop stopped immediately.")
+			a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop stopped immediately")
 			return
 		}
+		state.setError(err)
+		state.setLoopState("error")
+		state.setActivity("Loop error: " + err.Error())
+		a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop error: "+err.Error())
+		return
+	}
 
-		snap := state.snapshot()
-		runArgs := []string{}
-		if strings.TrimSpace(snap.selectedPRD) != "" {
-			runArgs = append(runArgs, snap.selectedPRD)
-		}
-		if strings.TrimSpace(snap.provider) != "" {
-			runArgs = append(runArgs, "--provider", strings.TrimSpace(snap.provider))
-		}
-		state.setActivity(fmt.Sprintf("Running iteration %d with provider %s.", snap.iterations+1, snap.provider))
-
-		// Build per-run overrides from TUI runtime flags.
-		snap = state.snapshot()
-		overrides := &LoopOverrides{
-			PhaseReporter: func(phase, description string) {
-				state.setActivity(fmt.Sprintf("[%s] %s", phase, description))
-			},
-			PlanEnabled:     &snap.planEnabled,
-			ReviewEnabled:   &snap.reviewEnabled,
-			CompoundEnabled: &snap.compoundEnabled,
-		}
-
-		if err := a.runLoop(ctx, store, cfg, global, baseDir, runArgs, overrides); err != nil {
-			if _, stopNow := controller.checkRequests(); stopNow && errors.Is(err, context.Canceled) {
-				state.setStopRequested(false)
-				state.setLoopState("stopped")
-				state.setError(nil)
-				state.setActivity("Loop stopped immediately.")
-				a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop stopped immediately")
-				return
-			}
-			state.setError(err)
-			state.setLoopState("error")
-			state.setActivity("Loop error: " + err.Error())
-			a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop error: "+err.Error())
-			return
-		}
-		state.setError(nil)
+	switch {
+	case stop:
 		state.setStopRequested(false)
 		state.setPauseRequested(false)
-		state.markIterationSuccess(snap.provider)
-		state.setActivity(fmt.Sprintf("Iteration %d completed.", snap.iterations+1))
+		state.setLoopState("stopped")
+		state.setActivity("Loop stopped.")
+		a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop stopped")
+	case pause:
+		state.setPauseRequested(false)
+		state.setLoopState("paused")
+		state.setActivity("Loop paused.")
+		a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop paused")
+	default:
+		loopState, activity := tuiDrainOutcome(summary)
+		state.setLoopState(loopState)
+		state.setActivity(activity)
+		a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop "+loopState)
+	}
+}
 
-		name := strings.TrimSpace(snap.selectedPRD)
-		resolvedName, err := store.ResolveName(name)
-		if err != nil {
-			state.setError(err)
-			state.setLoopState("error")
-			state.setActivity("Failed to resolve PRD: " + err.Error())
-			return
-		}
-		doc, err := store.Load(resolvedName)
-		if err != nil {
-			state.setError(err)
-			state.setLoopState("error")
-			state.setActivity("Failed to load PRD: " + err.Error())
-			return
-		}
-		if doc.NextStory() == nil {
-			state.setLoopState("completed")
-			state.setActivity("All stories completed.")
-			a.logTUIRuntimeAction(store, baseDir, state.snapshot(), "loop completed")
-			return
+// tuiDrainOutcome reports how an until-done run that ended on its own left the
+// PRD. It is "completed" only when no story remains; otherwise the loop stopped
+// and the activity names the stories that failed or are still blocked.
+func tuiDrainOutcome(summary loop.DrainSummary) (loopState, activity string) {
+	if len(summary.Failed) == 0 && len(summary.Skipped) == 0 {
+		return "completed", "All stories completed."
+	}
+	var parts []string
+	if len(summary.Failed) > 0 {
+		failed := make([]string, 0, len(summary.Failed))
+		for _, outcome := range summary.Failed {
+			failed = append(failed, outcome.StoryID)
 		}
+		parts = append(parts, "failed "+strings.Join(failed, ", "))
+	}
+	if len(summary.Skipped) > 0 {
+		parts = append(parts, "blocked or remaining "+strings.Join(summary.Skipped, ", "))
 	}
+	return "stopped", "Loop stopped with stories left: " + strings.Join(parts, "; ") + "."
 }
 
 func (a App) renderTUIView(store prd.Store, cfg config.Config, baseDir string, state tuiSnapshot) {
@@ -1596,6 +1827,44 @@ func parseRunOptions(args []string) (runOptions, error) {
 			}
 			options.AutoPROnComplete = flagValue
 			options.AutoPROnCompleteSet = true
+		case "until-done":
+			flagValue, parseErr := parseOptionalBoolFlag("until-done", value, hasValue)
+			if parseErr != nil {
+				return runOptions{}, parseErr
+			}
+			options.UntilDone = flagValue
+		case "max-stories":
+			if !hasValue {
+				i++
+				if i >= len(args) {
+					return runOptions{}, fmt.Errorf("--max-stories requires a value")
+				}
+				value = args[i]
+			}
+			maxStories, parseErr := strconv.Atoi(value)
+			if parseErr != nil || maxStories < 1 {
+				return runOptions{}, fmt.Errorf("--max-stories must be a positive integer")
+			}
+			options.MaxStories = maxStories
+		case "continue-on-failure":
+			flagValue, parseErr := parseOptionalBoolFlag("continue-on-failure", value, hasValue)
+			if parseErr != nil {
+				return runOptions{}, parseErr
+			}
+			options.ContinueOnFailure = flagValue
+		case "parallel":
+			if !hasValue {
+				i++
+				if i >= len(args) {
+					return runOptions{}, fmt.Errorf("--parallel requires a value")
+				}
+				value = args[i]
+			}
+			parallel, parseErr := strconv.Atoi(value)
+			if parseErr != nil || parallel < 1 {
+				return runOptions{}, fmt.Errorf("--parallel must be a positive integer")
+			}
+			options.Parallel = parallel
 		default:
 			return runOptions{}, fmt.Errorf("unknown run flag: --%s", key)
 		}
@@ -1660,6 +1929,9 @@ func parseBool(value string) (bool, error) {
 
 func resolveRuntimeSettings(cfg config.Config, global globalOptions, run runOptions) (string, int, []time.Duration, bool, error) {
 	providerName := cfg.Provider.Default
+	if workProvider := strings.TrimSpace(cfg.Phases.Work.Provider); workProvider != "" {
+		providerName = workProvider
+	}
 	if envProvider := strings.TrimSpace(os.Getenv("DAEDALUS_PROVIDER")); envProvider != "" {
 		providerName = envProvider
 	}
@@ -1763,6 +2035,146 @@ func resolveCompletionSettings(cfg config.Config, global globalOptions, run runO
 	return config.CompletionConfig{PushOnComplete: push, AutoPROnComplete: autoPR}, nil
 }
 
+// resolveFallbackProviders resolves provider.fallback in order, skipping the
+// primary provider, duplicates, and providers that are unknown or disabled.
+func (a App) resolveFallbackProviders(registry providers.Registry, cfg config.Config, primary string) []loop.FallbackProvider {
+	seen := map[string]struct{}{strings.ToLower(primary): {}}
+	var fallbacks []loop.FallbackProvider
+	for _, name := range cfg.Provider.Fallback {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		provider, err := registry.Resolve(key, cfg)
+		if err != nil {
+			a.writef("Skipping fallback provider %q: %v\n", key, err)
+			continue
+		}
+		fallbacks = append(fallbacks, loop.FallbackProvider{
+			Provider:  provider,
+			Iteration: resolveIterationOptions(cfg, provider.Name()),
+		})
+	}
+	return fallbacks
+}
+
+// resolvePhaseRoute resolves [phases.<phase>] against the work provider. ok is
+// false when the phase has no overrides. The route's provider is nil when the
+// phase runs on the work provider, so it follows provider fallbacks.
+func resolvePhaseRoute(registry providers.Registry, cfg config.Config, phase string, work providers.Provider) (loop.PhaseRoute, bool, error) {
+	phaseCfg := cfg.Phases.Get(phase)
+	if phaseCfg == (config.PhaseConfig{}) {
+		return loop.PhaseRoute{}, false, nil
+	}
+	route := loop.PhaseRoute{}
+	providerName := work.Name()
+	if key := strings.TrimSpace(phaseCfg.Provider); key != "" && !strings.EqualFold(key, work.Name()) {
+		provider, err := registry.Resolve(key, cfg)
+		if err != nil {
+			return loop.PhaseRoute{}, false, fmt.Errorf("phases.%s.provider: %w", phase, err)
+		}
+		route.Provider = provider
+		providerName = provider.Name()
+	}
+	route.Iteration = resolvePhaseOptions(cfg, phase, providerName)
+	return route, true, nil
+}
+
+// resolvePhaseOptions applies [phases.<phase>] model and policy overrides on
+// top of the provider's options. They are skipped when the phase names a
+// different provider, e.g. when --provider replaced it.
+func resolvePhaseOptions(cfg config.Config, phase, providerName string) loop.IterationOptions {
+	options := resolveIterationOptions(cfg, providerName)
+	phaseCfg := cfg.Phases.Get(phase)
+	if key := strings.TrimSpace(phaseCfg.Provider); key != "" && !strings.EqualFold(key, providerName) {
+		return options
+	}
+	if model := strings.TrimSpace(phaseCfg.Model); model != "" {
+		options.Model = model
+	}
+	if approvalPolicy := strings.TrimSpace(phaseCfg.ApprovalPolicy); approvalPolicy != "" {
+		options.ApprovalPolicy = approvalPolicy
+	}
+	if sandboxPolicy := strings.TrimSpace(phaseCfg.SandboxPolicy); sandboxPolicy != "" {
+		options.SandboxPolicy = sandboxPolicy
+	}
+	return options
+}
+
+// resolveTimeouts parses the [timeouts] section into the loop's phase
+// timeouts and the per-command quality timeout.
+func resolveTimeouts(cfg config.TimeoutsConfig) (loop.PhaseTimeouts, time.Duration, error) {
+	var timeouts loop.PhaseTimeouts
+	var qualityTimeout time.Duration
+	for _, field := range []struct {
+		key    string
+		value  string
+		target *time.Duration
+	}{
+		{"timeouts.plan", cfg.Plan, &timeouts.Plan},
+		{"timeouts.work", cfg.Work, &timeouts.Work},
+		{"timeouts.review", cfg.Review, &timeouts.Review},
+		{"timeouts.quality_command", cfg.QualityCommand, &qualityTimeout},
+	} {
+		parsed, err := config.ParseTimeout(field.value)
+		if err != nil {
+			return loop.PhaseTimeouts{}, 0, fmt.Errorf("%s: %w", field.key, err)
+		}
+		*field.target = parsed
+	}
+	return timeouts, qualityTimeout, nil
+}
+
+// resolveCommitter returns the committer for story commits, scanning them for
+// secrets unless [secrets] is disabled.
+func resolveCommitter(cfg config.SecretsConfig) (daedalusgit.Committer, error) {
+	committer := daedalusgit.NewCommitter()
+	if !cfg.Enabled {
+		return committer, nil
+	}
+	allowlist, err := secrets.ParseAllowlist(cfg.AllowPaths, cfg.AllowPatterns)
+	if err != nil {
+		return daedalusgit.Committer{}, err
+	}
+	return committer.WithSecretScanner(secrets.NewScanner().WithBlockedPaths(cfg.BlockPaths).WithAllowlist(allowlist)), nil
+}
+
+// resolveQualityChecks turns the quality.checks table into runner checks and
+// the concurrency to run them with. Without a table it returns no checks and
+// a concurrency of 1, so quality.commands keep running one at a time.
+func resolveQualityChecks(cfg config.QualityConfig) ([]quality.Check, int, error) {
+	if len(cfg.Checks) == 0 {
+		return nil, 1, nil
+	}
+	checks := make([]quality.Check, 0, len(cfg.Checks))
+	for _, entry := range cfg.Checks {
+		timeout, err := config.ParseTimeout(entry.Timeout)
+		if err != nil {
+			return nil, 0, fmt.Errorf("quality check %q: %w", entry.Name, err)
+		}
+		checks = append(checks, quality.Check{
+			Name:         strings.TrimSpace(entry.Name),
+			Command:      entry.Command,
+			Group:        strings.TrimSpace(entry.Group),
+			DependsOn:    entry.DependsOn,
+			Timeout:      timeout,
+			AllowFailure: entry.AllowFailure,
+			Format:       strings.TrimSpace(entry.Format),
+			Report:       strings.TrimSpace(entry.Report),
+			Scope:        strings.TrimSpace(entry.Scope),
+		})
+	}
+	if err := quality.ValidateChecks(checks); err != nil {
+		return nil, 0, err
+	}
+	concurrency := cfg.Concurrency
+	if concurrency == 0 {
+		concurrency = config.DefaultQualityConcurrency
+	}
+	return checks, concurrency, nil
+}
+
 func resolveIterationOptions(cfg config.Config, providerName string) loop.IterationOptions {
 	providerCfg := providerConfigForKey(cfg, providerName)
 	approvalPolicy := strings.TrimSpace(providerCfg.ApprovalPolicy)
@@ -1801,6 +2213,8 @@ func providerConfigForKey(cfg config.Config, providerName string) config.Generic
 		return cfg.Providers.Qwen
 	case "pi":
 		return cfg.Providers.Pi
+	case "mock":
+		return cfg.Providers.Mock
 	default:
 		return cfg.Providers.Codex
 	}
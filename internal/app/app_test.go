@@ -11,8 +11,11 @@ import (
 	"time"
 
 	"github.com/EstebanForge/daedalus/internal/config"
+	"github.com/EstebanForge/daedalus/internal/loop"
 	"github.com/EstebanForge/daedalus/internal/prd"
 	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
 )
 
 func TestRunNoCommandStartsTUIAndQuits(t *testing.T) {
@@ -218,6 +221,75 @@ func TestResolveIterationOptionsUsesProviderConfig(t *testing.T) {
 	}
 }
 
+func TestResolvePhaseRouteAppliesPhaseOverrides(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Defaults()
+	cfg.Providers.Claude.Enabled = true
+	cfg.Providers.Claude.Model = "claude-default"
+	cfg.Phases.Plan = config.PhaseConfig{Provider: "claude", Model: "claude-strong"}
+	cfg.Phases.Review = config.PhaseConfig{Model: "codex-mini", ApprovalPolicy: "never"}
+	cfg.Phases.Work = config.PhaseConfig{Provider: "claude", Model: "claude-work"}
+
+	registry := providers.NewRegistry()
+	work, err := registry.Resolve("codex", cfg)
+	if err != nil {
+		t.Fatalf("resolve work provider: %v", err)
+	}
+
+	plan, ok, err := resolvePhaseRoute(registry, cfg, config.PhasePlan, work)
+	if err != nil || !ok {
+		t.Fatalf("resolve plan route: ok=%v err=%v", ok, err)
+	}
+	if plan.Provider == nil || plan.Provider.Name() != "claude" || plan.Iteration.Model != "claude-strong" {
+		t.Fatalf("unexpected plan route: %+v", plan)
+	}
+
+	review, ok, err := resolvePhaseRoute(registry, cfg, config.PhaseReview, work)
+	if err != nil || !ok {
+		t.Fatalf("resolve review route: ok=%v err=%v", ok, err)
+	}
+	if review.Provider != nil || review.Iteration.Model != "codex-mini" || review.Iteration.ApprovalPolicy != "never" {
+		t.Fatalf("expected review to keep the work provider with overridden options, got %+v", review)
+	}
+
+	if _, ok, _ := resolvePhaseRoute(registry, cfg, config.PhaseScan, work); ok {
+		t.Fatal("expected no route for a phase without overrides")
+	}
+
+	// --provider replaced the work phase provider, so its model does not apply.
+	if options := resolvePhaseOptions(cfg, config.PhaseWork, "codex"); options.Model != "default" {
+		t.Fatalf("expected codex options, got %+v", options)
+	}
+
+	cfg.Phases.Plan.Provider = "gemini"
+	if _, _, err := resolvePhaseRoute(registry, cfg, config.PhasePlan, work); err == nil || !strings.Contains(err.Error(), "phases.plan.provider") {
+		t.Fatalf("expected disabled phase provider error, got %v", err)
+	}
+}
+
+func TestResolveRuntimeSettingsUsesWorkPhaseProvider(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Phases.Work.Provider = "claude"
+	t.Setenv("DAEDALUS_PROVIDER", "")
+
+	providerName, _, _, _, err := resolveRuntimeSettings(cfg, globalOptions{}, runOptions{})
+	if err != nil {
+		t.Fatalf("resolve runtime settings: %v", err)
+	}
+	if providerName != "claude" {
+		t.Fatalf("expected work phase provider, got %q", providerName)
+	}
+
+	providerName, _, _, _, err = resolveRuntimeSettings(cfg, globalOptions{}, runOptions{ProviderSet: true, Provider: "gemini"})
+	if err != nil {
+		t.Fatalf("resolve runtime settings: %v", err)
+	}
+	if providerName != "gemini" {
+		t.Fatalf("expected --provider to win, got %q", providerName)
+	}
+}
+
 func TestRunDoctorReturnsErrorForUnhealthyProvider(t *testing.T) {
 	t.Parallel()
 
@@ -471,6 +543,42 @@ func TestParseRunOptionsPushOnComplete(t *testing.T) {
 	}
 }
 
+func TestResolveQualityChecks(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Defaults()
+	checks, concurrency, err := resolveQualityChecks(cfg.Quality)
+	if err != nil || checks != nil || concurrency != 1 {
+		t.Fatalf("expected plain commands to run one at a time, got %v %d %v", checks, concurrency, err)
+	}
+
+	cfg.Quality.Checks = []config.QualityCheckConfig{
+		{Name: "build", Command: "go build ./..."},
+		{Name: "vet", Command: "go vet ./...", Group: "lint", DependsOn: []string{"build"}, Timeout: "90s", AllowFailure: true},
+		{Name: "test", Command: "go test -json {{changed_packages}} > test.json", Format: " go-test-json ", Report: " test.json ", Scope: " changed "},
+	}
+	checks, concurrency, err = resolveQualityChecks(cfg.Quality)
+	if err != nil {
+		t.Fatalf("resolve quality checks: %v", err)
+	}
+	if concurrency != config.DefaultQualityConcurrency {
+		t.Fatalf("expected default concurrency %d, got %d", config.DefaultQualityConcurrency, concurrency)
+	}
+	want := []quality.Check{
+		{Name: "build", Command: "go build ./..."},
+		{Name: "vet", Command: "go vet ./...", Group: "lint", DependsOn: []string{"build"}, Timeout: 90 * time.Second, AllowFailure: true},
+		{Name: "test", Command: "go test -json {{changed_packages}} > test.json", Format: quality.FormatGoTestJSON, Report: "test.json", Scope: quality.ScopeChanged},
+	}
+	if !reflect.DeepEqual(checks, want) {
+		t.Fatalf("expected %+v, got %+v", want, checks)
+	}
+
+	cfg.Quality.Checks[0].DependsOn = []string{"lint"}
+	if _, _, err := resolveQualityChecks(cfg.Quality); err == nil || !strings.Contains(err.Error(), "depends on itself") {
+		t.Fatalf("expected dependency cycle error, got %v", err)
+	}
+}
+
 func TestResolveCompletionSettingsDefaultsToFalse(t *testing.T) {
 	t.Parallel()
 
@@ -531,3 +639,207 @@ func TestResolveCompletionSettingsFlagOverridesEnv(t *testing.T) {
 		t.Fatal("expected run flag to override env var")
 	}
 }
+
+func TestParseRunOptionsSupportsUntilDoneFlags(t *testing.T) {
+	t.Parallel()
+
+	options, err := parseRunOptions([]string{"main", "--until-done", "--max-stories", "3", "--continue-on-failure"})
+	if err != nil {
+		t.Fatalf("parse run options: %v", err)
+	}
+	if !options.UntilDone {
+		t.Fatal("expected until-done flag to be set")
+	}
+	if options.MaxStories != 3 {
+		t.Fatalf("expected max stories 3, got %d", options.MaxStories)
+	}
+	if !options.ContinueOnFailure {
+		t.Fatal("expected continue-on-failure flag to be set")
+	}
+
+	if _, err := parseRunOptions([]string{"--max-stories", "0"}); err == nil {
+		t.Fatal("expected error for non-positive --max-stories")
+	}
+
+	options, err = parseRunOptions([]string{"--parallel=4"})
+	if err != nil {
+		t.Fatalf("parse run options: %v", err)
+	}
+	if options.Parallel != 4 {
+		t.Fatalf("expected parallel 4, got %d", options.Parallel)
+	}
+	if _, err := parseRunOptions([]string{"--parallel", "0"}); err == nil {
+		t.Fatal("expected error for non-positive --parallel")
+	}
+}
+
+func TestTUIDrainOutcomeOnlyCompletesWhenNoStoriesRemain(t *testing.T) {
+	t.Parallel()
+
+	if state, activity := tuiDrainOutcome(loop.DrainSummary{Passed: []string{"US-001"}}); state != "completed" || activity != "All stories completed." {
+		t.Fatalf("expected a drained PRD to complete, got %q %q", state, activity)
+	}
+
+	state, activity := tuiDrainOutcome(loop.DrainSummary{
+		Passed:  []string{"US-001"},
+		Failed:  []loop.StoryOutcome{{StoryID: "US-002"}},
+		Skipped: []string{"US-003", "US-004"},
+	})
+	if state != "stopped" {
+		t.Fatalf("expected stories left to stop the loop, got %q", state)
+	}
+	if want := "Loop stopped with stories left: failed US-002; blocked or remaining US-003, US-004."; activity != want {
+		t.Fatalf("expected %q, got %q", want, activity)
+	}
+}
+
+func TestRunStatusShowsBlockedStories(t *testing.T) {
+	tmp := t.TempDir()
+	store := prd.NewStore(tmp)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	doc := prd.Document{
+		Project: "demo",
+		UserStories: []prd.UserStory{
+			{ID: "US-001", Title: "Base", Priority: 1},
+			{ID: "US-002", Title: "Follow-up", Priority: 2, DependsOn: []string{"US-001"}},
+		},
+	}
+	if err := store.Save("main", doc); err != nil {
+		t.Fatalf("save PRD: %v", err)
+	}
+
+	var out bytes.Buffer
+	application := App{version: "test", out: &out}
+	if err := application.runStatus(store, []string{"main"}); err != nil {
+		t.Fatalf("run status: %v", err)
+	}
+	if !strings.Contains(out.String(), "US-002 - Follow-up (waiting on US-001)") {
+		t.Fatalf("expected blocked story in status output, got: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "Next: US-001 - Base") {
+		t.Fatalf("expected next story in status output, got: %s", out.String())
+	}
+}
+
+func TestRunReviewListShowsSources(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	reviewDir := project.ReviewPerspectivesPath(baseDir)
+	if err := os.MkdirAll(reviewDir, 0o755); err != nil {
+		t.Fatalf("mkdir review dir: %v", err)
+	}
+	content := "---\nprovider: claude\nmodel: claude-haiku\n---\n- Keyboard navigation works.\n"
+	if err := os.WriteFile(filepath.Join(reviewDir, "accessibility.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write perspective: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.Review.Perspectives = []string{"security", "accessibility", "api-compat"}
+
+	var out bytes.Buffer
+	application := App{version: "test", in: strings.NewReader(""), out: &out}
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := application.runReview(cfg, configPath, baseDir, []string{"list"}); err != nil {
+		t.Fatalf("run review list: %v", err)
+	}
+
+	text := out.String()
+	for _, fragment := range []string{
+		"- security [built-in]",
+		"- accessibility [project] " + filepath.Join(reviewDir, "accessibility.md") + " provider=claude model=claude-haiku",
+		"- api-compat [fallback]",
+		"user: " + filepath.Join(filepath.Dir(configPath), "review"),
+	} {
+		if !strings.Contains(text, fragment) {
+			t.Fatalf("expected %q in output, got:\n%s", fragment, text)
+		}
+	}
+
+	if err := application.runReview(cfg, configPath, baseDir, []string{"bogus"}); err == nil {
+		t.Fatal("expected error for unknown review subcommand")
+	}
+}
+
+func TestRunPRBodyRendersConfiguredTemplate(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := prd.NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	if err := store.Update("main", func(doc *prd.Document) error {
+		doc.UserStories[0].Passes = true
+		return nil
+	}); err != nil {
+		t.Fatalf("update PRD: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(baseDir, "pr.tmpl"), []byte("PRD {{ .PRD }}\n{{ range .Stories }}- {{ .ID }}\n{{ end }}"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	cfg := config.Defaults()
+	var out bytes.Buffer
+	application := App{version: "test", in: strings.NewReader(""), out: &out}
+	if err := application.runPRBody(store, cfg, baseDir, []string{"main"}); err != nil {
+		t.Fatalf("run pr-body: %v", err)
+	}
+	if !strings.Contains(out.String(), "### US-001: Define first implementation story") {
+		t.Fatalf("expected the built-in template, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	cfg.Completion.PRBodyTemplate = "pr.tmpl"
+	if err := application.runPRBody(store, cfg, baseDir, []string{"main"}); err != nil {
+		t.Fatalf("run pr-body: %v", err)
+	}
+	if out.String() != "PRD main\n- US-001\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	cfg.Completion.PRBodyTemplate = "missing.tmpl"
+	if err := application.runPRBody(store, cfg, baseDir, []string{"main"}); err == nil {
+		t.Fatal("expected an error for a missing template")
+	}
+}
+
+func TestRunUsageShowsTotalsPerStoryAndProvider(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := prd.NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	events := strings.Join([]string{
+		`{"type":"usage","storyID":"US-001","provider":"codex","inputTokens":1000,"outputTokens":200,"costUSD":0.5}`,
+		`{"type":"assistant_text","storyID":"US-001","message":"done"}`,
+		`{"type":"usage","storyID":"US-002","provider":"claude","inputTokens":400,"outputTokens":100,"costUSD":0.25,"estimated":true}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(project.PRDEventsPath(baseDir, "main"), []byte(events), 0o644); err != nil {
+		t.Fatalf("write events: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.Budget.MaxUSD = 5
+	var out bytes.Buffer
+	application := App{version: "test", in: strings.NewReader(""), out: &out}
+	if err := application.runUsage(store, cfg, baseDir, []string{"main"}); err != nil {
+		t.Fatalf("run usage: %v", err)
+	}
+
+	text := out.String()
+	for _, fragment := range []string{
+		"Total: 2 iterations, ~1700 tokens (1400 in / 300 out), $0.7500",
+		"Budget: $0.7500 of $5.00 used",
+		"  US-001  1 iteration, 1200 tokens (1000 in / 200 out), $0.5000",
+		"  claude  1 iteration, ~500 tokens (400 in / 100 out), $0.2500",
+	} {
+		if !strings.Contains(text, fragment) {
+			t.Fatalf("expected %q in output, got:\n%s", fragment, text)
+		}
+	}
+}
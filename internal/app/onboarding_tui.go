@@ -443,20 +443,31 @@ func (m onboardingModel) buildScanCmd() tea.Cmd {
 	description := m.textInput
 
 	return func() tea.Msg {
+		phase := cfg.Phases.Scan
+		providerName := cfg.Provider.Default
+		if strings.TrimSpace(phase.Provider) != "" {
+			providerName = strings.TrimSpace(phase.Provider)
+		}
 		registry := providers.NewRegistry()
-		provider, err := registry.Resolve(cfg.Provider.Default, cfg)
+		provider, err := registry.Resolve(providerName, cfg)
 		if err != nil {
-			return onboardingScanResultMsg{err: fmt.Errorf("provider unavailable (%s): %w; press s to skip", cfg.Provider.Default, err)}
+			return onboardingScanResultMsg{err: fmt.Errorf("provider unavailable (%s): %w; press s to skip", providerName, err)}
 		}
 
 		prompt := buildOnboardingScanPrompt(description)
 		contextFiles := findOnboardingContextFiles(baseDir)
 
+		approvalPolicy := "never"
+		if strings.TrimSpace(phase.ApprovalPolicy) != "" {
+			approvalPolicy = strings.TrimSpace(phase.ApprovalPolicy)
+		}
 		req := providers.IterationRequest{
 			WorkDir:        baseDir,
 			Prompt:         prompt,
 			ContextFiles:   contextFiles,
-			ApprovalPolicy: "never",
+			ApprovalPolicy: approvalPolicy,
+			SandboxPolicy:  strings.TrimSpace(phase.SandboxPolicy),
+			Model:          strings.TrimSpace(phase.Model),
 		}
 
 		eventCh, _, runErr := provider.RunIteration(ctx, req)
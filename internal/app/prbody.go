@@ -0,0 +1,56 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+	"github.com/EstebanForge/daedalus/internal/loop"
+	"github.com/EstebanForge/daedalus/internal/prd"
+)
+
+// runPRBody prints the pull request body auto_pr_on_complete would open the
+// PRD's pull request with, for teams that open pull requests by hand.
+func (a App) runPRBody(store prd.Store, cfg config.Config, baseDir string, args []string) error {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	name, err := store.ResolveName(name)
+	if err != nil {
+		return err
+	}
+	tmpl, err := loadPRBodyTemplate(cfg, baseDir)
+	if err != nil {
+		return err
+	}
+	data, err := loop.LoadPRBodyData(store, baseDir, name)
+	if err != nil {
+		return err
+	}
+	body, err := loop.RenderPRBody(tmpl, data)
+	if err != nil {
+		return err
+	}
+	a.writef("%s", body)
+	return nil
+}
+
+// loadPRBodyTemplate reads completion.pr_body_template, resolved against
+// baseDir. It returns "" for the built-in template.
+func loadPRBodyTemplate(cfg config.Config, baseDir string) (string, error) {
+	path := strings.TrimSpace(cfg.Completion.PRBodyTemplate)
+	if path == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("completion.pr_body_template: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// reviewPerspectiveDirs returns where review perspective files are looked up:
+// .daedalus/review in the project, then a review directory next to the config
+// file.
+func reviewPerspectiveDirs(baseDir, configPath string) quality.PerspectiveDirs {
+	dirs := quality.PerspectiveDirs{Project: project.ReviewPerspectivesPath(baseDir)}
+	if strings.TrimSpace(configPath) != "" {
+		dirs.User = filepath.Join(filepath.Dir(configPath), project.ReviewDirectory)
+	}
+	return dirs
+}
+
+func (a App) runReview(cfg config.Config, configPath, baseDir string, args []string) error {
+	subcommand := "list"
+	if len(args) > 0 && strings.TrimSpace(args[0]) != "" {
+		subcommand = strings.ToLower(strings.TrimSpace(args[0]))
+	}
+
+	switch subcommand {
+	case "list":
+		return a.runReviewList(cfg, reviewPerspectiveDirs(baseDir, configPath))
+	default:
+		return fmt.Errorf("unknown review subcommand: %s", subcommand)
+	}
+}
+
+func (a App) runReviewList(cfg config.Config, dirs quality.PerspectiveDirs) error {
+	configured, err := quality.ResolvePerspectives(dirs, cfg.Review.Perspectives)
+	if err != nil {
+		return err
+	}
+	available, err := quality.AvailablePerspectives(dirs)
+	if err != nil {
+		return err
+	}
+
+	state := "enabled"
+	if !cfg.Review.Enabled {
+		state = "disabled"
+	}
+	a.writef("Review: %s (fail_on=%s, remediation_rounds=%d)\n", state, cfg.Review.FailOn, cfg.Review.RemediationRounds)
+	a.writef("Search paths:\n  project: %s\n  user: %s\n", dirs.Project, displayPath(dirs.User))
+
+	a.writeLine("Configured perspectives:")
+	if len(configured) == 0 {
+		a.writeLine("(none)")
+	}
+	for _, perspective := range configured {
+		a.writeLine(formatPerspectiveLine(perspective))
+	}
+
+	a.writeLine("Available perspectives:")
+	for _, perspective := range available {
+		a.writeLine(formatPerspectiveLine(perspective))
+	}
+	return nil
+}
+
+func formatPerspectiveLine(perspective quality.Perspective) string {
+	builder := strings.Builder{}
+	builder.WriteString("- ")
+	builder.WriteString(perspective.Name)
+	builder.WriteString(" [")
+	builder.WriteString(perspective.Source)
+	builder.WriteString("]")
+	if perspective.Path != "" {
+		builder.WriteString(" ")
+		builder.WriteString(perspective.Path)
+	}
+	if perspective.Provider != "" {
+		builder.WriteString(" provider=")
+		builder.WriteString(perspective.Provider)
+	}
+	if perspective.Model != "" {
+		builder.WriteString(" model=")
+		builder.WriteString(perspective.Model)
+	}
+	if perspective.Source == quality.PerspectiveSourceFallback {
+		builder.WriteString(" (no definition found; uses a generic code-quality focus)")
+	} else if perspective.Description != "" {
+		builder.WriteString(" - ")
+		builder.WriteString(perspective.Description)
+	}
+	return builder.String()
+}
+
+func displayPath(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return "(none)"
+	}
+	return path
+}
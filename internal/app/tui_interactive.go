@@ -121,6 +121,10 @@ func (m interactiveTUIModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	snap := m.state.snapshot()
 	view := strings.TrimSpace(strings.ToLower(snap.view))
 
+	if snap.permission != nil && m.handlePermissionKey(key) {
+		return m, nil
+	}
+
 	if view == "help" {
 		switch key {
 		case "?", "esc", "q", "ctrl+c":
@@ -540,7 +544,7 @@ func (m interactiveTUIModel) cyclePRD(step int) {
 }
 
 func (m interactiveTUIModel) cycleProvider(step int) {
-	keys := []string{"codex", "claude", "gemini", "opencode", "copilot", "qwen", "pi"}
+	keys := []string{"codex", "claude", "gemini", "opencode", "copilot", "qwen", "pi", "mock"}
 	current := strings.ToLower(strings.TrimSpace(m.state.snapshot().provider))
 	if current != "" && !slices.Contains(keys, current) {
 		keys = append(keys, current)
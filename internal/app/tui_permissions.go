@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+const tuiPermissionShortcutLine = "Keys: y allow | Y allow for session | n deny | N deny for session | q quit"
+
+// tuiPermissionPrompt is a permission request waiting for the user.
+type tuiPermissionPrompt struct {
+	request providers.PermissionRequest
+	reply   chan providers.PermissionDecision
+}
+
+// tuiApprover answers what the [permissions] policy decides and asks the user
+// about the rest through a modal. "Remember" answers are reused for the same
+// request for the rest of the agent session; see tuiPermissionKey.
+type tuiApprover struct {
+	policy providers.PermissionPolicy
+	state  *tuiState
+
+	mu         sync.Mutex
+	remembered map[string]providers.PermissionDecision
+}
+
+func newTUIApprover(policy providers.PermissionPolicy, state *tuiState) *tuiApprover {
+	return &tuiApprover{
+		policy:     policy,
+		state:      state,
+		remembered: map[string]providers.PermissionDecision{},
+	}
+}
+
+func (a *tuiApprover) RequestPermission(ctx context.Context, request providers.PermissionRequest) (providers.PermissionDecision, error) {
+	if decision, matched := a.policy.Evaluate(request); matched {
+		return decision, nil
+	}
+
+	key := tuiPermissionKey(request)
+	a.mu.Lock()
+	remembered, ok := a.remembered[key]
+	a.mu.Unlock()
+	if ok {
+		remembered.Source = providers.PermissionSourceRemembered
+		return remembered, nil
+	}
+
+	prompt := &tuiPermissionPrompt{request: request, reply: make(chan providers.PermissionDecision, 1)}
+	a.state.enqueuePermission(prompt)
+	a.state.setActivity("Agent requests permission: " + request.Subject())
+
+	select {
+	case decision := <-prompt.reply:
+		if decision.Remember {
+			a.mu.Lock()
+			a.remembered[key] = decision
+			a.mu.Unlock()
+		}
+		return decision, nil
+	case <-ctx.Done():
+		a.state.dropPermission(prompt)
+		return providers.PermissionDecision{}, ctx.Err()
+	}
+}
+
+// tuiPermissionKey identifies the requests a "remember" answer covers: the
+// same command line, the same resolved paths, or, for other tool calls, the
+// same title, of the same kind in the same session.
+func tuiPermissionKey(request providers.PermissionRequest) string {
+	parts := []string{request.SessionID, request.Kind}
+	switch {
+	case strings.TrimSpace(request.Command) != "":
+		parts = append(parts, "command", strings.TrimSpace(request.Command))
+	case len(request.Paths) > 0:
+		paths := request.ResolvedPaths()
+		sort.Strings(paths)
+		parts = append(parts, "paths")
+		parts = append(parts, paths...)
+	default:
+		parts = append(parts, "title", request.Title)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func (s *tuiState) enqueuePermission(prompt *tuiPermissionPrompt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.permissions = append(s.permissions, prompt)
+}
+
+func (s *tuiState) dropPermission(prompt *tuiPermissionPrompt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index, pending := range s.permissions {
+		if pending == prompt {
+			s.permissions = append(s.permissions[:index], s.permissions[index+1:]...)
+			return
+		}
+	}
+}
+
+// answerPermission resolves the oldest pending prompt. It reports false when
+// nothing is pending.
+func (s *tuiState) answerPermission(allow, remember bool) (providers.PermissionRequest, bool) {
+	s.mu.Lock()
+	if len(s.permissions) == 0 {
+		s.mu.Unlock()
+		return providers.PermissionRequest{}, false
+	}
+	prompt := s.permissions[0]
+	s.permissions = s.permissions[1:]
+	s.mu.Unlock()
+
+	reason := "approved in TUI"
+	if !allow {
+		reason = "denied in TUI"
+	}
+	prompt.reply <- providers.PermissionDecision{
+		Allow:    allow,
+		Remember: remember,
+		Source:   providers.PermissionSourceUser,
+		Reason:   reason,
+	}
+	return prompt.request, true
+}
+
+func tuiPermissionLines(state tuiSnapshot) []string {
+	request := state.permission
+	lines := []string{
+		"The agent is asking for permission:",
+		"",
+		"  " + request.Subject(),
+		"",
+		fmt.Sprintf("Kind: %s", tuiFallbackText(request.Kind, "unknown")),
+	}
+	if request.Command != "" {
+		lines = append(lines, "Command: "+request.Command)
+	}
+	for _, path := range request.Paths {
+		lines = append(lines, "Path: "+path)
+	}
+	lines = append(lines, "", "Press y/Y to allow (once/session) or n/N to deny.", "At the command prompt: allow|deny [session].")
+	if state.permissionQueue > 1 {
+		lines = append(lines, fmt.Sprintf("%d more request(s) waiting.", state.permissionQueue-1))
+	}
+	return lines
+}
+
+// handlePermissionKey answers the pending prompt. Keys other than the answers
+// and quit are swallowed while the modal is open.
+func (m interactiveTUIModel) handlePermissionKey(key string) (handled bool) {
+	var allow, remember bool
+	switch key {
+	case "y":
+		allow = true
+	case "Y":
+		allow, remember = true, true
+	case "n", "esc":
+	case "N":
+		remember = true
+	case "ctrl+c", "q":
+		return false
+	default:
+		return true
+	}
+
+	request, ok := m.state.answerPermission(allow, remember)
+	if !ok {
+		return true
+	}
+	verdict := "Denied"
+	if allow {
+		verdict = "Allowed"
+	}
+	if remember {
+		verdict += " for this session"
+	}
+	m.state.setActivity(fmt.Sprintf("%s: %s", verdict, strings.TrimSpace(request.Subject())))
+	return true
+}
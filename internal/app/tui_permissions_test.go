@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+func waitForPendingPermission(t *testing.T, state *tuiState) tuiSnapshot {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if snap := state.snapshot(); snap.permission != nil {
+			return snap
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected a pending permission request")
+	return tuiSnapshot{}
+}
+
+func TestTUIApproverAsksAndRemembersForSession(t *testing.T) {
+	t.Parallel()
+
+	state := &tuiState{view: "dashboard"}
+	approver := newTUIApprover(providers.PermissionPolicy{DenyPatterns: []string{"git push --force*"}}, state)
+	model := interactiveTUIModel{ctx: context.Background(), state: state}
+	request := providers.PermissionRequest{SessionID: "s-1", Kind: "execute", Title: "Run tests", Command: "go test ./..."}
+
+	decision, err := approver.RequestPermission(context.Background(), providers.PermissionRequest{SessionID: "s-1", Kind: "execute", Command: "git push --force"})
+	if err != nil || decision.Allow || decision.Source != providers.PermissionSourcePolicy {
+		t.Fatalf("expected policy denial without asking, got %+v (%v)", decision, err)
+	}
+
+	answered := make(chan providers.PermissionDecision, 1)
+	go func() {
+		decision, _ := approver.RequestPermission(context.Background(), request)
+		answered <- decision
+	}()
+	snap := waitForPendingPermission(t, state)
+	if title, lines := tuiMainPanel(snap, config.Defaults(), "", nil, "", prd.Document{}, false, nil); title != "Permission Request" || !strings.Contains(strings.Join(lines, "\n"), "go test ./...") {
+		t.Fatalf("expected permission modal, got %q: %v", title, lines)
+	}
+
+	if _, cmd := model.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")}); cmd != nil || state.snapshot().view != "dashboard" {
+		t.Fatal("expected other keys to be swallowed while the modal is open")
+	}
+	model.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Y")})
+	decision = <-answered
+	if !decision.Allow || !decision.Remember || decision.Source != providers.PermissionSourceUser {
+		t.Fatalf("unexpected user decision: %+v", decision)
+	}
+
+	decision, err = approver.RequestPermission(context.Background(), request)
+	if err != nil || !decision.Allow || decision.Source != providers.PermissionSourceRemembered {
+		t.Fatalf("expected remembered decision, got %+v (%v)", decision, err)
+	}
+}
+
+func TestTUIApproverRemembersOnlyTheSameRequest(t *testing.T) {
+	t.Parallel()
+
+	state := &tuiState{}
+	approver := newTUIApprover(providers.PermissionPolicy{}, state)
+	model := interactiveTUIModel{ctx: context.Background(), state: state}
+	ask := func(request providers.PermissionRequest, key string) providers.PermissionDecision {
+		t.Helper()
+		answered := make(chan providers.PermissionDecision, 1)
+		go func() {
+			decision, _ := approver.RequestPermission(context.Background(), request)
+			answered <- decision
+		}()
+		waitForPendingPermission(t, state)
+		model.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return <-answered
+	}
+
+	test := providers.PermissionRequest{SessionID: "s-1", Kind: "execute", Title: "Run command", Command: "go test ./..."}
+	if decision := ask(test, "Y"); !decision.Allow || !decision.Remember {
+		t.Fatalf("expected a remembered approval, got %+v", decision)
+	}
+	if decision, err := approver.RequestPermission(context.Background(), test); err != nil || decision.Source != providers.PermissionSourceRemembered {
+		t.Fatalf("expected the same command to be remembered, got %+v (%v)", decision, err)
+	}
+	remove := providers.PermissionRequest{SessionID: "s-1", Kind: "execute", Title: "Run command", Command: "rm -rf ."}
+	if decision := ask(remove, "n"); decision.Allow || decision.Source != providers.PermissionSourceUser {
+		t.Fatalf("expected a different command to be asked about, got %+v", decision)
+	}
+
+	edit := providers.PermissionRequest{SessionID: "s-1", WorkDir: "/repo", Kind: "edit", Title: "Edit", Paths: []string{"main.go"}}
+	ask(edit, "Y")
+	same := edit
+	same.Paths = []string{"/repo/main.go"}
+	if decision, err := approver.RequestPermission(context.Background(), same); err != nil || decision.Source != providers.PermissionSourceRemembered {
+		t.Fatalf("expected the same resolved path to be remembered, got %+v (%v)", decision, err)
+	}
+	other := edit
+	other.Paths = []string{"config.go"}
+	if decision := ask(other, "n"); decision.Allow || decision.Source != providers.PermissionSourceUser {
+		t.Fatalf("expected another path to be asked about, got %+v", decision)
+	}
+}
+
+func TestTUIApproverDropsPromptOnCancel(t *testing.T) {
+	t.Parallel()
+
+	state := &tuiState{}
+	approver := newTUIApprover(providers.PermissionPolicy{}, state)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := approver.RequestPermission(ctx, providers.PermissionRequest{Kind: "edit"})
+		done <- err
+	}()
+	waitForPendingPermission(t, state)
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("expected cancellation error")
+	}
+	if state.snapshot().permission != nil {
+		t.Fatal("expected cancelled prompt to be removed")
+	}
+	if _, ok := state.answerPermission(true, false); ok {
+		t.Fatal("expected nothing left to answer")
+	}
+}
@@ -272,7 +272,11 @@ func (a App) buildStructuredTUIView(
 	content := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, rightPanel)
 
 	activity := tuiRenderActivityLine(theme, width, state)
-	shortcuts := theme.shortcuts.Width(width).Render(tuiShortcutLine(state.view))
+	shortcutLine := tuiShortcutLine(state.view)
+	if state.permission != nil {
+		shortcutLine = tuiPermissionShortcutLine
+	}
+	shortcuts := theme.shortcuts.Width(width).Render(shortcutLine)
 
 	return lipgloss.JoinVertical(lipgloss.Left, header, tabs, rule, content, rule, activity, shortcuts)
 }
@@ -285,6 +289,9 @@ func tuiRenderHeaderLine(theme tuiTheme, width int, cfg config.Config, state tui
 	if provider == "" {
 		provider = "unknown"
 	}
+	if state.fallbackTo != "" {
+		provider = fmt.Sprintf("%s (fallback from %s)", state.fallbackTo, state.fallbackFrom)
+	}
 
 	stateLabel := tuiStateBadge(theme, strings.TrimSpace(state.loopState))
 	left := lipgloss.JoinHorizontal(lipgloss.Center, theme.brand.Render("daedalus"), " ", stateLabel)
@@ -485,6 +492,9 @@ func tuiMainPanel(
 	docLoaded bool,
 	docErr error,
 ) (string, []string) {
+	if state.permission != nil {
+		return "Permission Request", tuiPermissionLines(state)
+	}
 	switch strings.TrimSpace(strings.ToLower(state.view)) {
 	case "stories":
 		return "Story Details", tuiStoryDetailsLines(state, doc, docLoaded, docErr)
@@ -838,7 +848,7 @@ func tuiSettingsLines(cfg config.Config, state tuiSnapshot) []string {
 		fmt.Sprintf("Worktree mode: %t", cfg.Worktree.Enabled),
 		fmt.Sprintf("Retry max: %d", cfg.Retry.MaxRetries),
 		fmt.Sprintf("Retry delays: %s", strings.Join(cfg.Retry.Delays, ", ")),
-		fmt.Sprintf("Quality commands: %s", strings.Join(cfg.Quality.Commands, " ; ")),
+		formatQualityCommands(cfg.Quality),
 		"",
 		"Compound Engineering:",
 		fmt.Sprintf("  [1] Plan phase:        %s (key: 1 to toggle)", boolYesNo(state.planEnabled)),
@@ -866,6 +876,7 @@ func tuiProviderStatusLines(cfg config.Config, active string) []string {
 		{name: "copilot", enabled: cfg.Providers.Copilot.Enabled, model: cfg.Providers.Copilot.Model},
 		{name: "qwen", enabled: cfg.Providers.Qwen.Enabled, model: cfg.Providers.Qwen.Model},
 		{name: "pi", enabled: cfg.Providers.Pi.Enabled, model: cfg.Providers.Pi.Model},
+		{name: "mock", enabled: cfg.Providers.Mock.Enabled, model: cfg.Providers.Mock.Model},
 	}
 
 	normalizedActive := strings.ToLower(strings.TrimSpace(active))
@@ -1078,3 +1089,16 @@ func tuiShouldUseANSI(writer io.Writer) bool {
 	}
 	return info.Mode()&os.ModeCharDevice != 0
 }
+
+// formatQualityCommands lists the quality commands, or the names of the
+// quality.checks table when one is configured.
+func formatQualityCommands(cfg config.QualityConfig) string {
+	if len(cfg.Checks) == 0 {
+		return fmt.Sprintf("Quality commands: %s", strings.Join(cfg.Commands, " ; "))
+	}
+	names := make([]string, 0, len(cfg.Checks))
+	for _, check := range cfg.Checks {
+		names = append(names, check.Name)
+	}
+	return fmt.Sprintf("Quality checks: %s", strings.Join(names, ", "))
+}
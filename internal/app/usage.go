@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+	"github.com/EstebanForge/daedalus/internal/loop"
+	"github.com/EstebanForge/daedalus/internal/prd"
+)
+
+// runUsage prints a PRD's token usage and cost per story and per provider,
+// read from the usage events in its events.jsonl.
+func (a App) runUsage(store prd.Store, cfg config.Config, baseDir string, args []string) error {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	name, err := store.ResolveName(name)
+	if err != nil {
+		return err
+	}
+	report, err := loop.LoadUsageReport(baseDir, name)
+	if err != nil {
+		return err
+	}
+
+	a.writef("PRD: %s\n", name)
+	a.writef("Total: %s\n", formatUsageTotal(report.Total))
+	if cfg.Budget.MaxUSD > 0 {
+		a.writef("Budget: $%.4f of $%.2f used\n", report.Total.Usage.CostUSD, cfg.Budget.MaxUSD)
+	}
+	if report.Total.Iterations == 0 {
+		a.writeLine("No usage recorded yet.")
+		return nil
+	}
+
+	a.writeLine("By story:")
+	for _, total := range report.ByStory {
+		a.writef("  %s  %s\n", total.Key, formatUsageTotal(total))
+	}
+	a.writeLine("By provider:")
+	for _, total := range report.ByProvider {
+		a.writef("  %s  %s\n", total.Key, formatUsageTotal(total))
+	}
+	if report.Total.Usage.Estimated || report.Total.Usage.CostEstimated {
+		a.writeLine("~ marks totals that include estimates.")
+	}
+	return nil
+}
+
+func formatUsageTotal(total loop.UsageTotal) string {
+	usage := total.Usage
+	tokens := fmt.Sprintf("%d tokens (%d in / %d out)", usage.TotalTokens(), usage.InputTokens, usage.OutputTokens)
+	if usage.Estimated {
+		tokens = "~" + tokens
+	}
+	cost := fmt.Sprintf("$%.4f", usage.CostUSD)
+	if usage.CostEstimated {
+		cost = "~" + cost
+	}
+	iterations := "iterations"
+	if total.Iterations == 1 {
+		iterations = "iteration"
+	}
+	return strings.Join([]string{fmt.Sprintf("%d %s", total.Iterations, iterations), tokens, cost}, ", ")
+}
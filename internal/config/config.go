@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -22,6 +24,128 @@ type Config struct {
 	Plan       PlanConfig       `toml:"plan"`
 	Review     ReviewConfig     `toml:"review"`
 	Compound   CompoundConfig   `toml:"compound"`
+	// Permissions auto-answers agent permission requests. The TUI asks the
+	// user about requests the policy does not decide.
+	Permissions PermissionsConfig `toml:"permissions"`
+	// Phases routes individual phases to a different provider or model.
+	Phases PhasesConfig `toml:"phases"`
+	// Context limits the context files sent to the agent with each prompt.
+	Context ContextConfig `toml:"context"`
+	// Budget stops the loop once a PRD has cost more than allowed.
+	Budget BudgetConfig `toml:"budget"`
+	// Timeouts bound how long each phase may run.
+	Timeouts TimeoutsConfig `toml:"timeouts"`
+	// Rollback decides what happens to a failed story's changes.
+	Rollback RollbackConfig `toml:"rollback"`
+	// Forge is where auto_pr_on_complete opens pull requests.
+	Forge ForgeConfig `toml:"forge"`
+	// Secrets scans each story's changes for credentials before committing.
+	Secrets SecretsConfig `toml:"secrets"`
+}
+
+// SecretsConfig controls the secret scanner that runs before a story's
+// changes are committed.
+type SecretsConfig struct {
+	Enabled bool `toml:"enabled"`
+	// BlockPaths are path patterns added to the built-in sensitive files
+	// (.env, *.pem, id_rsa, ...).
+	BlockPaths []string `toml:"block_paths"`
+	// AllowPaths are path patterns that are neither scanned nor blocked.
+	AllowPaths []string `toml:"allow_paths"`
+	// AllowPatterns are regular expressions; a finding whose secret or line
+	// matches one is ignored.
+	AllowPatterns []string `toml:"allow_patterns"`
+}
+
+// ForgeConfig locates the forge API pull requests are opened through.
+type ForgeConfig struct {
+	// Type is github, gitlab, gitea, or auto (detected from the origin
+	// remote's host).
+	Type string `toml:"type"`
+	// URL is the API base URL, e.g. https://git.example.com/api/v1. Empty
+	// derives it from the origin remote.
+	URL string `toml:"url"`
+	// TokenEnv names the environment variable holding the API token. Empty
+	// uses GITHUB_TOKEN (or GH_TOKEN), GITLAB_TOKEN, or GITEA_TOKEN.
+	TokenEnv string `toml:"token_env"`
+	// BaseBranch is the branch pull requests target. Empty uses the remote's
+	// default branch.
+	BaseBranch string `toml:"base_branch"`
+}
+
+// RollbackConfig controls the working-tree snapshot taken before each story's
+// work phase.
+type RollbackConfig struct {
+	// OnFailure is keep (leave the changes in place), restore (discard them),
+	// or park (commit them to daedalus/failed/<story>, then restore).
+	OnFailure string `toml:"on_failure"`
+}
+
+// TimeoutsConfig holds wall-clock limits as Go durations ("20m", "90s").
+// Empty or "0s" disables a limit.
+type TimeoutsConfig struct {
+	// Plan, Work, and Review limit a single agent iteration of that phase.
+	// Repair and remediation iterations use Work.
+	Plan   string `toml:"plan"`
+	Work   string `toml:"work"`
+	Review string `toml:"review"`
+	// QualityCommand limits each quality command.
+	QualityCommand string `toml:"quality_command"`
+}
+
+type BudgetConfig struct {
+	// MaxUSD is the most a PRD may cost across runs, as recorded in its
+	// events.jsonl. Zero means no limit.
+	MaxUSD float64 `toml:"max_usd"`
+}
+
+// ContextConfig is the budget for context files. progress.md and learnings
+// are cut first when it is exceeded. Zero means no limit.
+type ContextConfig struct {
+	MaxBytes int `toml:"max_bytes"`
+	// MaxTokens is estimated at four bytes per token. When both limits are
+	// set the smaller one applies.
+	MaxTokens int `toml:"max_tokens"`
+}
+
+// PhasesConfig holds per-phase overrides. Empty fields fall back to the
+// phase's provider settings under [providers.<key>].
+type PhasesConfig struct {
+	Plan   PhaseConfig `toml:"plan"`
+	Work   PhaseConfig `toml:"work"`
+	Review PhaseConfig `toml:"review"`
+	Scan   PhaseConfig `toml:"scan"`
+}
+
+type PhaseConfig struct {
+	Provider       string `toml:"provider"`
+	Model          string `toml:"model"`
+	ApprovalPolicy string `toml:"approval_policy"`
+	SandboxPolicy  string `toml:"sandbox_policy"`
+}
+
+// Phase names accepted by PhasesConfig.Get.
+const (
+	PhasePlan   = "plan"
+	PhaseWork   = "work"
+	PhaseReview = "review"
+	PhaseScan   = "scan"
+)
+
+// Get returns the overrides for phase, or a zero PhaseConfig for unknown phases.
+func (p PhasesConfig) Get(phase string) PhaseConfig {
+	switch phase {
+	case PhasePlan:
+		return p.Plan
+	case PhaseWork:
+		return p.Work
+	case PhaseReview:
+		return p.Review
+	case PhaseScan:
+		return p.Scan
+	default:
+		return PhaseConfig{}
+	}
 }
 
 type PlanConfig struct {
@@ -31,6 +155,26 @@ type PlanConfig struct {
 type ReviewConfig struct {
 	Enabled      bool     `toml:"enabled"`
 	Perspectives []string `toml:"perspectives"`
+	// RemediationRounds is how many times review findings are sent back to the
+	// agent for fixing before the story fails. Zero disables remediation.
+	RemediationRounds int `toml:"remediation_rounds"`
+	// FailOn is the lowest finding severity that fails the review:
+	// critical, high, medium, low, or info.
+	FailOn string `toml:"fail_on"`
+}
+
+type PermissionsConfig struct {
+	// AllowTools lists ACP tool kinds (read, edit, execute, ...) or title
+	// globs that are allowed without asking.
+	AllowTools []string `toml:"allow_tools"`
+	// AllowPaths lists path globs, relative to the work dir, that allowed
+	// tools may touch. Empty means any path inside the work dir.
+	AllowPaths []string `toml:"allow_paths"`
+	// DenyPatterns are globs matched against commands, titles, and paths.
+	// They win over the allow lists.
+	DenyPatterns []string `toml:"deny_patterns"`
+	// Default answers requests that no rule matches: allow or deny.
+	Default string `toml:"default"`
 }
 
 type CompoundConfig struct {
@@ -41,10 +185,21 @@ type CompoundConfig struct {
 type CompletionConfig struct {
 	PushOnComplete   bool `toml:"push_on_complete"`
 	AutoPROnComplete bool `toml:"auto_pr_on_complete"`
+	// PRBodyTemplate is a text/template file for pull request bodies, relative
+	// to the project root. Empty uses the built-in template.
+	PRBodyTemplate string `toml:"pr_body_template"`
+	// PRDraft opens pull requests as drafts.
+	PRDraft bool `toml:"pr_draft"`
+	// PRLabels and PRReviewers are added to new pull requests.
+	PRLabels    []string `toml:"pr_labels"`
+	PRReviewers []string `toml:"pr_reviewers"`
 }
 
 type ProviderConfig struct {
 	Default string `toml:"default"`
+	// Fallback lists providers tried in order when the active one fails with
+	// an authentication error or stays rate limited after all retries.
+	Fallback []string `toml:"fallback"`
 }
 
 type RetryConfig struct {
@@ -53,9 +208,64 @@ type RetryConfig struct {
 }
 
 type QualityConfig struct {
+	// Commands run one after another. Checks, when set, replaces them.
 	Commands []string `toml:"commands"`
+	// RepairRounds is how many times failing checks are sent back to the agent
+	// for fixing before the story fails. Zero disables repair.
+	RepairRounds int `toml:"repair_rounds"`
+	// Checks declares the quality commands as a table ([[quality.checks]]).
+	// Checks without pending dependencies run at the same time.
+	Checks []QualityCheckConfig `toml:"checks"`
+	// Concurrency caps how many checks run at once. Zero means 4.
+	Concurrency int `toml:"concurrency"`
+	// Coverage gates stories on the coverage profiles the checks write.
+	Coverage CoverageConfig `toml:"coverage"`
+	// FullEvery runs checks scoped to changes on everything every N
+	// stories. The last story of a PRD always does. Zero disables it.
+	FullEvery int `toml:"full_every"`
+}
+
+// CoverageConfig is the coverage gate ([quality.coverage]).
+type CoverageConfig struct {
+	// Profiles are the Go cover profiles and LCOV files a quality command
+	// writes, relative to the work dir; glob patterns are allowed. Empty
+	// disables the gate.
+	Profiles []string `toml:"profiles"`
+	// Min is the lowest total coverage in percent. Zero disables it.
+	Min float64 `toml:"min"`
+	// MaxDrop is how many percentage points coverage may fall during a
+	// story. Zero disables it.
+	MaxDrop float64 `toml:"max_drop"`
+}
+
+// QualityCheckConfig is one row of the quality.checks table.
+type QualityCheckConfig struct {
+	Name    string `toml:"name"`
+	Command string `toml:"command"`
+	// Group lets depends_on refer to several checks at once.
+	Group string `toml:"group"`
+	// DependsOn names checks or groups that must finish first; the check is
+	// skipped when one of them fails.
+	DependsOn []string `toml:"depends_on"`
+	// Timeout overrides timeouts.quality_command for this check.
+	Timeout string `toml:"timeout"`
+	// AllowFailure reports a failure without failing the gate.
+	AllowFailure bool `toml:"allow_failure"`
+	// Format parses the command's test output into per-test results:
+	// go-test-json, junit, or tap.
+	Format string `toml:"format"`
+	// Report is the file the command writes its test output to, relative
+	// to the work dir. Stdout is parsed when it is empty.
+	Report string `toml:"report"`
+	// Scope is "all" (default) or "changed": the {{changed_files}} and
+	// {{changed_packages}} placeholders then get what the story changed.
+	Scope string `toml:"scope"`
 }
 
+// DefaultQualityConcurrency is how many quality checks run at once when
+// quality.concurrency is not set.
+const DefaultQualityConcurrency = 4
+
 type WorktreeConfig struct {
 	Enabled bool `toml:"enabled"`
 }
@@ -72,6 +282,8 @@ type ProvidersConfig struct {
 	Copilot  GenericProviderConfig `toml:"copilot"`
 	Qwen     GenericProviderConfig `toml:"qwen"`
 	Pi       GenericProviderConfig `toml:"pi"`
+	// Mock is the built-in scripted agent used for offline runs and tests.
+	Mock GenericProviderConfig `toml:"mock"`
 }
 
 type GenericProviderConfig struct {
@@ -80,6 +292,13 @@ type GenericProviderConfig struct {
 	ApprovalPolicy string `toml:"approval_policy"`
 	SandboxPolicy  string `toml:"sandbox_policy"`
 	ACPCommand     string `toml:"acp_command"`
+	// Scenario is the YAML or JSON script replayed by the mock provider.
+	// Other providers ignore it.
+	Scenario string `toml:"scenario"`
+	// InputUSDPerMTok and OutputUSDPerMTok price iterations, in USD per
+	// million tokens, when the agent does not report a cost itself.
+	InputUSDPerMTok  float64 `toml:"input_usd_per_mtok"`
+	OutputUSDPerMTok float64 `toml:"output_usd_per_mtok"`
 }
 
 func Defaults() Config {
@@ -92,7 +311,8 @@ func Defaults() Config {
 			Delays:     []string{"0s", "5s", "15s"},
 		},
 		Quality: QualityConfig{
-			Commands: []string{"go test ./..."},
+			Commands:     []string{"go test ./..."},
+			RepairRounds: 2,
 		},
 		Worktree: WorktreeConfig{
 			Enabled: false,
@@ -128,10 +348,40 @@ func Defaults() Config {
 				"performance",
 				"complexity",
 			},
+			RemediationRounds: 1,
+			FailOn:            "low",
 		},
 		Compound: CompoundConfig{
 			Enabled: true,
 		},
+		Permissions: PermissionsConfig{
+			DenyPatterns: []string{
+				"git push --force*",
+				"git push -f*",
+				"git reset --hard*",
+				"rm -rf /*",
+				"rm -rf ~*",
+			},
+			Default: "allow",
+		},
+		Context: ContextConfig{
+			MaxBytes: 256 * 1024,
+		},
+		Timeouts: TimeoutsConfig{
+			Plan:           "15m",
+			Work:           "45m",
+			Review:         "15m",
+			QualityCommand: "20m",
+		},
+		Rollback: RollbackConfig{
+			OnFailure: "keep",
+		},
+		Forge: ForgeConfig{
+			Type: "auto",
+		},
+		Secrets: SecretsConfig{
+			Enabled: true,
+		},
 	}
 }
 
@@ -182,6 +432,12 @@ func Validate(cfg Config) error {
 		return fmt.Errorf("provider.default is required")
 	}
 
+	for _, fallback := range cfg.Provider.Fallback {
+		if strings.TrimSpace(fallback) == "" {
+			return fmt.Errorf("provider.fallback must not contain empty values")
+		}
+	}
+
 	if cfg.Retry.MaxRetries < 0 {
 		return fmt.Errorf("retry.max_retries must be >= 0")
 	}
@@ -204,6 +460,110 @@ func Validate(cfg Config) error {
 			return fmt.Errorf("quality.commands must not contain empty values")
 		}
 	}
+	if cfg.Quality.RepairRounds < 0 {
+		return fmt.Errorf("quality.repair_rounds must be >= 0")
+	}
+	for _, profile := range cfg.Quality.Coverage.Profiles {
+		if strings.TrimSpace(profile) == "" {
+			return fmt.Errorf("quality.coverage.profiles must not contain empty values")
+		}
+	}
+	if cfg.Quality.Coverage.Min < 0 || cfg.Quality.Coverage.Min > 100 {
+		return fmt.Errorf("quality.coverage.min must be between 0 and 100")
+	}
+	if cfg.Quality.Coverage.MaxDrop < 0 {
+		return fmt.Errorf("quality.coverage.max_drop must be >= 0")
+	}
+	if cfg.Quality.Concurrency < 0 {
+		return fmt.Errorf("quality.concurrency must be >= 0")
+	}
+	if cfg.Quality.FullEvery < 0 {
+		return fmt.Errorf("quality.full_every must be >= 0")
+	}
+	checkNames := map[string]struct{}{}
+	for i, check := range cfg.Quality.Checks {
+		name := strings.TrimSpace(check.Name)
+		if name == "" {
+			return fmt.Errorf("quality.checks[%d].name must not be empty", i)
+		}
+		if _, dup := checkNames[name]; dup {
+			return fmt.Errorf("quality.checks: name %q is used twice", name)
+		}
+		checkNames[name] = struct{}{}
+		if strings.TrimSpace(check.Command) == "" {
+			return fmt.Errorf("quality.checks[%d].command must not be empty", i)
+		}
+		if _, err := ParseTimeout(check.Timeout); err != nil {
+			return fmt.Errorf("quality.checks[%d].timeout: %w", i, err)
+		}
+		switch strings.TrimSpace(check.Format) {
+		case "", "go-test-json", "junit", "tap":
+		default:
+			return fmt.Errorf("quality.checks[%d].format must be one of: go-test-json, junit, tap", i)
+		}
+		if strings.TrimSpace(check.Report) != "" && strings.TrimSpace(check.Format) == "" {
+			return fmt.Errorf("quality.checks[%d].report needs a format", i)
+		}
+		switch strings.TrimSpace(check.Scope) {
+		case "", "all", "changed":
+		default:
+			return fmt.Errorf("quality.checks[%d].scope must be one of: all, changed", i)
+		}
+	}
+	if cfg.Review.RemediationRounds < 0 {
+		return fmt.Errorf("review.remediation_rounds must be >= 0")
+	}
+	switch strings.TrimSpace(strings.ToLower(cfg.Review.FailOn)) {
+	case "", "critical", "high", "medium", "low", "info":
+	default:
+		return fmt.Errorf("review.fail_on must be one of: critical, high, medium, low, info")
+	}
+
+	switch strings.TrimSpace(strings.ToLower(cfg.Permissions.Default)) {
+	case "", "allow", "deny":
+	default:
+		return fmt.Errorf("permissions.default must be one of: allow, deny")
+	}
+	for _, pattern := range cfg.Permissions.DenyPatterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("permissions.deny_patterns must not contain empty values")
+		}
+	}
+
+	for _, timeout := range []struct{ key, value string }{
+		{"timeouts.plan", cfg.Timeouts.Plan},
+		{"timeouts.work", cfg.Timeouts.Work},
+		{"timeouts.review", cfg.Timeouts.Review},
+		{"timeouts.quality_command", cfg.Timeouts.QualityCommand},
+	} {
+		if _, err := ParseTimeout(timeout.value); err != nil {
+			return fmt.Errorf("%s: %w", timeout.key, err)
+		}
+	}
+
+	switch strings.TrimSpace(strings.ToLower(cfg.Rollback.OnFailure)) {
+	case "", "keep", "restore", "park":
+	default:
+		return fmt.Errorf("rollback.on_failure must be one of: keep, restore, park")
+	}
+	switch strings.TrimSpace(strings.ToLower(cfg.Forge.Type)) {
+	case "", "auto", "github", "gitlab", "gitea":
+	default:
+		return fmt.Errorf("forge.type must be one of: auto, github, gitlab, gitea")
+	}
+	if err := validateSecrets(cfg.Secrets); err != nil {
+		return err
+	}
+
+	if cfg.Budget.MaxUSD < 0 {
+		return fmt.Errorf("budget.max_usd must be >= 0")
+	}
+	if cfg.Context.MaxBytes < 0 {
+		return fmt.Errorf("context.max_bytes must be >= 0")
+	}
+	if cfg.Context.MaxTokens < 0 {
+		return fmt.Errorf("context.max_tokens must be >= 0")
+	}
 
 	theme := strings.TrimSpace(strings.ToLower(cfg.UI.Theme))
 	if theme == "" {
@@ -222,6 +582,35 @@ func Validate(cfg Config) error {
 	return nil
 }
 
+func validateSecrets(secrets SecretsConfig) error {
+	for _, list := range []struct {
+		key      string
+		patterns []string
+	}{
+		{"secrets.block_paths", secrets.BlockPaths},
+		{"secrets.allow_paths", secrets.AllowPaths},
+	} {
+		key := list.key
+		for _, pattern := range list.patterns {
+			if strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("%s must not contain empty values", key)
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s has an invalid pattern %q: %w", key, pattern, err)
+			}
+		}
+	}
+	for _, pattern := range secrets.AllowPatterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("secrets.allow_patterns must not contain empty values")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("secrets.allow_patterns has an invalid expression %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
 func ParseRetryDelays(delays []string) ([]time.Duration, error) {
 	parsed := make([]time.Duration, 0, len(delays))
 	for _, delay := range delays {
@@ -234,6 +623,22 @@ func ParseRetryDelays(delays []string) ([]time.Duration, error) {
 	return parsed, nil
 }
 
+// ParseTimeout parses a timeout duration. Empty means no timeout.
+func ParseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("timeout %q must be >= 0", value)
+	}
+	return timeout, nil
+}
+
 func applyFallbacks(cfg *Config) {
 	defaults := Defaults()
 
@@ -254,6 +659,12 @@ func applyFallbacks(cfg *Config) {
 	if strings.TrimSpace(cfg.UI.Theme) == "" {
 		cfg.UI.Theme = defaults.UI.Theme
 	}
+	if strings.TrimSpace(cfg.Rollback.OnFailure) == "" {
+		cfg.Rollback.OnFailure = defaults.Rollback.OnFailure
+	}
+	if strings.TrimSpace(cfg.Forge.Type) == "" {
+		cfg.Forge.Type = defaults.Forge.Type
+	}
 
 	if cfg.Providers.Codex.Model == "" {
 		cfg.Providers.Codex.Model = defaults.Providers.Codex.Model
@@ -278,6 +689,16 @@ func applyFallbacks(cfg *Config) {
 		cfg.Review.Perspectives = defaults.Review.Perspectives
 	}
 
+	if strings.TrimSpace(cfg.Review.FailOn) == "" {
+		cfg.Review.FailOn = defaults.Review.FailOn
+	}
+	cfg.Review.FailOn = strings.TrimSpace(strings.ToLower(cfg.Review.FailOn))
+
+	if strings.TrimSpace(cfg.Permissions.Default) == "" {
+		cfg.Permissions.Default = defaults.Permissions.Default
+	}
+	cfg.Permissions.Default = strings.TrimSpace(strings.ToLower(cfg.Permissions.Default))
+
 	// Compound: enabled defaults to true.
 	if cfg.Compound == (CompoundConfig{}) {
 		cfg.Compound.Enabled = defaults.Compound.Enabled
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadReturnsDefaultsWhenFileDoesNotExist(t *testing.T) {
@@ -127,7 +128,7 @@ func TestLoadAppliesCompletionFromTOML(t *testing.T) {
 
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.toml")
-	content := "[provider]\ndefault = \"codex\"\n\n[retry]\nmax_retries = 1\ndelays = [\"0s\"]\n\n[quality]\ncommands = [\"go test ./...\"]\n\n[completion]\npush_on_complete = true\n"
+	content := "[provider]\ndefault = \"codex\"\n\n[retry]\nmax_retries = 1\ndelays = [\"0s\"]\n\n[quality]\ncommands = [\"go test ./...\"]\n\n[completion]\npush_on_complete = true\npr_body_template = \".github/daedalus-pr.md\"\n"
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatalf("write config: %v", err)
 	}
@@ -142,6 +143,9 @@ func TestLoadAppliesCompletionFromTOML(t *testing.T) {
 	if cfg.Completion.AutoPROnComplete {
 		t.Fatal("expected auto_pr_on_complete to remain false")
 	}
+	if cfg.Completion.PRBodyTemplate != ".github/daedalus-pr.md" {
+		t.Fatalf("expected pr_body_template to be read, got %q", cfg.Completion.PRBodyTemplate)
+	}
 }
 
 func TestValidateRejectsAutoPRWithoutPush(t *testing.T) {
@@ -195,6 +199,24 @@ func TestReviewConfigDefaultsToEnabled(t *testing.T) {
 	if len(cfg.Review.Perspectives) != 3 {
 		t.Fatalf("expected 3 review perspectives by default, got %d", len(cfg.Review.Perspectives))
 	}
+	if cfg.Review.RemediationRounds != 1 {
+		t.Fatalf("expected review.remediation_rounds to default to 1, got %d", cfg.Review.RemediationRounds)
+	}
+
+	if cfg.Review.FailOn != "low" {
+		t.Fatalf("expected review.fail_on to default to low, got %q", cfg.Review.FailOn)
+	}
+
+	cfg.Review.RemediationRounds = -1
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "remediation_rounds") {
+		t.Fatalf("expected remediation_rounds validation error, got %v", err)
+	}
+
+	cfg = Defaults()
+	cfg.Review.FailOn = "urgent"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "fail_on") {
+		t.Fatalf("expected fail_on validation error, got %v", err)
+	}
 }
 
 func TestCompoundConfigDefaultsToEnabled(t *testing.T) {
@@ -217,3 +239,347 @@ func TestValidateAcceptsCompoundEngineeringConfig(t *testing.T) {
 		t.Fatalf("expected valid config, got error: %v", err)
 	}
 }
+
+func TestQualityRepairRoundsDefaultAndOverride(t *testing.T) {
+	t.Parallel()
+
+	if Defaults().Quality.RepairRounds != 2 {
+		t.Fatalf("expected repair_rounds to default to 2, got %d", Defaults().Quality.RepairRounds)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[quality]\ncommands = [\"go test ./...\"]\nrepair_rounds = 0\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Quality.RepairRounds != 0 {
+		t.Fatalf("expected explicit repair_rounds = 0 to disable repair, got %d", cfg.Quality.RepairRounds)
+	}
+
+	cfg.Quality.RepairRounds = -1
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "repair_rounds") {
+		t.Fatalf("expected repair_rounds validation error, got %v", err)
+	}
+}
+
+func TestLoadReadsPermissionsPolicy(t *testing.T) {
+	t.Parallel()
+
+	if len(Defaults().Permissions.DenyPatterns) == 0 || Defaults().Permissions.Default != "allow" {
+		t.Fatalf("unexpected permission defaults: %+v", Defaults().Permissions)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[permissions]\nallow_tools = [\"read\", \"edit\"]\nallow_paths = [\"internal/**\"]\ndeny_patterns = [\"make deploy*\"]\ndefault = \"Deny\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Permissions.Default != "deny" || len(cfg.Permissions.AllowTools) != 2 || cfg.Permissions.DenyPatterns[0] != "make deploy*" {
+		t.Fatalf("unexpected permissions: %+v", cfg.Permissions)
+	}
+
+	cfg.Permissions.Default = "ask"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "permissions.default") {
+		t.Fatalf("expected permissions.default validation error, got %v", err)
+	}
+}
+
+func TestLoadReadsProviderFallback(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[provider]\ndefault = \"codex\"\nfallback = [\"claude\", \"gemini\"]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if strings.Join(cfg.Provider.Fallback, ",") != "claude,gemini" {
+		t.Fatalf("unexpected fallback chain: %v", cfg.Provider.Fallback)
+	}
+
+	cfg.Provider.Fallback = []string{" "}
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "provider.fallback") {
+		t.Fatalf("expected provider.fallback validation error, got %v", err)
+	}
+}
+
+func TestLoadReadsPhaseOverrides(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[phases.plan]\nprovider = \"claude\"\nmodel = \"opus\"\n\n[phases.review]\nmodel = \"mini\"\napproval_policy = \"never\"\nsandbox_policy = \"read-only\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Phases.Get(PhasePlan); got.Provider != "claude" || got.Model != "opus" {
+		t.Fatalf("unexpected plan phase: %+v", got)
+	}
+	if got := cfg.Phases.Get(PhaseReview); got.SandboxPolicy != "read-only" || got.ApprovalPolicy != "never" {
+		t.Fatalf("unexpected review phase: %+v", got)
+	}
+	if got := cfg.Phases.Get("deploy"); got != (PhaseConfig{}) {
+		t.Fatalf("expected zero config for unknown phase, got %+v", got)
+	}
+}
+
+func TestLoadReadsContextBudget(t *testing.T) {
+	t.Parallel()
+
+	if got := Defaults().Context.MaxBytes; got != 256*1024 {
+		t.Fatalf("expected a 256 KiB default budget, got %d", got)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[context]\nmax_bytes = 0\nmax_tokens = 8000\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Context.MaxBytes != 0 || cfg.Context.MaxTokens != 8000 {
+		t.Fatalf("unexpected context budget: %+v", cfg.Context)
+	}
+
+	cfg.Context.MaxTokens = -1
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "context.max_tokens") {
+		t.Fatalf("expected context.max_tokens validation error, got %v", err)
+	}
+}
+
+func TestLoadReadsBudgetAndProviderPrices(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[budget]\nmax_usd = 12.5\n\n[providers.codex]\ninput_usd_per_mtok = 1.25\noutput_usd_per_mtok = 10.0\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Budget.MaxUSD != 12.5 || cfg.Providers.Codex.InputUSDPerMTok != 1.25 || cfg.Providers.Codex.OutputUSDPerMTok != 10 {
+		t.Fatalf("unexpected budget or prices: %+v %+v", cfg.Budget, cfg.Providers.Codex)
+	}
+
+	cfg.Budget.MaxUSD = -1
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "budget.max_usd") {
+		t.Fatalf("expected budget.max_usd validation error, got %v", err)
+	}
+}
+
+func TestLoadReadsTimeouts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[timeouts]\nwork = \"1h30m\"\nquality_command = \"\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Timeouts.Work != "1h30m" || cfg.Timeouts.Plan != "15m" || cfg.Timeouts.QualityCommand != "" {
+		t.Fatalf("unexpected timeouts: %+v", cfg.Timeouts)
+	}
+	if work, err := ParseTimeout(cfg.Timeouts.Work); err != nil || work != 90*time.Minute {
+		t.Fatalf("expected 90m, got %s (%v)", work, err)
+	}
+
+	cfg.Timeouts.Review = "-5m"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "timeouts.review") {
+		t.Fatalf("expected timeouts.review validation error, got %v", err)
+	}
+	cfg.Timeouts.Review = "soon"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "timeouts.review") {
+		t.Fatalf("expected timeouts.review validation error, got %v", err)
+	}
+}
+
+func TestRollbackOnFailureDefaultsToKeepAndIsValidated(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("[rollback]\non_failure = \"\"\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Rollback.OnFailure != "keep" {
+		t.Fatalf("expected keep by default, got %q", cfg.Rollback.OnFailure)
+	}
+
+	cfg.Rollback.OnFailure = "stash"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "rollback.on_failure") {
+		t.Fatalf("expected rollback.on_failure validation error, got %v", err)
+	}
+}
+
+func TestLoadAppliesForgeAndPullRequestOptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[completion]\npr_draft = true\npr_labels = [\"daedalus\"]\npr_reviewers = [\"alice\"]\n\n[forge]\ntype = \"gitea\"\nurl = \"https://git.example.com/api/v1\"\ntoken_env = \"MY_GITEA_TOKEN\"\nbase_branch = \"develop\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	want := ForgeConfig{Type: "gitea", URL: "https://git.example.com/api/v1", TokenEnv: "MY_GITEA_TOKEN", BaseBranch: "develop"}
+	if cfg.Forge != want {
+		t.Fatalf("expected %+v, got %+v", want, cfg.Forge)
+	}
+	if !cfg.Completion.PRDraft || len(cfg.Completion.PRLabels) != 1 || len(cfg.Completion.PRReviewers) != 1 {
+		t.Fatalf("unexpected completion options: %+v", cfg.Completion)
+	}
+
+	cfg.Forge.Type = "bitbucket"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "forge.type") {
+		t.Fatalf("expected forge.type validation error, got %v", err)
+	}
+	if Defaults().Forge.Type != "auto" {
+		t.Fatalf("expected auto forge detection by default, got %q", Defaults().Forge.Type)
+	}
+}
+
+func TestLoadAppliesQualityChecksTable(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `[quality]
+concurrency = 2
+full_every = 5
+
+[quality.coverage]
+profiles = ["cover.out", "web/coverage/lcov.info"]
+min = 70
+max_drop = 1.5
+
+[[quality.checks]]
+name = "build"
+command = "go build ./..."
+
+[[quality.checks]]
+name = "vet"
+command = "go vet ./..."
+group = "lint"
+depends_on = ["build"]
+timeout = "2m"
+
+[[quality.checks]]
+name = "test"
+command = "go test -json {{changed_packages}}"
+format = "go-test-json"
+scope = "changed"
+
+[[quality.checks]]
+name = "audit"
+command = "govulncheck ./..."
+allow_failure = true
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Quality.Concurrency != 2 || cfg.Quality.FullEvery != 5 || len(cfg.Quality.Checks) != 4 {
+		t.Fatalf("unexpected quality config: %+v", cfg.Quality)
+	}
+	if coverage := cfg.Quality.Coverage; len(coverage.Profiles) != 2 || coverage.Min != 70 || coverage.MaxDrop != 1.5 {
+		t.Fatalf("unexpected coverage config: %+v", coverage)
+	}
+	vet := cfg.Quality.Checks[1]
+	if vet.Group != "lint" || len(vet.DependsOn) != 1 || vet.Timeout != "2m" || cfg.Quality.Checks[2].Format != "go-test-json" || cfg.Quality.Checks[2].Scope != "changed" || !cfg.Quality.Checks[3].AllowFailure {
+		t.Fatalf("unexpected checks: %+v", cfg.Quality.Checks)
+	}
+
+	for want, mutate := range map[string]func(*Config){
+		"name must not be empty":    func(c *Config) { c.Quality.Checks[0].Name = "" },
+		"used twice":                func(c *Config) { c.Quality.Checks[1].Name = "build" },
+		"command must not be empty": func(c *Config) { c.Quality.Checks[0].Command = " " },
+		"timeout":                   func(c *Config) { c.Quality.Checks[1].Timeout = "soon" },
+		"quality.concurrency":       func(c *Config) { c.Quality.Concurrency = -1 },
+		"format must be one of":     func(c *Config) { c.Quality.Checks[2].Format = "xunit" },
+		"scope must be one of":      func(c *Config) { c.Quality.Checks[2].Scope = "diff" },
+		"report needs a format":     func(c *Config) { c.Quality.Checks[0].Report = "junit.xml" },
+		"quality.full_every":        func(c *Config) { c.Quality.FullEvery = -1 },
+		"coverage.min":              func(c *Config) { c.Quality.Coverage.Min = 101 },
+		"coverage.max_drop":         func(c *Config) { c.Quality.Coverage.MaxDrop = -1 },
+		"coverage.profiles":         func(c *Config) { c.Quality.Coverage.Profiles = []string{" "} },
+	} {
+		broken := cfg
+		broken.Quality.Checks = append([]QualityCheckConfig(nil), cfg.Quality.Checks...)
+		mutate(&broken)
+		if err := Validate(broken); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q validation error, got %v", want, err)
+		}
+	}
+}
+
+func TestLoadAppliesSecretsScanner(t *testing.T) {
+	t.Parallel()
+
+	if !Defaults().Secrets.Enabled {
+		t.Fatal("expected the secret scanner to be enabled by default")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "[secrets]\nblock_paths = [\"config/prod/*\"]\nallow_paths = [\"testdata\"]\nallow_patterns = [\"secrets:allow\"]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !cfg.Secrets.Enabled || len(cfg.Secrets.BlockPaths) != 1 || len(cfg.Secrets.AllowPaths) != 1 || len(cfg.Secrets.AllowPatterns) != 1 {
+		t.Fatalf("unexpected secrets config: %+v", cfg.Secrets)
+	}
+
+	for want, mutate := range map[string]func(*Config){
+		"secrets.block_paths must not contain empty": func(c *Config) { c.Secrets.BlockPaths = []string{" "} },
+		"secrets.allow_paths has an invalid pattern": func(c *Config) { c.Secrets.AllowPaths = []string{"[a-"} },
+		"secrets.allow_patterns has an invalid":      func(c *Config) { c.Secrets.AllowPatterns = []string{"("} },
+	} {
+		broken := cfg
+		mutate(&broken)
+		if err := Validate(broken); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q validation error, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Head returns the commit HEAD points to, or an empty string in a
+// repository without commits.
+func (Committer) Head(ctx context.Context, workDir string) (string, error) {
+	if _, err := gitOutput(ctx, workDir, "rev-parse", "--git-dir"); err != nil {
+		return "", err
+	}
+	return currentHead(ctx, workDir), nil
+}
+
+// ChangedFiles lists the files of workDir's working tree that differ from
+// ref: modified, added, deleted, and untracked files that are not ignored.
+// Daedalus' own artifacts are left out. Paths are relative to workDir.
+func (Committer) ChangedFiles(ctx context.Context, workDir, ref string) ([]string, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("no ref to compare the working tree with")
+	}
+	// Writing the working tree to a commit covers untracked files too.
+	current, err := commitWorktree(ctx, workDir, "", "daedalus: working tree")
+	if err != nil {
+		return nil, err
+	}
+	output, err := gitOutput(ctx, workDir, "diff", "--name-only", "--no-renames", "-z", "--relative", ref, current, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range splitNul(output) {
+		if !isArtifactPath(file) {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChangedFilesListsWorkingTreeChangesSinceRef(t *testing.T) {
+	t.Parallel()
+
+	workDir := initRepo(t)
+	writeFile(t, filepath.Join(workDir, "kept.go"), "package main\n")
+	writeFile(t, filepath.Join(workDir, "edited.go"), "package main\n")
+	writeFile(t, filepath.Join(workDir, "deleted.go"), "package main\n")
+	run(t, workDir, "git", "add", "-A")
+	run(t, workDir, "git", "commit", "-m", "initial commit")
+
+	committer := NewCommitter()
+	head, err := committer.Head(context.Background(), workDir)
+	if err != nil || head == "" {
+		t.Fatalf("expected HEAD, got %q (%v)", head, err)
+	}
+
+	writeFile(t, filepath.Join(workDir, "edited.go"), "package main\n\nfunc f() {}\n")
+	if err := os.Remove(filepath.Join(workDir, "deleted.go")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(workDir, "pkg", "new"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, filepath.Join(workDir, "pkg", "new", "new.go"), "package new\n")
+	if err := os.MkdirAll(filepath.Join(workDir, ".daedalus"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, filepath.Join(workDir, ".daedalus", "progress.md"), "artifact\n")
+
+	files, err := committer.ChangedFiles(context.Background(), workDir, head)
+	if err != nil {
+		t.Fatalf("changed files: %v", err)
+	}
+	if got, want := strings.Join(files, ","), "deleted.go,edited.go,pkg/new/new.go"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	// A work dir below the repository root only sees its own changes.
+	files, err = committer.ChangedFiles(context.Background(), filepath.Join(workDir, "pkg"), head)
+	if err != nil {
+		t.Fatalf("changed files: %v", err)
+	}
+	if got := strings.Join(files, ","); got != "new/new.go" {
+		t.Fatalf("expected new/new.go, got %s", got)
+	}
+}
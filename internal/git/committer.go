@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/secrets"
 )
 
 type CommitResult struct {
@@ -14,13 +17,35 @@ type CommitResult struct {
 	Message   string
 }
 
-type Committer struct{}
+type Committer struct {
+	forge ForgeOptions
+	// secrets, when set, scans each story's changes before they are staged.
+	secrets *secrets.Scanner
+}
 
 func NewCommitter() Committer {
 	return Committer{}
 }
 
-func (Committer) CommitStory(ctx context.Context, workDir, storyID, storyTitle string) (CommitResult, error) {
+// StoryCommit describes the story a commit completes.
+type StoryCommit struct {
+	StoryID string
+	Title   string
+	// Type is the conventional commit type; empty means feat.
+	Type string
+	// Summary is the agent's account of the work, used as the commit body.
+	Summary  string
+	PRD      string
+	Provider string
+	// RunID identifies the provider run (the ACP session) that did the work.
+	RunID string
+}
+
+// CommitStory stages every change in workDir and commits it with a message
+// built from story and the staged diff. With a secret scanner, findings in
+// the changes stop it before anything is staged, with a
+// *secrets.BlockedError.
+func (c Committer) CommitStory(ctx context.Context, workDir string, story StoryCommit) (CommitResult, error) {
 	dirty, err := hasChanges(ctx, workDir)
 	if err != nil {
 		return CommitResult{}, err
@@ -28,12 +53,22 @@ func (Committer) CommitStory(ctx context.Context, workDir, storyID, storyTitle s
 	if !dirty {
 		return CommitResult{Committed: false, Message: "no changes to commit"}, nil
 	}
+	if c.secrets != nil {
+		if err := c.scanSecrets(ctx, workDir); err != nil {
+			return CommitResult{}, err
+		}
+	}
 
-	message := fmt.Sprintf("feat(%s): %s", strings.TrimSpace(storyID), strings.TrimSpace(storyTitle))
 	if err := runGit(ctx, workDir, "add", "-A"); err != nil {
 		return CommitResult{}, err
 	}
-	if err := runGit(ctx, workDir, "commit", "-m", message); err != nil {
+	diffStat, err := gitOutput(ctx, workDir, "diff", "--cached", "--stat=72")
+	if err != nil {
+		return CommitResult{}, err
+	}
+	message := BuildCommitMessage(story, diffStat)
+	// Keep "#" lines: agent summaries are often markdown.
+	if err := runGit(ctx, workDir, "commit", "--cleanup=whitespace", "-m", message); err != nil {
 		return CommitResult{}, err
 	}
 
@@ -53,23 +88,6 @@ func (Committer) PushBranch(ctx context.Context, workDir string) error {
 	return runGit(ctx, workDir, "push", "-u", "origin", "HEAD")
 }
 
-func (Committer) CreatePR(ctx context.Context, workDir string) error {
-	cmd := exec.CommandContext(ctx, "gh", "pr", "create", "--fill")
-	if strings.TrimSpace(workDir) != "" {
-		cmd.Dir = workDir
-	}
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		msg := strings.TrimSpace(stderr.String())
-		if msg == "" {
-			msg = err.Error()
-		}
-		return fmt.Errorf("gh pr create failed: %s", msg)
-	}
-	return nil
-}
-
 func hasChanges(ctx context.Context, workDir string) (bool, error) {
 	out, err := gitOutput(ctx, workDir, "status", "--porcelain")
 	if err != nil {
@@ -84,10 +102,18 @@ func runGit(ctx context.Context, workDir string, args ...string) error {
 }
 
 func gitOutput(ctx context.Context, workDir string, args ...string) (string, error) {
+	return gitOutputEnv(ctx, workDir, nil, args...)
+}
+
+// gitOutputEnv runs git with env added to the process environment.
+func gitOutputEnv(ctx context.Context, workDir string, env []string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	if strings.TrimSpace(workDir) != "" {
 		cmd.Dir = workDir
 	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
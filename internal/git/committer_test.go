@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -15,7 +16,7 @@ func TestCommitStoryCommitsWhenRepoHasChanges(t *testing.T) {
 	writeFile(t, filepath.Join(repo, "hello.txt"), "hello\n")
 
 	committer := NewCommitter()
-	result, err := committer.CommitStory(context.Background(), repo, "US-101", "Add hello file")
+	result, err := committer.CommitStory(context.Background(), repo, StoryCommit{StoryID: "US-101", Title: "Add hello file"})
 	if err != nil {
 		t.Fatalf("commit failed: %v", err)
 	}
@@ -27,12 +28,69 @@ func TestCommitStoryCommitsWhenRepoHasChanges(t *testing.T) {
 	}
 }
 
+func TestCommitStoryWritesBodyAndTrailers(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	writeFile(t, filepath.Join(repo, "export.go"), "package export\n")
+
+	committer := NewCommitter()
+	_, err := committer.CommitStory(context.Background(), repo, StoryCommit{
+		StoryID:  "US-103",
+		Title:    "Fix the CSV exporter",
+		Type:     "fix",
+		Summary:  "# Changes\nQuoted fields that contain commas.",
+		PRD:      "main",
+		Provider: "codex",
+		RunID:    "sess-42",
+	})
+	if err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	cmd := exec.Command("git", "log", "-1", "--format=%B")
+	cmd.Dir = repo
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	message := string(output)
+	for _, expected := range []string{
+		"fix(US-103): Fix the CSV exporter\n\n# Changes\nQuoted fields that contain commas.\n\nFiles changed:\n export.go | 1 +",
+		"Daedalus-Story: US-103\nDaedalus-PRD: main\nDaedalus-Provider: codex\nDaedalus-Run-ID: sess-42",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Fatalf("expected commit message to contain %q, got:\n%s", expected, message)
+		}
+	}
+
+	cmd = exec.Command("git", "log", "-1", "--format=%(trailers:key=Daedalus-PRD,valueonly)")
+	cmd.Dir = repo
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("git log trailers: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "main" {
+		t.Fatalf("expected git to parse the PRD trailer, got %q", output)
+	}
+}
+
+func TestBuildCommitMessageOmitsEmptySections(t *testing.T) {
+	t.Parallel()
+
+	got := BuildCommitMessage(StoryCommit{StoryID: "US-1", Title: "Add  export", Summary: "iteration completed"}, "")
+	want := "feat(US-1): Add export\n\nDaedalus-Story: US-1\n"
+	if got != want {
+		t.Fatalf("BuildCommitMessage() = %q, want %q", got, want)
+	}
+}
+
 func TestCommitStorySkipsWhenNoChanges(t *testing.T) {
 	t.Parallel()
 
 	repo := initRepo(t)
 	committer := NewCommitter()
-	result, err := committer.CommitStory(context.Background(), repo, "US-102", "No-op")
+	result, err := committer.CommitStory(context.Background(), repo, StoryCommit{StoryID: "US-102", Title: "No-op"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -73,20 +131,6 @@ func TestPushBranchFailsWithNoRemote(t *testing.T) {
 	}
 }
 
-func TestCreatePRFailsWhenGhNotFound(t *testing.T) {
-	workDir := t.TempDir()
-	t.Setenv("PATH", "")
-
-	committer := NewCommitter()
-	err := committer.CreatePR(context.Background(), workDir)
-	if err == nil {
-		t.Fatal("expected error when gh not in PATH")
-	}
-	if err.Error() == "" {
-		t.Fatal("expected non-empty error message")
-	}
-}
-
 func initRepo(t *testing.T) string {
 	t.Helper()
 
@@ -0,0 +1,332 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Forge types.
+const (
+	ForgeAuto   = "auto"
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	ForgeGitea  = "gitea"
+)
+
+// ForgeOptions locates the forge CreatePR opens pull requests on.
+type ForgeOptions struct {
+	// Type is github, gitlab, gitea, or auto (empty) to detect it from the
+	// remote's host.
+	Type string
+	// URL is the API base URL. Empty derives it from the remote.
+	URL string
+	// TokenEnv names the environment variable holding the API token. Empty
+	// uses the forge's default variable.
+	TokenEnv string
+	// BaseBranch is the branch pull requests target. Empty uses the remote's
+	// default branch, or main.
+	BaseBranch string
+}
+
+// WithForge returns a committer that opens pull requests on the forge
+// described by options.
+func (c Committer) WithForge(options ForgeOptions) Committer {
+	c.forge = options
+	return c
+}
+
+// PullRequest is what CreatePR opens.
+type PullRequest struct {
+	Title string
+	Body  string
+	// Draft, Labels, and Reviewers apply to new pull requests only.
+	Draft     bool
+	Labels    []string
+	Reviewers []string
+}
+
+// PullRequestResult identifies the pull request CreatePR opened or updated.
+type PullRequestResult struct {
+	Number int
+	URL    string
+	// Updated is set when a pull request for the branch was already open and
+	// only its body was replaced.
+	Updated bool
+}
+
+// Forge is a code hosting service with a pull request API. GitLab calls
+// pull requests merge requests; Number is then the merge request's IID.
+type Forge interface {
+	Name() string
+	// FindPullRequest returns the open pull request from head, if any.
+	FindPullRequest(ctx context.Context, head string) (PullRequestResult, bool, error)
+	CreatePullRequest(ctx context.Context, head, base string, pr PullRequest) (PullRequestResult, error)
+	// UpdatePullRequest replaces the body of pull request number.
+	UpdatePullRequest(ctx context.Context, number int, pr PullRequest) (PullRequestResult, error)
+}
+
+// Repository is a repository on a forge, as named by a remote URL.
+type Repository struct {
+	// Scheme is http or https; SSH remotes use https.
+	Scheme string
+	// Host includes the port of HTTP remotes.
+	Host string
+	// Owner is the user, organization, or (on GitLab) group path.
+	Owner string
+	Name  string
+}
+
+// Path returns "owner/name".
+func (r Repository) Path() string {
+	return r.Owner + "/" + r.Name
+}
+
+// ParseRemote parses an HTTP(S), ssh://, or scp-style (git@host:owner/repo)
+// remote URL.
+func ParseRemote(remote string) (Repository, error) {
+	remote = strings.TrimSpace(remote)
+	repo := Repository{Scheme: "https"}
+	var path string
+	if strings.Contains(remote, "://") {
+		parsed, err := url.Parse(remote)
+		if err != nil {
+			return Repository{}, fmt.Errorf("invalid remote URL %q: %w", remote, err)
+		}
+		switch parsed.Scheme {
+		case "http", "https":
+			repo.Scheme = parsed.Scheme
+			repo.Host = parsed.Host
+		case "ssh", "git+ssh":
+			repo.Host = parsed.Hostname()
+		default:
+			return Repository{}, fmt.Errorf("unsupported remote URL %q", remote)
+		}
+		path = parsed.Path
+	} else {
+		hostPart, pathPart, ok := strings.Cut(remote, ":")
+		if !ok {
+			return Repository{}, fmt.Errorf("unsupported remote URL %q", remote)
+		}
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		repo.Host = hostPart
+		path = pathPart
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	slash := strings.LastIndex(path, "/")
+	if repo.Host == "" || slash <= 0 || slash == len(path)-1 {
+		return Repository{}, fmt.Errorf("remote URL %q does not name an owner and repository", remote)
+	}
+	repo.Owner = path[:slash]
+	repo.Name = path[slash+1:]
+	return repo, nil
+}
+
+// NewForge returns the forge repo lives on. The token is read from the
+// environment.
+func NewForge(options ForgeOptions, repo Repository) (Forge, error) {
+	kind := strings.ToLower(strings.TrimSpace(options.Type))
+	if kind == "" || kind == ForgeAuto {
+		detected, err := detectForge(repo.Host)
+		if err != nil {
+			return nil, err
+		}
+		kind = detected
+	}
+
+	tokenEnvs := map[string][]string{
+		ForgeGitHub: {"GITHUB_TOKEN", "GH_TOKEN"},
+		ForgeGitLab: {"GITLAB_TOKEN"},
+		ForgeGitea:  {"GITEA_TOKEN"},
+	}[kind]
+	if tokenEnvs == nil {
+		return nil, fmt.Errorf("unknown forge type %q", options.Type)
+	}
+	if env := strings.TrimSpace(options.TokenEnv); env != "" {
+		tokenEnvs = []string{env}
+	}
+	token := ""
+	for _, env := range tokenEnvs {
+		if token = strings.TrimSpace(os.Getenv(env)); token != "" {
+			break
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("%s: no API token; set %s", kind, strings.Join(tokenEnvs, " or "))
+	}
+
+	apiURL := strings.TrimRight(strings.TrimSpace(options.URL), "/")
+	if apiURL == "" {
+		apiURL = defaultAPIURL(kind, repo)
+	}
+	client := forgeClient{name: kind, baseURL: apiURL, http: &http.Client{Timeout: 30 * time.Second}}
+	switch kind {
+	case ForgeGitHub:
+		client.auth = func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+			req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		}
+		return gitHubForge{client: client, repo: repo}, nil
+	case ForgeGitLab:
+		client.auth = func(req *http.Request) {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+		return gitLabForge{client: client, repo: repo}, nil
+	default:
+		client.auth = func(req *http.Request) {
+			req.Header.Set("Authorization", "token "+token)
+		}
+		return giteaForge{client: client, repo: repo}, nil
+	}
+}
+
+// detectForge guesses the forge from well-known hosts and host names.
+// Self-hosted forges on other hosts need forge.type.
+func detectForge(host string) (string, error) {
+	host = strings.ToLower(host)
+	if hostname, _, found := strings.Cut(host, ":"); found {
+		host = hostname
+	}
+	switch {
+	case host == "github.com" || strings.HasPrefix(host, "github."):
+		return ForgeGitHub, nil
+	case host == "gitlab.com" || strings.HasPrefix(host, "gitlab."):
+		return ForgeGitLab, nil
+	case host == "codeberg.org" || strings.HasPrefix(host, "gitea.") || strings.HasPrefix(host, "forgejo."):
+		return ForgeGitea, nil
+	}
+	return "", fmt.Errorf("cannot detect the forge of %s; set forge.type", host)
+}
+
+func defaultAPIURL(kind string, repo Repository) string {
+	root := repo.Scheme + "://" + repo.Host
+	switch kind {
+	case ForgeGitHub:
+		if strings.EqualFold(repo.Host, "github.com") {
+			return "https://api.github.com"
+		}
+		return root + "/api/v3"
+	case ForgeGitLab:
+		return root + "/api/v4"
+	default:
+		return root + "/api/v1"
+	}
+}
+
+// CreatePR opens a pull request from the current branch of workDir on the
+// origin remote's forge. When one is already open for the branch, its body
+// is replaced instead.
+func (c Committer) CreatePR(ctx context.Context, workDir string, pr PullRequest) (PullRequestResult, error) {
+	remote, err := gitOutput(ctx, workDir, "remote", "get-url", "origin")
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	repo, err := ParseRemote(remote)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	forge, err := NewForge(c.forge, repo)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	head, err := gitOutput(ctx, workDir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	head = strings.TrimSpace(head)
+	if head == "HEAD" {
+		return PullRequestResult{}, fmt.Errorf("cannot open a pull request from a detached HEAD")
+	}
+	base := c.baseBranch(ctx, workDir)
+	if head == base {
+		return PullRequestResult{}, fmt.Errorf("cannot open a pull request from %s into itself", head)
+	}
+
+	existing, found, err := forge.FindPullRequest(ctx, head)
+	if err != nil {
+		return PullRequestResult{}, err
+	}
+	if found {
+		return forge.UpdatePullRequest(ctx, existing.Number, pr)
+	}
+	return forge.CreatePullRequest(ctx, head, base, pr)
+}
+
+func (c Committer) baseBranch(ctx context.Context, workDir string) string {
+	if base := strings.TrimSpace(c.forge.BaseBranch); base != "" {
+		return base
+	}
+	ref, err := gitOutput(ctx, workDir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err == nil {
+		if base := strings.TrimPrefix(strings.TrimSpace(ref), "origin/"); base != "" {
+			return base
+		}
+	}
+	return "main"
+}
+
+// forgeClient sends JSON requests to a forge API.
+type forgeClient struct {
+	name    string
+	baseURL string
+	auth    func(req *http.Request)
+	http    *http.Client
+}
+
+// do sends in as JSON to path, which must already be escaped, and decodes
+// the response into out when out is not nil.
+func (c forgeClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.auth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s API: %w", c.name, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("%s API: %w", c.name, err)
+	}
+	if resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 300 {
+			msg = msg[:300] + "..."
+		}
+		return fmt.Errorf("%s API: %s %s: %s: %s", c.name, method, path, resp.Status, msg)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s API: %s %s: invalid response: %w", c.name, method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// giteaForge opens pull requests through the Gitea (and Forgejo) REST API.
+type giteaForge struct {
+	client forgeClient
+	repo   Repository
+}
+
+type giteaPull struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+}
+
+func (f giteaForge) Name() string {
+	return ForgeGitea
+}
+
+func (f giteaForge) repoPath() string {
+	return "/repos/" + url.PathEscape(f.repo.Owner) + "/" + url.PathEscape(f.repo.Name)
+}
+
+func (f giteaForge) FindPullRequest(ctx context.Context, head string) (PullRequestResult, bool, error) {
+	// The list cannot be filtered by head branch, so scan the open ones.
+	for page := 1; ; page++ {
+		var pulls []giteaPull
+		path := fmt.Sprintf("%s/pulls?state=open&limit=50&page=%d", f.repoPath(), page)
+		if err := f.client.do(ctx, http.MethodGet, path, nil, &pulls); err != nil {
+			return PullRequestResult{}, false, err
+		}
+		for _, pull := range pulls {
+			if pull.Head.Ref == head {
+				return PullRequestResult{Number: pull.Number, URL: pull.HTMLURL}, true, nil
+			}
+		}
+		if len(pulls) < 50 {
+			return PullRequestResult{}, false, nil
+		}
+	}
+}
+
+func (f giteaForge) CreatePullRequest(ctx context.Context, head, base string, pr PullRequest) (PullRequestResult, error) {
+	title := pr.Title
+	if pr.Draft {
+		// Gitea treats pull requests with a WIP title prefix as drafts.
+		title = "WIP: " + title
+	}
+	request := map[string]interface{}{
+		"head":  head,
+		"base":  base,
+		"title": title,
+		"body":  pr.Body,
+	}
+	if len(pr.Labels) > 0 {
+		ids, err := f.labelIDs(ctx, pr.Labels)
+		if err != nil {
+			return PullRequestResult{}, err
+		}
+		request["labels"] = ids
+	}
+	var pull giteaPull
+	if err := f.client.do(ctx, http.MethodPost, f.repoPath()+"/pulls", request, &pull); err != nil {
+		return PullRequestResult{}, err
+	}
+	result := PullRequestResult{Number: pull.Number, URL: pull.HTMLURL}
+	if len(pr.Reviewers) > 0 {
+		path := fmt.Sprintf("%s/pulls/%d/requested_reviewers", f.repoPath(), pull.Number)
+		if err := f.client.do(ctx, http.MethodPost, path, map[string]interface{}{"reviewers": pr.Reviewers}, nil); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (f giteaForge) UpdatePullRequest(ctx context.Context, number int, pr PullRequest) (PullRequestResult, error) {
+	var pull giteaPull
+	path := fmt.Sprintf("%s/pulls/%d", f.repoPath(), number)
+	if err := f.client.do(ctx, http.MethodPatch, path, map[string]interface{}{"body": pr.Body}, &pull); err != nil {
+		return PullRequestResult{}, err
+	}
+	return PullRequestResult{Number: pull.Number, URL: pull.HTMLURL, Updated: true}, nil
+}
+
+// labelIDs resolves label names to IDs; Gitea takes labels by ID.
+func (f giteaForge) labelIDs(ctx context.Context, names []string) ([]int64, error) {
+	byName := map[string]int64{}
+	for page := 1; ; page++ {
+		var labels []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		}
+		path := fmt.Sprintf("%s/labels?limit=50&page=%d", f.repoPath(), page)
+		if err := f.client.do(ctx, http.MethodGet, path, nil, &labels); err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			byName[label.Name] = label.ID
+		}
+		if len(labels) < 50 {
+			break
+		}
+	}
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("gitea: label %q not found in %s", name, f.repo.Path())
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,78 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// gitHubForge opens pull requests through the GitHub REST API.
+type gitHubForge struct {
+	client forgeClient
+	repo   Repository
+}
+
+type gitHubPull struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (f gitHubForge) Name() string {
+	return ForgeGitHub
+}
+
+func (f gitHubForge) pullsPath() string {
+	return "/repos/" + url.PathEscape(f.repo.Owner) + "/" + url.PathEscape(f.repo.Name) + "/pulls"
+}
+
+func (f gitHubForge) FindPullRequest(ctx context.Context, head string) (PullRequestResult, bool, error) {
+	query := url.Values{"state": {"open"}, "head": {f.repo.Owner + ":" + head}}
+	var pulls []gitHubPull
+	if err := f.client.do(ctx, http.MethodGet, f.pullsPath()+"?"+query.Encode(), nil, &pulls); err != nil {
+		return PullRequestResult{}, false, err
+	}
+	if len(pulls) == 0 {
+		return PullRequestResult{}, false, nil
+	}
+	return PullRequestResult{Number: pulls[0].Number, URL: pulls[0].HTMLURL}, true, nil
+}
+
+func (f gitHubForge) CreatePullRequest(ctx context.Context, head, base string, pr PullRequest) (PullRequestResult, error) {
+	request := map[string]interface{}{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  head,
+		"base":  base,
+		"draft": pr.Draft,
+	}
+	var pull gitHubPull
+	if err := f.client.do(ctx, http.MethodPost, f.pullsPath(), request, &pull); err != nil {
+		return PullRequestResult{}, err
+	}
+	result := PullRequestResult{Number: pull.Number, URL: pull.HTMLURL}
+
+	// Labels live on the pull request's issue.
+	if len(pr.Labels) > 0 {
+		path := fmt.Sprintf("/repos/%s/%s/issues/%d/labels", url.PathEscape(f.repo.Owner), url.PathEscape(f.repo.Name), pull.Number)
+		if err := f.client.do(ctx, http.MethodPost, path, map[string]interface{}{"labels": pr.Labels}, nil); err != nil {
+			return result, err
+		}
+	}
+	if len(pr.Reviewers) > 0 {
+		path := fmt.Sprintf("%s/%d/requested_reviewers", f.pullsPath(), pull.Number)
+		if err := f.client.do(ctx, http.MethodPost, path, map[string]interface{}{"reviewers": pr.Reviewers}, nil); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (f gitHubForge) UpdatePullRequest(ctx context.Context, number int, pr PullRequest) (PullRequestResult, error) {
+	var pull gitHubPull
+	path := fmt.Sprintf("%s/%d", f.pullsPath(), number)
+	if err := f.client.do(ctx, http.MethodPatch, path, map[string]interface{}{"body": pr.Body}, &pull); err != nil {
+		return PullRequestResult{}, err
+	}
+	return PullRequestResult{Number: pull.Number, URL: pull.HTMLURL, Updated: true}, nil
+}
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// gitLabForge opens merge requests through the GitLab REST API.
+type gitLabForge struct {
+	client forgeClient
+	repo   Repository
+}
+
+type gitLabMergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+func (f gitLabForge) Name() string {
+	return ForgeGitLab
+}
+
+// projectPath addresses the project by its URL-encoded path, which also
+// covers projects in subgroups.
+func (f gitLabForge) projectPath() string {
+	return "/projects/" + url.PathEscape(f.repo.Path())
+}
+
+func (f gitLabForge) FindPullRequest(ctx context.Context, head string) (PullRequestResult, bool, error) {
+	query := url.Values{"state": {"opened"}, "source_branch": {head}}
+	var requests []gitLabMergeRequest
+	if err := f.client.do(ctx, http.MethodGet, f.projectPath()+"/merge_requests?"+query.Encode(), nil, &requests); err != nil {
+		return PullRequestResult{}, false, err
+	}
+	if len(requests) == 0 {
+		return PullRequestResult{}, false, nil
+	}
+	return PullRequestResult{Number: requests[0].IID, URL: requests[0].WebURL}, true, nil
+}
+
+func (f gitLabForge) CreatePullRequest(ctx context.Context, head, base string, pr PullRequest) (PullRequestResult, error) {
+	title := pr.Title
+	if pr.Draft {
+		// GitLab marks merge requests as drafts by their title.
+		title = "Draft: " + title
+	}
+	request := map[string]interface{}{
+		"source_branch": head,
+		"target_branch": base,
+		"title":         title,
+		"description":   pr.Body,
+	}
+	if len(pr.Labels) > 0 {
+		request["labels"] = strings.Join(pr.Labels, ",")
+	}
+	if len(pr.Reviewers) > 0 {
+		ids, err := f.userIDs(ctx, pr.Reviewers)
+		if err != nil {
+			return PullRequestResult{}, err
+		}
+		request["reviewer_ids"] = ids
+	}
+	var created gitLabMergeRequest
+	if err := f.client.do(ctx, http.MethodPost, f.projectPath()+"/merge_requests", request, &created); err != nil {
+		return PullRequestResult{}, err
+	}
+	return PullRequestResult{Number: created.IID, URL: created.WebURL}, nil
+}
+
+func (f gitLabForge) UpdatePullRequest(ctx context.Context, number int, pr PullRequest) (PullRequestResult, error) {
+	var updated gitLabMergeRequest
+	path := fmt.Sprintf("%s/merge_requests/%d", f.projectPath(), number)
+	if err := f.client.do(ctx, http.MethodPut, path, map[string]interface{}{"description": pr.Body}, &updated); err != nil {
+		return PullRequestResult{}, err
+	}
+	return PullRequestResult{Number: updated.IID, URL: updated.WebURL, Updated: true}, nil
+}
+
+// userIDs looks up the IDs of usernames; GitLab assigns reviewers by ID.
+func (f gitLabForge) userIDs(ctx context.Context, usernames []string) ([]int, error) {
+	ids := make([]int, 0, len(usernames))
+	for _, username := range usernames {
+		var users []struct {
+			ID int `json:"id"`
+		}
+		query := url.Values{"username": {username}}
+		if err := f.client.do(ctx, http.MethodGet, "/users?"+query.Encode(), nil, &users); err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, fmt.Errorf("gitlab: reviewer %q not found", username)
+		}
+		ids = append(ids, users[0].ID)
+	}
+	return ids, nil
+}
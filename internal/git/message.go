@@ -0,0 +1,81 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxCommitSummaryBytes caps the agent summary quoted in a commit body.
+const maxCommitSummaryBytes = 4000
+
+// Trailer keys added to every story commit, so `git log --grep` and release
+// tooling can trace a commit back to its PRD story.
+const (
+	TrailerStory    = "Daedalus-Story"
+	TrailerPRD      = "Daedalus-PRD"
+	TrailerProvider = "Daedalus-Provider"
+	TrailerRunID    = "Daedalus-Run-ID"
+)
+
+// BuildCommitMessage returns the commit message for story:
+//
+//	<type>(<story id>): <title>
+//
+//	<agent summary>
+//
+//	Files changed:
+//	<diffstat>
+//
+//	Daedalus-Story: <story id>
+//	...
+//
+// Sections and trailers with no value are left out.
+func BuildCommitMessage(story StoryCommit, diffStat string) string {
+	commitType := strings.TrimSpace(story.Type)
+	if commitType == "" {
+		commitType = "feat"
+	}
+	storyID := strings.TrimSpace(story.StoryID)
+	title := strings.Join(strings.Fields(story.Title), " ")
+
+	sections := []string{fmt.Sprintf("%s(%s): %s", commitType, storyID, title)}
+	if summary := commitSummary(story.Summary); summary != "" {
+		sections = append(sections, summary)
+	}
+	if stat := strings.TrimRight(diffStat, " \n"); strings.TrimSpace(stat) != "" {
+		sections = append(sections, "Files changed:\n"+stat)
+	}
+
+	var trailers []string
+	for _, trailer := range []struct{ key, value string }{
+		{TrailerStory, storyID},
+		{TrailerPRD, story.PRD},
+		{TrailerProvider, story.Provider},
+		{TrailerRunID, story.RunID},
+	} {
+		if value := strings.Join(strings.Fields(trailer.value), " "); value != "" {
+			trailers = append(trailers, trailer.key+": "+value)
+		}
+	}
+	if len(trailers) > 0 {
+		sections = append(sections, strings.Join(trailers, "\n"))
+	}
+	return strings.Join(sections, "\n\n") + "\n"
+}
+
+// commitSummary trims the agent summary to fit a commit body. The default
+// "iteration completed" placeholder carries no information and is dropped.
+func commitSummary(summary string) string {
+	summary = strings.TrimSpace(summary)
+	if summary == "" || summary == "iteration completed" {
+		return ""
+	}
+	if len(summary) > maxCommitSummaryBytes {
+		cut := summary[:maxCommitSummaryBytes]
+		if newline := strings.LastIndexByte(cut, '\n'); newline > 0 {
+			cut = cut[:newline]
+		}
+		summary = strings.TrimRight(strings.ToValidUTF8(cut, ""), " \n") + "\n[...]"
+	}
+	return summary
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/secrets"
+)
+
+// WithSecretScanner makes CommitStory scan what it is about to commit and
+// refuse to commit when the scanner finds anything.
+func (c Committer) WithSecretScanner(scanner secrets.Scanner) Committer {
+	c.secrets = &scanner
+	return c
+}
+
+// scanSecrets scans the files `git add -A` would stage in workDir and returns
+// a *secrets.BlockedError when there are findings. Only the lines the commit
+// adds are scanned, so secrets already in HEAD do not block every commit.
+func (c Committer) scanSecrets(ctx context.Context, workDir string) error {
+	changes, err := addedChanges(ctx, workDir)
+	if err != nil {
+		return err
+	}
+	if findings := c.secrets.Scan(changes); len(findings) > 0 {
+		return &secrets.BlockedError{Findings: findings}
+	}
+	return nil
+}
+
+// addedChanges lists the files of workDir's working tree that are added or
+// modified since HEAD, with the lines they add. Daedalus' own artifacts are
+// left out.
+func addedChanges(ctx context.Context, workDir string) ([]secrets.Change, error) {
+	current, err := commitWorktree(ctx, workDir, "", "daedalus: secret scan")
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-c", "core.quotePath=false", "diff-tree", "-r", "--no-commit-id", "--no-renames", "--relative", "--diff-filter=d"}
+	trees := []string{current}
+	if head := currentHead(ctx, workDir); head != "" {
+		trees = []string{head, current}
+	} else {
+		args = append(args, "--root")
+	}
+
+	names, err := gitOutput(ctx, workDir, append(append(args, "--name-only", "-z"), trees...)...)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := gitOutput(ctx, workDir, append(append(args, "-p", "-U0", "--no-prefix", "--no-color", "--no-ext-diff"), trees...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	added := parseAddedLines(patch)
+	var changes []secrets.Change
+	for _, name := range splitNul(names) {
+		changes = append(changes, secrets.Change{Path: name, Lines: added[name]})
+	}
+	return changes, nil
+}
+
+// parseAddedLines reads a zero-context patch made with --no-prefix and
+// returns the added lines of each file, numbered as in the new file.
+func parseAddedLines(patch string) map[string][]secrets.Line {
+	added := map[string][]secrets.Line{}
+	file := ""
+	inHunk := false
+	next := 0
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			file = unquotePath(strings.TrimPrefix(line, "+++ "))
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+			next = hunkStart(line)
+		case inHunk && strings.HasPrefix(line, "+"):
+			if file != "" {
+				added[file] = append(added[file], secrets.Line{Number: next, Text: line[1:]})
+			}
+			next++
+		case inHunk && strings.HasPrefix(line, " "):
+			next++
+		}
+	}
+	return added
+}
+
+// hunkStart returns the first new-file line of a hunk header such as
+// "@@ -3,0 +4,2 @@".
+func hunkStart(header string) int {
+	_, rest, ok := strings.Cut(header, " +")
+	if !ok {
+		return 0
+	}
+	end := strings.IndexAny(rest, ", ")
+	if end < 0 {
+		end = len(rest)
+	}
+	start, _ := strconv.Atoi(rest[:end])
+	return start
+}
+
+// unquotePath undoes git's C-style quoting of paths with special characters.
+func unquotePath(value string) string {
+	value = strings.TrimSuffix(value, "\t")
+	if strings.HasPrefix(value, `"`) {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+	return value
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/secrets"
+)
+
+// The token is assembled at run time so the repository holds no secret.
+var testToken = "gh" + "p_" + "R8fK2mQ9vLx4TzW7pN3bJ6hC1sD5gY0aE2uI"
+
+func TestCommitStoryBlocksSecretsBeforeStaging(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	writeFile(t, filepath.Join(repo, "main.go"), "package main\n")
+	run(t, repo, "git", "add", "-A")
+	run(t, repo, "git", "commit", "-m", "initial commit")
+
+	writeFile(t, filepath.Join(repo, "main.go"), "package main\n\nconst token = \""+testToken+"\"\n")
+	writeFile(t, filepath.Join(repo, ".env"), "DEBUG=1\n")
+
+	committer := NewCommitter().WithSecretScanner(secrets.NewScanner())
+	_, err := committer.CommitStory(context.Background(), repo, StoryCommit{StoryID: "US-201", Title: "Add token"})
+	var blocked *secrets.BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected the commit to be blocked, got %v", err)
+	}
+	if len(blocked.Findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", blocked.Findings)
+	}
+	if finding := blocked.Findings[1]; finding.Path != "main.go" || finding.Line != 3 || finding.RuleID != "github-token" {
+		t.Fatalf("unexpected finding %+v", finding)
+	}
+
+	cmd := exec.Command("git", "diff", "--cached", "--name-only")
+	cmd.Dir = repo
+	staged, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git diff: %v", err)
+	}
+	if strings.TrimSpace(string(staged)) != "" {
+		t.Fatalf("expected nothing staged, got %s", staged)
+	}
+}
+
+func TestCommitStoryBlocksSecretsInFirstCommit(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	writeFile(t, filepath.Join(repo, "config.yaml"), "github:\n  token: "+testToken+"\n")
+
+	committer := NewCommitter().WithSecretScanner(secrets.NewScanner())
+	_, err := committer.CommitStory(context.Background(), repo, StoryCommit{StoryID: "US-203", Title: "Add config"})
+	var blocked *secrets.BlockedError
+	if !errors.As(err, &blocked) || len(blocked.Findings) != 1 || blocked.Findings[0].Line != 2 {
+		t.Fatalf("expected the token to block the first commit, got %v", err)
+	}
+}
+
+func TestCommitStoryScansOnlyAddedLines(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	// A secret committed before the scanner was enabled does not block
+	// later commits that leave it alone.
+	writeFile(t, filepath.Join(repo, "legacy.go"), "package main\n\nconst token = \""+testToken+"\"\n")
+	run(t, repo, "git", "add", "-A")
+	run(t, repo, "git", "commit", "-m", "initial commit")
+	writeFile(t, filepath.Join(repo, "legacy.go"), "package main\n\nconst token = \""+testToken+"\"\n\nfunc f() {}\n")
+
+	committer := NewCommitter().WithSecretScanner(secrets.NewScanner())
+	result, err := committer.CommitStory(context.Background(), repo, StoryCommit{StoryID: "US-202", Title: "Add f"})
+	if err != nil || !result.Committed {
+		t.Fatalf("expected the commit to go through, got %+v (%v)", result, err)
+	}
+}
+
+func TestParseAddedLinesNumbersNewFileLines(t *testing.T) {
+	t.Parallel()
+
+	patch := `diff --git app.go app.go
+index 1111111..2222222 100644
+--- app.go
++++ app.go
+@@ -2,0 +3,2 @@ package app
++var a = 1
+++++ not a header
+@@ -10 +12 @@ func f() {
+-	old()
++	new()
+diff --git "dir/my\tfile.txt" "dir/my\tfile.txt"
+new file mode 100644
+--- /dev/null
++++ "dir/my\tfile.txt"
+@@ -0,0 +1 @@
++hello
+diff --git gone.txt gone.txt
+--- gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	added := parseAddedLines(patch)
+	app := added["app.go"]
+	if len(app) != 3 || app[0] != (secrets.Line{Number: 3, Text: "var a = 1"}) || app[1].Text != "+++ not a header" || app[2] != (secrets.Line{Number: 12, Text: "\tnew()"}) {
+		t.Fatalf("unexpected app.go lines %+v", app)
+	}
+	if lines := added["dir/my\tfile.txt"]; len(lines) != 1 || lines[0].Number != 1 {
+		t.Fatalf("expected the quoted path to be unquoted, got %+v", added)
+	}
+	if len(added) != 2 {
+		t.Fatalf("expected no lines for a deleted file, got %+v", added)
+	}
+}
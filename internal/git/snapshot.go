@@ -0,0 +1,168 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// artifactsExclude keeps Daedalus' own artifacts out of snapshots and
+// restores, so progress and events written during a story survive a rollback.
+const artifactsExclude = ":(exclude).daedalus"
+
+// Snapshot is a commit holding the working tree as it was before a story ran,
+// tracked and untracked files alike. Creating it leaves HEAD, the index, and
+// the working tree untouched.
+type Snapshot struct {
+	Ref    string `json:"ref"`
+	Commit string `json:"commit"`
+	// Head is the commit HEAD pointed to; empty in a repository without
+	// commits.
+	Head string `json:"head,omitempty"`
+}
+
+// Snapshot records the working tree of workDir as a commit and points ref at
+// it. Files ignored by .gitignore are not included.
+func (Committer) Snapshot(ctx context.Context, workDir, ref, message string) (Snapshot, error) {
+	head := currentHead(ctx, workDir)
+	commit, err := commitWorktree(ctx, workDir, head, message)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := runGit(ctx, workDir, "update-ref", ref, commit); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Ref: ref, Commit: commit, Head: head}, nil
+}
+
+// Restore puts the working tree back the way it was when snapshot was taken.
+// Commits made since are dropped from the current branch, files created since
+// are deleted, and every restored change is left unstaged.
+func (Committer) Restore(ctx context.Context, workDir string, snapshot Snapshot) error {
+	if snapshot.Head != "" && currentHead(ctx, workDir) != snapshot.Head {
+		if err := runGit(ctx, workDir, "reset", "-q", "--soft", snapshot.Head); err != nil {
+			return err
+		}
+	}
+
+	saved, err := gitOutput(ctx, workDir, "ls-tree", "-r", "-z", "--name-only", snapshot.Commit)
+	if err != nil {
+		return err
+	}
+	keep := map[string]struct{}{}
+	for _, path := range splitNul(saved) {
+		keep[path] = struct{}{}
+	}
+	current, err := gitOutput(ctx, workDir, "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--", ".", artifactsExclude)
+	if err != nil {
+		return err
+	}
+	for _, path := range splitNul(current) {
+		if _, ok := keep[path]; ok || isArtifactPath(path) {
+			continue
+		}
+		if err := removeFile(workDir, path); err != nil {
+			return err
+		}
+	}
+
+	if len(keep) > 0 {
+		if err := runGit(ctx, workDir, "checkout", snapshot.Commit, "--", ".", artifactsExclude); err != nil {
+			return err
+		}
+	}
+	if snapshot.Head == "" {
+		return runGit(ctx, workDir, "rm", "-r", "-q", "--cached", "--ignore-unmatch", "--", ".", artifactsExclude)
+	}
+	return runGit(ctx, workDir, "reset", "-q", "--", ".", artifactsExclude)
+}
+
+// Park commits the current working tree to branch, without checking it out,
+// and then restores snapshot. It returns the parked commit.
+func (c Committer) Park(ctx context.Context, workDir string, snapshot Snapshot, branch, message string) (string, error) {
+	commit, err := commitWorktree(ctx, workDir, currentHead(ctx, workDir), message)
+	if err != nil {
+		return "", err
+	}
+	if err := runGit(ctx, workDir, "update-ref", "refs/heads/"+branch, commit); err != nil {
+		return "", err
+	}
+	if err := c.Restore(ctx, workDir, snapshot); err != nil {
+		return commit, err
+	}
+	return commit, nil
+}
+
+// DropSnapshot deletes the snapshot's ref once it is no longer needed.
+func (Committer) DropSnapshot(ctx context.Context, workDir string, snapshot Snapshot) error {
+	return runGit(ctx, workDir, "update-ref", "-d", snapshot.Ref)
+}
+
+func currentHead(ctx context.Context, workDir string) string {
+	head, err := gitOutput(ctx, workDir, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(head)
+}
+
+// commitWorktree writes the working tree to a commit through a temporary
+// index, so the real index is left alone.
+func commitWorktree(ctx context.Context, workDir, parent, message string) (string, error) {
+	indexDir, err := os.MkdirTemp("", "daedalus-index-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.RemoveAll(indexDir)
+	}()
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(indexDir, "index")}
+
+	if _, err := gitOutputEnv(ctx, workDir, env, "add", "-A", "--", ".", artifactsExclude); err != nil {
+		return "", err
+	}
+	tree, err := gitOutputEnv(ctx, workDir, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", strings.TrimSpace(tree), "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := gitOutput(ctx, workDir, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(commit), nil
+}
+
+func splitNul(output string) []string {
+	var paths []string
+	for _, path := range strings.Split(output, "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func isArtifactPath(path string) bool {
+	return path == ".daedalus" || strings.HasPrefix(path, ".daedalus/")
+}
+
+// removeFile deletes path and any directories it leaves empty.
+func removeFile(workDir, path string) error {
+	full := filepath.Join(workDir, filepath.FromSlash(path))
+	if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	root := filepath.Clean(workDir)
+	for dir := filepath.Dir(full); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
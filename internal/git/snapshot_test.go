@@ -0,0 +1,138 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreRevertsStoryChangesAndKeepsArtifacts(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	writeFile(t, filepath.Join(repo, "tracked.txt"), "original\n")
+	run(t, repo, "git", "add", "-A")
+	run(t, repo, "git", "commit", "-m", "initial commit")
+	// Pre-existing user edits must survive the rollback.
+	writeFile(t, filepath.Join(repo, "tracked.txt"), "user edit\n")
+	writeFile(t, filepath.Join(repo, "notes.txt"), "untracked user notes\n")
+
+	committer := NewCommitter()
+	ctx := context.Background()
+	snapshot, err := committer.Snapshot(ctx, repo, "refs/daedalus/snapshots/main/US-001", "snapshot")
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if status := gitStatus(t, repo); !strings.Contains(status, " M tracked.txt") || !strings.Contains(status, "?? notes.txt") {
+		t.Fatalf("expected the snapshot to leave the tree alone, got:\n%s", status)
+	}
+
+	// The agent edits, creates, stages, and commits files.
+	writeFile(t, filepath.Join(repo, "tracked.txt"), "agent edit\n")
+	if err := os.MkdirAll(filepath.Join(repo, "pkg"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, filepath.Join(repo, "pkg", "half.go"), "package pkg\n")
+	run(t, repo, "git", "add", "pkg/half.go")
+	writeFile(t, filepath.Join(repo, "committed.txt"), "agent commit\n")
+	run(t, repo, "git", "add", "committed.txt")
+	run(t, repo, "git", "commit", "-m", "agent commit")
+	if err := os.MkdirAll(filepath.Join(repo, ".daedalus"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeFile(t, filepath.Join(repo, ".daedalus", "progress.md"), "story failed\n")
+
+	if err := committer.Restore(ctx, repo, snapshot); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(repo, "tracked.txt")); got != "user edit\n" {
+		t.Fatalf("expected the user's edit back, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(repo, "notes.txt")); got != "untracked user notes\n" {
+		t.Fatalf("expected untracked notes to be kept, got %q", got)
+	}
+	for _, gone := range []string{"committed.txt", "pkg/half.go", "pkg"} {
+		if _, err := os.Stat(filepath.Join(repo, gone)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", gone, err)
+		}
+	}
+	if got := readFile(t, filepath.Join(repo, ".daedalus", "progress.md")); got != "story failed\n" {
+		t.Fatalf("expected artifacts to be left alone, got %q", got)
+	}
+	if head := currentHead(ctx, repo); head != snapshot.Head {
+		t.Fatalf("expected HEAD back at %s, got %s", snapshot.Head, head)
+	}
+	status := gitStatus(t, repo)
+	if !strings.Contains(status, " M tracked.txt") || !strings.Contains(status, "?? notes.txt") || strings.Contains(status, "half.go") {
+		t.Fatalf("expected the pre-story status, got:\n%s", status)
+	}
+}
+
+func TestParkMovesFailedChangesToBranch(t *testing.T) {
+	t.Parallel()
+
+	repo := initRepo(t)
+	writeFile(t, filepath.Join(repo, "tracked.txt"), "original\n")
+	run(t, repo, "git", "add", "-A")
+	run(t, repo, "git", "commit", "-m", "initial commit")
+
+	committer := NewCommitter()
+	ctx := context.Background()
+	snapshot, err := committer.Snapshot(ctx, repo, "refs/daedalus/snapshots/main/US-002", "snapshot")
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	writeFile(t, filepath.Join(repo, "tracked.txt"), "broken\n")
+	writeFile(t, filepath.Join(repo, "new.txt"), "new\n")
+
+	commit, err := committer.Park(ctx, repo, snapshot, "daedalus/failed/US-002", "park")
+	if err != nil {
+		t.Fatalf("park: %v", err)
+	}
+	if status := gitStatus(t, repo); status != "" {
+		t.Fatalf("expected a clean tree after parking, got:\n%s", status)
+	}
+	parked := gitOut(t, repo, "show", "daedalus/failed/US-002:tracked.txt")
+	if parked != "broken\n" {
+		t.Fatalf("expected the failed edit on the branch, got %q", parked)
+	}
+	if branchHead := strings.TrimSpace(gitOut(t, repo, "rev-parse", "daedalus/failed/US-002")); branchHead != commit {
+		t.Fatalf("expected the branch at %s, got %s", commit, branchHead)
+	}
+
+	if err := committer.DropSnapshot(ctx, repo, snapshot); err != nil {
+		t.Fatalf("drop snapshot: %v", err)
+	}
+	if refs := gitOut(t, repo, "for-each-ref", "refs/daedalus"); refs != "" {
+		t.Fatalf("expected the snapshot ref to be gone, got %q", refs)
+	}
+}
+
+func gitStatus(t *testing.T, dir string) string {
+	t.Helper()
+	return strings.TrimRight(gitOut(t, dir, "status", "--porcelain"), "\n")
+}
+
+func gitOut(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return string(output)
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,219 @@
+package loop
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	daedalusgit "github.com/EstebanForge/daedalus/internal/git"
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// Story run phases, in the order runStory goes through them.
+const (
+	runPhasePlan    = "plan"
+	runPhaseWork    = "work"
+	runPhaseReview  = "review"
+	runPhaseQuality = "quality"
+	runPhaseCommit  = "commit"
+)
+
+var runPhaseOrder = map[string]int{
+	runPhasePlan:    1,
+	runPhaseWork:    2,
+	runPhaseReview:  3,
+	runPhaseQuality: 4,
+	runPhaseCommit:  5,
+}
+
+// runState is the checkpoint of a story run, stored in
+// .daedalus/prds/<name>/runs/<storyID>.json. It is saved as each phase
+// starts, so a run interrupted mid-story resumes at the phase it was in.
+type runState struct {
+	StoryID string `json:"storyID"`
+	// Phase is the phase the run was in; every earlier phase has finished.
+	Phase string `json:"phase"`
+	// Attempts is how many work iterations the work phase took.
+	Attempts int    `json:"attempts,omitempty"`
+	PlanPath string `json:"planPath,omitempty"`
+	// Provider and SessionID identify the ACP session the work phase ran in,
+	// so repair and remediation prompts can continue it after a restart.
+	Provider  string           `json:"provider,omitempty"`
+	SessionID string           `json:"sessionID,omitempty"`
+	Summary   string           `json:"summary,omitempty"`
+	Quality   *runQualityState `json:"quality,omitempty"`
+	// Snapshot is the work dir as it was before the work phase.
+	Snapshot *daedalusgit.Snapshot `json:"snapshot,omitempty"`
+	// StartRef is the commit quality checks scoped to changes diff against.
+	StartRef string `json:"startRef,omitempty"`
+	// CoverageBaseline is the coverage before the work phase, when the
+	// coverage gate is enabled and a baseline was known.
+	CoverageBaseline *runCoverageState `json:"coverageBaseline,omitempty"`
+	UpdatedAt        string            `json:"updatedAt"`
+}
+
+// runQualityState is the outcome of the last quality run, without output.
+type runQualityState struct {
+	Passed  bool                    `json:"passed"`
+	Results []runQualityResultState `json:"results"`
+	// Coverage is the passed coverage gate, with its baseline.
+	Coverage *runCoverageResultState `json:"coverage,omitempty"`
+}
+
+type runCoverageState struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+type runCoverageResultState struct {
+	runCoverageState
+	Baseline *runCoverageState `json:"baseline,omitempty"`
+	Min      float64           `json:"min,omitempty"`
+	MaxDrop  float64           `json:"maxDrop,omitempty"`
+}
+
+type runQualityResultState struct {
+	Name         string `json:"name,omitempty"`
+	Group        string `json:"group,omitempty"`
+	Command      string `json:"command"`
+	ExitCode     int    `json:"exitCode"`
+	Duration     string `json:"duration"`
+	TimedOut     bool   `json:"timedOut,omitempty"`
+	Skipped      bool   `json:"skipped,omitempty"`
+	Unchanged    bool   `json:"unchanged,omitempty"`
+	AllowFailure bool   `json:"allowFailure,omitempty"`
+}
+
+// finished reports whether phase completed before the checkpoint was saved.
+func (s runState) finished(phase string) bool {
+	return runPhaseOrder[s.Phase] > runPhaseOrder[phase]
+}
+
+// resumeSessionID returns the saved session ID when it belongs to provider.
+func (s runState) resumeSessionID(provider string) string {
+	if s.Provider != provider {
+		return ""
+	}
+	return s.SessionID
+}
+
+func (s *runState) setQuality(report quality.Report) {
+	state := &runQualityState{Passed: report.Passed}
+	for _, result := range report.Results {
+		state.Results = append(state.Results, runQualityResultState{
+			Name:         result.Name,
+			Group:        result.Group,
+			Command:      result.Command,
+			ExitCode:     result.ExitCode,
+			Duration:     result.Duration.String(),
+			TimedOut:     result.TimedOut,
+			Skipped:      result.Skipped,
+			Unchanged:    result.Unchanged,
+			AllowFailure: result.AllowFailure,
+		})
+	}
+	if coverage := report.Coverage; coverage != nil {
+		state.Coverage = &runCoverageResultState{
+			runCoverageState: runCoverageState{Covered: coverage.Coverage.Covered, Total: coverage.Coverage.Total},
+			Min:              coverage.Min,
+			MaxDrop:          coverage.MaxDrop,
+		}
+		if coverage.Baseline != nil {
+			state.Coverage.Baseline = &runCoverageState{Covered: coverage.Baseline.Covered, Total: coverage.Baseline.Total}
+		}
+	}
+	s.Quality = state
+}
+
+// qualityReport rebuilds the saved quality outcome for the progress summary.
+func (s runState) qualityReport() quality.Report {
+	if s.Quality == nil {
+		return quality.Report{Passed: true}
+	}
+	report := quality.Report{Passed: s.Quality.Passed}
+	for _, result := range s.Quality.Results {
+		duration, _ := time.ParseDuration(result.Duration)
+		report.Results = append(report.Results, quality.Result{
+			Name:         result.Name,
+			Group:        result.Group,
+			Command:      result.Command,
+			ExitCode:     result.ExitCode,
+			Duration:     duration,
+			TimedOut:     result.TimedOut,
+			Skipped:      result.Skipped,
+			Unchanged:    result.Unchanged,
+			AllowFailure: result.AllowFailure,
+		})
+	}
+	if coverage := s.Quality.Coverage; coverage != nil {
+		report.Coverage = &quality.CoverageResult{
+			Coverage: quality.Coverage{Covered: coverage.Covered, Total: coverage.Total},
+			Min:      coverage.Min,
+			MaxDrop:  coverage.MaxDrop,
+		}
+		if coverage.Baseline != nil {
+			report.Coverage.Baseline = &quality.Coverage{Covered: coverage.Baseline.Covered, Total: coverage.Baseline.Total}
+		}
+	}
+	return report
+}
+
+// loadRunState returns the checkpoint to resume story from. Only a story
+// still marked in progress is resumed, and only while its saved plan exists;
+// otherwise the run starts from the first phase.
+func loadRunState(artifactDir, name string, story prd.UserStory) (runState, bool) {
+	fresh := runState{StoryID: story.ID}
+	if !story.InProgress {
+		return fresh, false
+	}
+	data, err := os.ReadFile(project.PRDRunStatePath(artifactDir, name, story.ID))
+	if err != nil {
+		return fresh, false
+	}
+	var state runState
+	if err := json.Unmarshal(data, &state); err != nil || state.StoryID != story.ID {
+		return fresh, false
+	}
+	if _, known := runPhaseOrder[state.Phase]; !known {
+		return fresh, false
+	}
+	if strings.TrimSpace(state.PlanPath) != "" {
+		if _, err := os.Stat(state.PlanPath); err != nil {
+			return fresh, false
+		}
+	}
+	return state, true
+}
+
+// saveRunState records that the run has entered phase.
+func saveRunState(artifactDir, name string, state *runState, phase string) error {
+	state.Phase = phase
+	state.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := project.PRDRunStatePath(artifactDir, name, state.StoryID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Write through a temp file so a crash never leaves a torn checkpoint.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func clearRunState(artifactDir, name, storyID string) error {
+	err := os.Remove(project.PRDRunStatePath(artifactDir, name, storyID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
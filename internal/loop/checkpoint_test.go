@@ -0,0 +1,165 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+func TestRunOnceKeepsRunStateWhenCancelledAndResumes(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	cancelled := countingProvider{fakeProvider: fakeProvider{err: context.Canceled}, name: "fake", calls: &calls}
+	manager, store, baseDir := newTestManager(t, cancelled, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the run to be cancelled, got %v", err)
+	}
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	state, ok := loadRunState(baseDir, "main", doc.UserStories[0])
+	if !ok || state.Phase != runPhaseWork {
+		t.Fatalf("expected a run state in the work phase, got %+v (%v)", state, ok)
+	}
+
+	manager.provider = countingProvider{fakeProvider: fakeProvider{}, name: "fake", calls: &calls}
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the work phase to run again, got %d calls", calls)
+	}
+	if _, err := os.Stat(project.PRDRunStatePath(baseDir, "main", state.StoryID)); !os.IsNotExist(err) {
+		t.Fatalf("expected the run state to be removed once the story passed, got %v", err)
+	}
+}
+
+func TestRunOnceResumesAtQualityPhaseWithSavedSession(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	var got providers.IterationRequest
+	provider := countingProvider{fakeProvider: fakeProvider{gotRequest: &got}, name: "fake", calls: &calls}
+	failing := fakeChecker{report: quality.Report{Passed: false, Results: []quality.Result{{Command: "go test ./...", ExitCode: 1}}}}
+	manager, store, baseDir := newTestManager(t, provider, failing, noRetries)
+	manager.SetQualityRepairRounds(1)
+
+	if err := store.Update("main", func(doc *prd.Document) error {
+		return setStoryInProgress(doc, doc.UserStories[0].ID)
+	}); err != nil {
+		t.Fatalf("mark in progress: %v", err)
+	}
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	storyID := doc.UserStories[0].ID
+	state := runState{StoryID: storyID, Attempts: 1, Provider: "fake", SessionID: "sess-42", Summary: "did the work"}
+	if err := saveRunState(baseDir, "main", &state, runPhaseQuality); err != nil {
+		t.Fatalf("save run state: %v", err)
+	}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected quality checks to fail")
+	}
+	if calls != 1 {
+		t.Fatalf("expected only the repair iteration to run, got %d calls", calls)
+	}
+	if got.Metadata["phase"] != "repair" || got.ResumeSessionID != "sess-42" {
+		t.Fatalf("expected the repair to resume the saved session, got %+v", got)
+	}
+	if _, err := os.Stat(project.PRDRunStatePath(baseDir, "main", storyID)); !os.IsNotExist(err) {
+		t.Fatalf("expected the run state to be removed after the story failed, got %v", err)
+	}
+}
+
+// crashingProvider opens session "sess-7" on every turn. Its first turn stops
+// the run while the turn is still in flight.
+type crashingProvider struct {
+	stop     context.CancelFunc
+	requests *[]providers.IterationRequest
+}
+
+func (p crashingProvider) Name() string {
+	return "fake"
+}
+
+func (p crashingProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p crashingProvider) RunIteration(_ context.Context, request providers.IterationRequest) (<-chan providers.Event, providers.IterationResult, error) {
+	*p.requests = append(*p.requests, request)
+	events := make(chan providers.Event, 1)
+	if len(*p.requests) == 1 {
+		p.stop()
+		events <- providers.Event{Type: providers.EventError, Message: providers.EncodeEventError(providers.ProviderError{Category: providers.ErrorTimeout, Message: "context canceled"})}
+	} else {
+		events <- providers.Event{Type: providers.EventAssistantText, Message: "done"}
+	}
+	close(events)
+	return events, providers.IterationResult{Success: true, ProviderRunID: "sess-7"}, nil
+}
+
+func TestRunOnceResumesSessionOfInterruptedWorkPhase(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	var requests []providers.IterationRequest
+	provider := crashingProvider{stop: stop, requests: &requests}
+	manager, _, baseDir := newTestManager(t, provider, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+
+	if err := manager.RunOnce(ctx, "main", baseDir, baseDir); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the run to stop mid-work, got %v", err)
+	}
+	data, err := os.ReadFile(project.PRDRunStatePath(baseDir, "main", "US-001"))
+	if err != nil {
+		t.Fatalf("read run state: %v", err)
+	}
+	if !strings.Contains(string(data), `"phase": "work"`) || !strings.Contains(string(data), `"sessionID": "sess-7"`) {
+		t.Fatalf("expected the work phase checkpoint to hold the session, got %s", data)
+	}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if len(requests) != 2 || requests[0].ResumeSessionID != "" || requests[1].ResumeSessionID != "sess-7" {
+		t.Fatalf("expected the resumed work phase to reuse the session, got %+v", requests)
+	}
+}
+
+func TestLoadRunStateIgnoresStoriesNotInProgress(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	state := runState{StoryID: "US-001"}
+	if err := saveRunState(baseDir, "main", &state, runPhaseReview); err != nil {
+		t.Fatalf("save run state: %v", err)
+	}
+	if _, ok := loadRunState(baseDir, "main", prd.UserStory{ID: "US-001"}); ok {
+		t.Fatal("expected a story that is not in progress to start over")
+	}
+	loaded, ok := loadRunState(baseDir, "main", prd.UserStory{ID: "US-001", InProgress: true})
+	if !ok || !loaded.finished(runPhaseWork) || loaded.finished(runPhaseReview) {
+		t.Fatalf("expected to resume at review, got %+v (%v)", loaded, ok)
+	}
+
+	state.PlanPath = "/missing/plan.md"
+	if err := saveRunState(baseDir, "main", &state, runPhaseReview); err != nil {
+		t.Fatalf("save run state: %v", err)
+	}
+	if _, ok := loadRunState(baseDir, "main", prd.UserStory{ID: "US-001", InProgress: true}); ok {
+		t.Fatal("expected a run whose plan is gone to start over")
+	}
+}
@@ -0,0 +1,193 @@
+package loop
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// SetCoverageGate enables the coverage gate, which runs after the quality
+// checks pass. A gate without profiles is disabled.
+func (m *Manager) SetCoverageGate(gate quality.CoverageGate) {
+	m.coverage = gate
+}
+
+// recordedCoverage is the coverage of the last story that passed, stored in
+// .daedalus/prds/<name>/coverage.json. It is the next story's baseline.
+type recordedCoverage struct {
+	StoryID   string  `json:"storyID"`
+	Covered   int     `json:"covered"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+	UpdatedAt string  `json:"updatedAt"`
+}
+
+// captureCoverageBaseline records the coverage before the story's work
+// phase: the coverage recorded by the last passed story, or else whatever
+// the profiles in workDir hold. Profiles on disk are not trusted when checks
+// are scoped to changes, since a scoped run may have left them partial. With
+// no baseline the gate checks only the minimum.
+func (m Manager) captureCoverageBaseline(artifactDir, name, workDir string, state *runState) {
+	if !m.coverage.Enabled() || state.CoverageBaseline != nil {
+		return
+	}
+	baseline := loadRecordedCoverage(artifactDir, name)
+	if baseline == nil && !quality.HasScopedChecks(m.qualityChecks) {
+		if coverage, err := quality.ReadCoverage(workDir, m.coverage.Profiles, time.Time{}); err == nil {
+			baseline = &coverage
+		}
+	}
+	if baseline != nil {
+		state.CoverageBaseline = &runCoverageState{Covered: baseline.Covered, Total: baseline.Total}
+	}
+}
+
+// coverageBaseline returns the baseline captured before the work phase. A
+// checkpoint saved without one falls back to the recorded coverage.
+func (s runState) coverageBaseline(artifactDir, name string) *quality.Coverage {
+	if s.CoverageBaseline != nil {
+		return &quality.Coverage{Covered: s.CoverageBaseline.Covered, Total: s.CoverageBaseline.Total}
+	}
+	return loadRecordedCoverage(artifactDir, name)
+}
+
+func loadRecordedCoverage(artifactDir, name string) *quality.Coverage {
+	data, err := os.ReadFile(project.PRDCoveragePath(artifactDir, name))
+	if err != nil {
+		return nil
+	}
+	var recorded recordedCoverage
+	if err := json.Unmarshal(data, &recorded); err != nil || recorded.Total <= 0 {
+		return nil
+	}
+	return &quality.Coverage{Covered: recorded.Covered, Total: recorded.Total}
+}
+
+// recordCoverage stores the coverage of a passed story as the baseline of
+// the next one.
+func recordCoverage(artifactDir, name, storyID string, coverage quality.Coverage) error {
+	data, err := json.MarshalIndent(recordedCoverage{
+		StoryID:   storyID,
+		Covered:   coverage.Covered,
+		Total:     coverage.Total,
+		Percent:   coverage.Percent(),
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := project.PRDCoveragePath(artifactDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// applyCoverageGate runs the coverage gate once the quality checks that began
+// at startedAt pass, and fails report when it does not pass.
+func (m Manager) applyCoverageGate(workDir string, baseline *quality.Coverage, startedAt time.Time, report quality.Report) quality.Report {
+	if !m.coverage.Enabled() || !report.Passed {
+		return report
+	}
+	result := m.coverage.Check(workDir, baseline, startedAt)
+	report.Coverage = &result
+	if !result.Passed() {
+		report.Passed = false
+	}
+	return report
+}
+
+func appendCoverageEvent(workDir, name, storyID string, iteration int, result quality.CoverageResult) error {
+	payload := map[string]interface{}{
+		"type":      string(providers.EventCommandOutput),
+		"message":   "coverage gate: " + formatCoverageLine(result),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"iteration": iteration,
+		"storyID":   storyID,
+		"phase":     "coverage",
+		"passed":    result.Passed(),
+	}
+	if result.Coverage.Total > 0 {
+		payload["covered"] = result.Coverage.Covered
+		payload["total"] = result.Coverage.Total
+		payload["percent"] = result.Coverage.Percent()
+	}
+	if result.Baseline != nil {
+		payload["baseline"] = result.Baseline.Percent()
+	}
+	if delta, ok := result.Delta(); ok {
+		payload["delta"] = delta
+	}
+	if result.Min > 0 {
+		payload["min"] = result.Min
+	}
+	if result.MaxDrop > 0 {
+		payload["maxDrop"] = result.MaxDrop
+	}
+	if !result.Passed() {
+		payload["failure"] = result.Failure
+	}
+	if err := appendEventPayload(workDir, name, payload); err != nil {
+		return err
+	}
+	return appendAgentLog(workDir, name, "[coverage] "+formatCoverageLine(result)+"\n")
+}
+
+// formatCoverageLine summarizes result on one line, as in "81.25% (baseline
+// 82.00%, -0.75 points)".
+func formatCoverageLine(result quality.CoverageResult) string {
+	if result.Coverage.Total == 0 {
+		return "unavailable: " + result.Failure
+	}
+	line := fmt.Sprintf("%.2f%%", result.Coverage.Percent())
+	if delta, ok := result.Delta(); ok {
+		line += fmt.Sprintf(" (baseline %.2f%%, %+.2f points)", result.Baseline.Percent(), delta)
+	} else {
+		line += " (no baseline)"
+	}
+	if !result.Passed() {
+		line += ": " + result.Failure
+	}
+	return line
+}
+
+func formatCoverageSummary(result quality.CoverageResult) string {
+	builder := strings.Builder{}
+	builder.WriteString("- Coverage: ")
+	if result.Coverage.Total == 0 {
+		builder.WriteString("unavailable\n")
+	} else {
+		fmt.Fprintf(&builder, "%.2f%% (%d of %d)\n", result.Coverage.Percent(), result.Coverage.Covered, result.Coverage.Total)
+	}
+	if result.Baseline != nil {
+		fmt.Fprintf(&builder, "  Baseline: %.2f%%\n", result.Baseline.Percent())
+	} else {
+		builder.WriteString("  Baseline: none recorded\n")
+	}
+	if delta, ok := result.Delta(); ok {
+		fmt.Fprintf(&builder, "  Delta: %+.2f points\n", delta)
+	}
+	if result.Min > 0 {
+		fmt.Fprintf(&builder, "  Minimum: %.2f%%\n", result.Min)
+	}
+	if result.MaxDrop > 0 {
+		fmt.Fprintf(&builder, "  Max drop: %.2f points\n", result.MaxDrop)
+	}
+	if !result.Passed() {
+		builder.WriteString("  Failed: ")
+		builder.WriteString(result.Failure)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,138 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// coverProfile is a Go cover profile with covered of 100 statements.
+func coverProfile(covered int) string {
+	return fmt.Sprintf("mode: set\na.go:1.1,2.1 %d 1\nb.go:1.1,2.1 %d 0\n", covered, 100-covered)
+}
+
+// writeCoverProfile writes coverProfile(covered) to dir/cover.out.
+func writeCoverProfile(t *testing.T, dir string, covered int) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "cover.out"), []byte(coverProfile(covered)), 0o644); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+}
+
+// profileChecker passes the quality checks after writing a cover profile, as a
+// `go test -coverprofile` command would.
+type profileChecker struct {
+	covered int
+}
+
+func (c profileChecker) Run(_ context.Context, workDir string, _ []quality.Check) (quality.Report, error) {
+	if err := os.WriteFile(filepath.Join(workDir, "cover.out"), []byte(coverProfile(c.covered)), 0o644); err != nil {
+		return quality.Report{}, err
+	}
+	return quality.Report{Passed: true}, nil
+}
+
+func TestRunOnceRecordsCoverageWithBaselineAndDelta(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	manager, _, baseDir := newTestManager(t, countingProvider{name: "fake", calls: &calls}, profileChecker{covered: 79}, noRetries)
+	manager.SetCoverageGate(quality.CoverageGate{Profiles: []string{"cover.out"}, Min: 50, MaxDrop: 2})
+	if err := recordCoverage(baseDir, "main", "US-000", quality.Coverage{Covered: 80, Total: 100}); err != nil {
+		t.Fatalf("record baseline: %v", err)
+	}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	for _, expected := range []string{"- Coverage: 79.00% (79 of 100)", "Baseline: 80.00%", "Delta: -1.00 points"} {
+		if !strings.Contains(string(progress), expected) {
+			t.Fatalf("expected progress to contain %q, got:\n%s", expected, progress)
+		}
+	}
+	recorded := loadRecordedCoverage(baseDir, "main")
+	if recorded == nil || *recorded != (quality.Coverage{Covered: 79, Total: 100}) {
+		t.Fatalf("expected the story's coverage to become the baseline, got %+v", recorded)
+	}
+}
+
+func TestRunOnceFailsStoryWhenCoverageDrops(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	manager, _, baseDir := newTestManager(t, countingProvider{name: "fake", calls: &calls}, profileChecker{covered: 75}, noRetries)
+	manager.SetCoverageGate(quality.CoverageGate{Profiles: []string{"cover.out"}, MaxDrop: 2})
+	if err := recordCoverage(baseDir, "main", "US-000", quality.Coverage{Covered: 90, Total: 100}); err != nil {
+		t.Fatalf("record baseline: %v", err)
+	}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected the coverage drop to fail the story")
+	}
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if !strings.Contains(string(progress), "Failed: coverage fell 15.00 points from 90.00% to 75.00%") {
+		t.Fatalf("expected the coverage failure in progress, got:\n%s", progress)
+	}
+	events, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	for _, expected := range []string{`"phase":"coverage"`, `"baseline":90`, `"delta":-15`, `"passed":false`} {
+		if !strings.Contains(string(events), expected) {
+			t.Fatalf("expected events to contain %q, got:\n%s", expected, events)
+		}
+	}
+	if recorded := loadRecordedCoverage(baseDir, "main"); recorded == nil || recorded.Covered != 90 {
+		t.Fatalf("expected the baseline to stay, got %+v", recorded)
+	}
+}
+
+func TestRunOnceFailsStoryWhenCoverageProfileIsStale(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	manager, _, baseDir := newTestManager(t, countingProvider{name: "fake", calls: &calls}, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.SetCoverageGate(quality.CoverageGate{Profiles: []string{"cover.out"}, Min: 50})
+	// A profile from an earlier run that the quality checks did not rewrite.
+	writeCoverProfile(t, baseDir, 90)
+	stale := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(baseDir, "cover.out"), stale, stale); err != nil {
+		t.Fatalf("age profile: %v", err)
+	}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected a stale profile to fail the story")
+	}
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if !strings.Contains(string(progress), "was not written by this run") {
+		t.Fatalf("expected the stale profile failure in progress, got:\n%s", progress)
+	}
+}
+
+func TestBuildRepairPromptIncludesCoverageFailure(t *testing.T) {
+	t.Parallel()
+
+	report := quality.Report{Coverage: &quality.CoverageResult{Failure: "coverage 60.00% is below the minimum of 70.00%"}}
+	prompt := buildRepairPrompt(prd.UserStory{ID: "US-001", Title: "Widget"}, report, 1, 2)
+	if !strings.Contains(prompt, "Coverage gate failed: coverage 60.00% is below the minimum of 70.00%") || strings.Contains(prompt, "Failing commands:") {
+		t.Fatalf("expected only the coverage failure, got:\n%s", prompt)
+	}
+}
@@ -0,0 +1,120 @@
+package loop
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+// FallbackProvider is a provider the loop switches to when the active one
+// keeps failing, together with its own iteration options.
+type FallbackProvider struct {
+	Provider  providers.Provider
+	Iteration IterationOptions
+}
+
+// ProviderSwitchReporter is called when the loop moves to a fallback provider.
+type ProviderSwitchReporter func(from, to string, reason error)
+
+// providerChain is the primary provider followed by its fallbacks. It is
+// shared by every copy of the Manager, so once a run has switched, later
+// stories (including parallel ones) stay on the fallback.
+type providerChain struct {
+	mu      sync.Mutex
+	entries []FallbackProvider
+	active  int
+}
+
+// SetFallbackProviders sets the providers tried, in order, after the primary
+// provider fails with an authentication error or stays rate limited after all
+// retries.
+func (m *Manager) SetFallbackProviders(fallbacks []FallbackProvider) {
+	if len(fallbacks) == 0 {
+		m.chain = nil
+		return
+	}
+	entries := make([]FallbackProvider, 0, len(fallbacks)+1)
+	entries = append(entries, FallbackProvider{Provider: m.provider, Iteration: m.iteration})
+	entries = append(entries, fallbacks...)
+	m.chain = &providerChain{entries: entries}
+}
+
+// SetProviderSwitchReporter sets a callback for provider fallbacks. The TUI
+// uses it to show the active provider in its header.
+func (m *Manager) SetProviderSwitchReporter(reporter ProviderSwitchReporter) {
+	m.providerSwitchReporter = reporter
+}
+
+// activeProvider returns the provider iterations currently run on, its
+// options, and its position in the chain.
+func (m Manager) activeProvider() (providers.Provider, IterationOptions, int) {
+	if m.chain == nil {
+		return m.provider, m.iteration, 0
+	}
+	m.chain.mu.Lock()
+	defer m.chain.mu.Unlock()
+	entry := m.chain.entries[m.chain.active]
+	return entry.Provider, entry.Iteration, m.chain.active
+}
+
+// advance moves past the provider at index from. When another story already
+// advanced, the provider it moved to is returned. ok is false when the chain
+// is exhausted.
+func (c *providerChain) advance(from int) (entry FallbackProvider, index int, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active <= from {
+		if from+1 >= len(c.entries) {
+			return FallbackProvider{}, from, false
+		}
+		c.active = from + 1
+	}
+	return c.entries[c.active], c.active, true
+}
+
+// shouldFallBack reports whether err warrants switching providers. It is
+// called once retries are exhausted, so rate limits count as persistent.
+func shouldFallBack(err error) bool {
+	var providerErr providers.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	switch providerErr.Category {
+	case providers.ErrorAuthentication, providers.ErrorRateLimit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m Manager) reportProviderSwitch(from, to string, reason error) {
+	if m.providerSwitchReporter != nil {
+		m.providerSwitchReporter(from, to, reason)
+	}
+}
+
+func appendProviderSwitchEvent(workDir, name, storyID string, iteration int, from, to string, reason error) error {
+	category := string(providers.ErrorFatal)
+	var providerErr providers.ProviderError
+	if errors.As(reason, &providerErr) {
+		category = string(providerErr.Category)
+	}
+	payload := map[string]interface{}{
+		"type":      string(providers.EventProviderFallback),
+		"message":   fmt.Sprintf("provider %s failed (%s); switching to %s", from, category, to),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"iteration": iteration,
+		"storyID":   storyID,
+		"from":      from,
+		"to":        to,
+		"category":  category,
+		"reason":    reason.Error(),
+	}
+	if err := appendEventPayload(workDir, name, payload); err != nil {
+		return err
+	}
+	return appendAgentLog(workDir, name, fmt.Sprintf("[fallback] %s -> %s: %s\n", from, to, reason.Error()))
+}
@@ -0,0 +1,140 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+type countingProvider struct {
+	fakeProvider
+	name  string
+	calls *int
+}
+
+func (p countingProvider) Name() string {
+	return p.name
+}
+
+func (p countingProvider) RunIteration(ctx context.Context, request providers.IterationRequest) (<-chan providers.Event, providers.IterationResult, error) {
+	*p.calls++
+	return p.fakeProvider.RunIteration(ctx, request)
+}
+
+func newFallbackTestManager(t *testing.T, primary providers.Provider, maxRetries int) (Manager, prd.Store, string) {
+	t.Helper()
+	manager, store, baseDir := newTestManager(t, primary, fakeChecker{report: quality.Report{Passed: true}}, RetryPolicy{MaxRetries: maxRetries, Delays: []time.Duration{0}})
+	manager.iteration.Model = "primary-model"
+	return manager, store, baseDir
+}
+
+func TestRunOnceFallsBackOnPersistentProviderErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		err          error
+		maxRetries   int
+		primaryCalls int
+	}{
+		{name: "authentication", err: providers.ProviderError{Category: providers.ErrorAuthentication, Message: "not logged in"}, maxRetries: 2, primaryCalls: 1},
+		{name: "rate limit after retries", err: providers.ProviderError{Category: providers.ErrorRateLimit, Message: "429"}, maxRetries: 1, primaryCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var primaryCalls, fallbackCalls int
+			var fallbackRequest providers.IterationRequest
+			primary := countingProvider{fakeProvider: fakeProvider{err: tt.err}, name: "codex", calls: &primaryCalls}
+			fallback := countingProvider{
+				fakeProvider: fakeProvider{events: []providers.Event{{Type: providers.EventAssistantText, Message: "done"}}, gotRequest: &fallbackRequest},
+				name:         "claude",
+				calls:        &fallbackCalls,
+			}
+			manager, store, baseDir := newFallbackTestManager(t, primary, tt.maxRetries)
+			manager.SetFallbackProviders([]FallbackProvider{{Provider: fallback, Iteration: IterationOptions{Model: "fallback-model"}}})
+			var switches []string
+			manager.SetProviderSwitchReporter(func(from, to string, reason error) {
+				switches = append(switches, from+"->"+to)
+			})
+
+			if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+				t.Fatalf("run once: %v", err)
+			}
+			if primaryCalls != tt.primaryCalls || fallbackCalls != 1 {
+				t.Fatalf("expected %d primary and 1 fallback call, got %d and %d", tt.primaryCalls, primaryCalls, fallbackCalls)
+			}
+			if fallbackRequest.Model != "fallback-model" {
+				t.Fatalf("expected fallback iteration options, got model %q", fallbackRequest.Model)
+			}
+			if strings.Join(switches, ",") != "codex->claude" {
+				t.Fatalf("unexpected switches: %v", switches)
+			}
+			doc, err := store.Load("main")
+			if err != nil {
+				t.Fatalf("load PRD: %v", err)
+			}
+			if !doc.UserStories[0].Passes {
+				t.Fatal("expected story to pass on the fallback provider")
+			}
+
+			eventsData, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+			if err != nil {
+				t.Fatalf("read events.jsonl: %v", err)
+			}
+			if !strings.Contains(string(eventsData), `"type":"provider_fallback"`) || !strings.Contains(string(eventsData), `"to":"claude"`) {
+				t.Fatalf("expected provider_fallback event, got: %s", eventsData)
+			}
+
+			// Later iterations stay on the fallback.
+			provider, options, _ := manager.activeProvider()
+			if provider.Name() != "claude" || options.Model != "fallback-model" {
+				t.Fatalf("expected fallback to stay active, got %s/%s", provider.Name(), options.Model)
+			}
+		})
+	}
+}
+
+func TestRunOnceDoesNotFallBackOnOtherErrors(t *testing.T) {
+	t.Parallel()
+
+	var primaryCalls, fallbackCalls int
+	primary := countingProvider{fakeProvider: fakeProvider{err: providers.NewConfigurationError("bad model", nil)}, name: "codex", calls: &primaryCalls}
+	fallback := countingProvider{name: "claude", calls: &fallbackCalls}
+	manager, _, baseDir := newFallbackTestManager(t, primary, 0)
+	manager.SetFallbackProviders([]FallbackProvider{{Provider: fallback}})
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected configuration error to fail the story")
+	}
+	if fallbackCalls != 0 {
+		t.Fatalf("expected no fallback, got %d calls", fallbackCalls)
+	}
+}
+
+func TestRunOnceFailsWhenFallbackChainIsExhausted(t *testing.T) {
+	t.Parallel()
+
+	authErr := providers.ProviderError{Category: providers.ErrorAuthentication, Message: "not logged in"}
+	var primaryCalls, fallbackCalls int
+	primary := countingProvider{fakeProvider: fakeProvider{err: authErr}, name: "codex", calls: &primaryCalls}
+	fallback := countingProvider{fakeProvider: fakeProvider{err: authErr}, name: "claude", calls: &fallbackCalls}
+	manager, _, baseDir := newFallbackTestManager(t, primary, 0)
+	manager.SetFallbackProviders([]FallbackProvider{{Provider: fallback}})
+
+	err := manager.RunOnce(context.Background(), "main", baseDir, baseDir)
+	if err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Fatalf("expected the last provider error, got %v", err)
+	}
+	if primaryCalls != 1 || fallbackCalls != 1 {
+		t.Fatalf("expected each provider to be tried once, got %d and %d", primaryCalls, fallbackCalls)
+	}
+}
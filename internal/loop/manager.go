@@ -31,19 +31,26 @@ type IterationOptions struct {
 type CompletionPolicy struct {
 	PushOnComplete   bool
 	AutoPROnComplete bool
+	// PRBodyTemplate is the text/template the pull request body is rendered
+	// from; empty uses the built-in template.
+	PRBodyTemplate string
+	// PRDraft, PRLabels, and PRReviewers apply to new pull requests.
+	PRDraft     bool
+	PRLabels    []string
+	PRReviewers []string
 }
 
 type qualityChecker interface {
-	Run(ctx context.Context, workDir string, commands []string) (quality.Report, error)
+	Run(ctx context.Context, workDir string, checks []quality.Check) (quality.Report, error)
 }
 
 type committer interface {
-	CommitStory(ctx context.Context, workDir, storyID, storyTitle string) (daedalusgit.CommitResult, error)
+	CommitStory(ctx context.Context, workDir string, story daedalusgit.StoryCommit) (daedalusgit.CommitResult, error)
 }
 
 type completionExecutor interface {
 	PushBranch(ctx context.Context, workDir string) error
-	CreatePR(ctx context.Context, workDir string) error
+	CreatePR(ctx context.Context, workDir string, pr daedalusgit.PullRequest) (daedalusgit.PullRequestResult, error)
 }
 
 // PhaseReporter is called when the loop enters a new execution phase.
@@ -51,20 +58,34 @@ type completionExecutor interface {
 type PhaseReporter func(phase, description string)
 
 type Manager struct {
-	store              prd.Store
-	provider           providers.Provider
-	retry              RetryPolicy
-	iteration          IterationOptions
-	qualityChecker     qualityChecker
-	qualityCommands    []string
-	committer          committer
-	completion         CompletionPolicy
-	completionExec     completionExecutor
-	planEnabled        bool
-	reviewer           quality.Reviewer
-	reviewPerspectives []string
-	compoundEnabled    bool
-	phaseReporter      PhaseReporter
+	store                   prd.Store
+	provider                providers.Provider
+	retry                   RetryPolicy
+	iteration               IterationOptions
+	qualityChecker          qualityChecker
+	qualityChecks           []quality.Check
+	committer               committer
+	completion              CompletionPolicy
+	completionExec          completionExecutor
+	planEnabled             bool
+	reviewer                quality.Reviewer
+	reviewPerspectives      []string
+	compoundEnabled         bool
+	phaseReporter           PhaseReporter
+	workspaces              storyWorkspaces
+	qualityRepairRounds     int
+	reviewRemediationRounds int
+	approver                providers.Approver
+	chain                   *providerChain
+	providerSwitchReporter  ProviderSwitchReporter
+	phaseRoutes             map[string]PhaseRoute
+	budget                  *usageBudget
+	timeouts                PhaseTimeouts
+	rollback                RollbackPolicy
+	snapshots               snapshotter
+	coverage                quality.CoverageGate
+	changes                 changeLister
+	qualityFullEvery        int
 }
 
 // SetPhaseReporter sets a callback for phase transitions during RunOnce.
@@ -111,7 +132,7 @@ func NewManager(
 		retry:              retry,
 		iteration:          iteration,
 		qualityChecker:     checker,
-		qualityCommands:    qualityCommands,
+		qualityChecks:      quality.CommandChecks(qualityCommands),
 		committer:          commitService,
 		completion:         completionPolicy,
 		completionExec:     completionExec,
@@ -123,27 +144,61 @@ func NewManager(
 }
 
 func (m Manager) RunOnce(ctx context.Context, name string, artifactDir string, workDir string) error {
+	_, err := m.runNextStory(ctx, name, artifactDir, workDir, nil)
+	return err
+}
+
+// runNextStory selects the next runnable story, skipping any IDs in excluded,
+// and runs it through every phase. It returns the ID of the story it attempted,
+// or an empty string when no story was runnable.
+func (m Manager) runNextStory(ctx context.Context, name, artifactDir, workDir string, excluded map[string]struct{}) (string, error) {
 	if strings.TrimSpace(artifactDir) == "" {
 		artifactDir = workDir
 	}
 
+	if err := m.checkBudget(artifactDir, name); err != nil {
+		return "", err
+	}
+
 	doc, err := m.store.Load(name)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	story := doc.NextStory()
+	story := doc.NextStoryExcluding(excluded)
 	if story == nil {
-		return nil
+		return "", nil
 	}
+	storyID := story.ID
+	err = m.runStory(ctx, name, artifactDir, workDir, doc, story)
+	return storyID, err
+}
+
+// runStory takes story through every phase. When an earlier run of the story
+// was interrupted, it resumes at the phase recorded in the story's run state.
+// The run state is removed once the story passes or fails; it is kept when the
+// run is cancelled or stopped by the budget, so the next run can resume.
+func (m Manager) runStory(ctx context.Context, name, artifactDir, workDir string, doc prd.Document, story *prd.UserStory) (err error) {
 	storyID := story.ID
 	storyTitle := story.Title
 
-	if !story.InProgress {
-		if err := setStoryInProgress(&doc, storyID); err != nil {
-			return err
+	state, resumed := loadRunState(artifactDir, name, *story)
+	if resumed {
+		_ = appendAgentLog(artifactDir, name, fmt.Sprintf("[resume] %s resuming at the %s phase\n", storyID, state.Phase))
+	}
+	defer func() {
+		if err != nil && !stopsRun(err) && state.Snapshot != nil {
+			m.rollBack(ctx, artifactDir, name, workDir, storyID, *state.Snapshot)
 		}
-		if err := m.store.Save(name, doc); err != nil {
+		if err == nil || !stopsRun(err) {
+			_ = clearRunState(artifactDir, name, storyID)
+		}
+	}()
+
+	if !story.InProgress {
+		if err := m.store.Update(name, func(current *prd.Document) error {
+			return setStoryInProgress(current, storyID)
+		}); err != nil {
 			return err
 		}
 	}
@@ -156,13 +211,17 @@ func (m Manager) RunOnce(ctx context.Context, name string, artifactDir string, w
 
 	// ── PHASE 1: Plan (optional) ──────────────────────────────────────────────
 	var planPath string
-	if m.planEnabled {
+	if m.planEnabled && state.finished(runPhasePlan) {
+		planPath = state.PlanPath
+	} else if m.planEnabled {
 		m.reportPhase("planning", storyID)
+		_ = saveRunState(artifactDir, name, &state, runPhasePlan)
 		planPath, err = m.runPlanPhase(ctx, artifactDir, workDir, name, doc, *story, contextFiles)
 		if err != nil {
 			_ = appendProgress(artifactDir, name, storyID, "error", "plan phase failed: "+err.Error())
 			return fmt.Errorf("plan phase failed: %w", err)
 		}
+		state.PlanPath = planPath
 		// Re-read learnings after plan in case the agent added any.
 		if m.compoundEnabled {
 			contextFiles = m.buildContextFiles(artifactDir, workDir, name)
@@ -176,92 +235,113 @@ func (m Manager) RunOnce(ctx context.Context, name string, artifactDir string, w
 	}
 
 	// ── PHASE 2: Work ─────────────────────────────────────────────────────────
-	m.reportPhase("working", storyID)
 	prompt := buildStoryPrompt(doc, *story)
+	provider, _, _ := m.activeProvider()
 	request := providers.IterationRequest{
-		WorkDir:        workDir,
-		Prompt:         prompt,
-		ContextFiles:   contextFiles,
-		ApprovalPolicy: m.iteration.ApprovalPolicy,
-		SandboxPolicy:  m.iteration.SandboxPolicy,
-		Model:          m.iteration.Model,
+		WorkDir:         workDir,
+		Prompt:          prompt,
+		ContextFiles:    contextFiles,
+		ApprovalPolicy:  m.iteration.ApprovalPolicy,
+		SandboxPolicy:   m.iteration.SandboxPolicy,
+		Model:           m.iteration.Model,
+		ResumeSessionID: state.resumeSessionID(provider.Name()),
 		Metadata: map[string]string{
 			"storyID": storyID,
+			"phase":   "work",
 		},
 	}
 
-	result, iterationAttempt, err := m.runIterationWithRetry(ctx, artifactDir, name, request)
-	if err != nil {
-		_ = appendProgress(artifactDir, name, storyID, "error", result.Summary)
-		_ = m.appendLearnings(artifactDir, name, storyID, "work", err.Error())
-		return fmt.Errorf("iteration failed: %w", err)
-	}
-
-	// ── PHASE 3: Parallel Review (optional) ───────────────────────────────────
-	if m.reviewer != nil && len(m.reviewPerspectives) > 0 {
-		m.reportPhase("reviewing", storyID)
-		reviewReport, reviewErr := m.reviewer.RunReview(ctx, workDir, contextFiles, m.reviewPerspectives, request)
-		if reviewErr != nil {
-			_ = appendAgentLog(artifactDir, name, "[review] error: "+reviewErr.Error()+"\n")
-		}
-		summary := providers.SynthesizeReviewSummary(reviewReport.Reviews)
-		if summary != "" {
-			_ = appendAgentLog(artifactDir, name, "[review] summary:\n"+summary+"\n")
+	var result providers.IterationResult
+	iterationAttempt := state.Attempts
+	if state.finished(runPhaseWork) {
+		result.Summary = state.Summary
+	} else {
+		m.reportPhase("working", storyID)
+		m.takeSnapshot(ctx, artifactDir, name, workDir, storyID, &state)
+		m.captureCoverageBaseline(artifactDir, name, workDir, &state)
+		m.captureStartRef(ctx, workDir, &state)
+		_ = saveRunState(artifactDir, name, &state, runPhaseWork)
+		result, iterationAttempt, err = m.runIterationWithRetry(ctx, artifactDir, name, request, func(provider, sessionID string) {
+			// Checkpoint the session as soon as it is open, so a run that
+			// dies mid-turn resumes it instead of starting a fresh one.
+			state.Provider = provider
+			state.SessionID = sessionID
+			_ = saveRunState(artifactDir, name, &state, runPhaseWork)
+		})
+		if err != nil {
+			_ = appendProgress(artifactDir, name, storyID, "error", result.Summary)
+			_ = m.appendLearnings(artifactDir, name, storyID, "work", err.Error())
+			return fmt.Errorf("iteration failed: %w", err)
 		}
-		// If review found issues, treat as a quality failure.
-		if !reviewReport.Passed {
-			_ = appendProgress(artifactDir, name, storyID, "failed", "[review] "+summary)
-			_ = m.appendLearnings(artifactDir, name, storyID, "review", summary)
-			return fmt.Errorf("review found issues")
+		provider, _, _ = m.activeProvider()
+		state.Attempts = iterationAttempt
+		state.Summary = result.Summary
+		state.Provider = provider.Name()
+		state.SessionID = result.ProviderRunID
+		request.ResumeSessionID = result.ProviderRunID
+	}
+
+	// ── PHASE 3: Parallel Review (optional, with remediation rounds) ──────────
+	if m.reviewer != nil && len(m.reviewPerspectives) > 0 && !state.finished(runPhaseReview) {
+		_ = saveRunState(artifactDir, name, &state, runPhaseReview)
+		if err := m.runReviewPhase(ctx, artifactDir, name, workDir, *story, contextFiles, request); err != nil {
+			return err
 		}
 	}
 
-	// ── PHASE 4: Sequential Quality Checks ────────────────────────────────────
+	// ── PHASE 4: Quality Checks (with optional repair rounds) ─────────────────
 	if m.qualityChecker == nil {
 		return fmt.Errorf("quality checker is not configured")
 	}
 
-	report, err := m.qualityChecker.Run(ctx, workDir, m.qualityCommands)
-	if err != nil {
-		_ = appendQualityRunnerError(artifactDir, name, storyID, iterationAttempt, err)
-		_ = appendProgress(artifactDir, name, storyID, "error", err.Error())
-		return fmt.Errorf("quality checks failed to run: %w", err)
-	}
-	if err := appendQualityReport(artifactDir, name, storyID, iterationAttempt, report); err != nil {
-		return fmt.Errorf("failed to persist quality report: %w", err)
-	}
-	if !report.Passed {
-		_ = appendProgress(artifactDir, name, storyID, "failed", formatQualitySummary(report))
-		_ = m.appendLearnings(artifactDir, name, storyID, "quality", formatQualitySummary(report))
-		return fmt.Errorf("quality checks failed")
+	report := state.qualityReport()
+	if !state.finished(runPhaseQuality) {
+		_ = saveRunState(artifactDir, name, &state, runPhaseQuality)
+		report, err = m.runQualityPhase(ctx, artifactDir, name, workDir, *story, request, iterationAttempt, qualityRun{
+			coverageBaseline: state.coverageBaseline(artifactDir, name),
+			startRef:         state.StartRef,
+			fullSuite:        m.fullSuiteReason(doc, storyID),
+		})
+		if err != nil {
+			return err
+		}
+		state.setQuality(report)
 	}
 
 	// ── PHASE 5: Commit ────────────────────────────────────────────────────────
 	if m.committer == nil {
 		return fmt.Errorf("git committer is not configured")
 	}
-
-	commitResult, err := m.committer.CommitStory(ctx, workDir, storyID, storyTitle)
+	_ = saveRunState(artifactDir, name, &state, runPhaseCommit)
+
+	if state.Provider == "" {
+		active, _, _ := m.activeProvider()
+		state.Provider = active.Name()
+	}
+	commitResult, err := m.committer.CommitStory(ctx, workDir, daedalusgit.StoryCommit{
+		StoryID:  storyID,
+		Title:    storyTitle,
+		Type:     story.CommitType(),
+		Summary:  result.Summary,
+		PRD:      name,
+		Provider: state.Provider,
+		RunID:    state.SessionID,
+	})
 	if err != nil {
+		appendSecretFindings(artifactDir, name, storyID, iterationAttempt, err)
 		_ = appendProgress(artifactDir, name, storyID, "error", err.Error())
 		return fmt.Errorf("git commit failed: %w", err)
 	}
+	// The story's changes are committed; nothing is left to roll back.
+	m.dropSnapshot(ctx, workDir, &state)
 
-	if err := markStoryPassed(&doc, storyID); err != nil {
+	if err := m.store.Update(name, func(current *prd.Document) error {
+		return markStoryPassed(current, storyID)
+	}); err != nil {
 		return err
 	}
-	if err := m.store.Save(name, doc); err != nil {
-		return err
-	}
-
-	if m.completion.PushOnComplete && commitResult.Committed && m.completionExec != nil {
-		if pushErr := m.completionExec.PushBranch(ctx, workDir); pushErr != nil {
-			_ = appendAgentLog(artifactDir, name, "[completion] push failed: "+pushErr.Error()+"\n")
-		} else if m.completion.AutoPROnComplete {
-			if prErr := m.completionExec.CreatePR(ctx, workDir); prErr != nil {
-				_ = appendAgentLog(artifactDir, name, "[completion] pr creation failed: "+prErr.Error()+"\n")
-			}
-		}
+	if report.Coverage != nil {
+		_ = recordCoverage(artifactDir, name, storyID, report.Coverage.Coverage)
 	}
 
 	summary := strings.TrimSpace(result.Summary)
@@ -276,10 +356,51 @@ func (m Manager) RunOnce(ctx context.Context, name string, artifactDir string, w
 		return err
 	}
 
+	// Progress is written first so the PR body includes this story.
+	if m.completion.PushOnComplete && commitResult.Committed && m.completionExec != nil {
+		if pushErr := m.completionExec.PushBranch(ctx, workDir); pushErr != nil {
+			_ = appendAgentLog(artifactDir, name, "[completion] push failed: "+pushErr.Error()+"\n")
+		} else if m.completion.AutoPROnComplete {
+			m.openPullRequest(ctx, artifactDir, name, workDir, storyID)
+		}
+	}
+
 	return nil
 }
 
-func (m Manager) runIterationWithRetry(ctx context.Context, artifactDir, name string, request providers.IterationRequest) (providers.IterationResult, int, error) {
+// runIterationWithRetry runs request on the active provider, retrying
+// retryable failures. When fallback providers are configured and the provider
+// keeps failing with an authentication or rate-limit error, it switches to the
+// next one and starts over. The returned attempt count spans all providers.
+// onSession, when set, is called with the provider and session ID of each
+// turn as soon as the turn has started.
+func (m Manager) runIterationWithRetry(ctx context.Context, artifactDir, name string, request providers.IterationRequest, onSession func(provider, sessionID string)) (providers.IterationResult, int, error) {
+	attempts := 0
+	for {
+		provider, options, index := m.activeProvider()
+		if m.chain != nil {
+			request.ApprovalPolicy = options.ApprovalPolicy
+			request.SandboxPolicy = options.SandboxPolicy
+			request.Model = options.Model
+		}
+		result, used, err := m.runProviderAttempts(ctx, provider, artifactDir, name, request, attempts, onSession)
+		attempts += used
+		if err == nil || m.chain == nil || ctx.Err() != nil || !shouldFallBack(err) {
+			return result, attempts, err
+		}
+
+		next, _, ok := m.chain.advance(index)
+		if !ok {
+			return result, attempts, err
+		}
+		_ = appendProviderSwitchEvent(artifactDir, name, request.Metadata["storyID"], attempts, provider.Name(), next.Provider.Name(), err)
+		m.reportProviderSwitch(provider.Name(), next.Provider.Name(), err)
+	}
+}
+
+// runProviderAttempts runs request on provider up to the retry policy's limit.
+// Event iterations are numbered after the offset attempts already made.
+func (m Manager) runProviderAttempts(ctx context.Context, provider providers.Provider, artifactDir, name string, request providers.IterationRequest, offset int, onSession func(provider, sessionID string)) (providers.IterationResult, int, error) {
 	var lastErr error
 	var lastResult providers.IterationResult
 	lastAttempt := 0
@@ -290,26 +411,57 @@ func (m Manager) runIterationWithRetry(ctx context.Context, artifactDir, name st
 
 	for attempt := 0; attempt < totalAttempts; attempt++ {
 		lastAttempt = attempt + 1
-		events, result, err := m.provider.RunIteration(ctx, request)
+		iteration := offset + attempt + 1
+		request.Approver = m.loggedApprover(artifactDir, name, request.Metadata["storyID"], request.Metadata["phase"], iteration)
+		turnCtx, cancelTurn, timeout := m.withPhaseTimeout(ctx, request.Metadata["phase"])
+		events, result, err := provider.RunIteration(turnCtx, request)
 		lastResult = result
 		if err != nil {
+			cancelTurn()
 			lastErr = err
-			_ = appendProviderError(artifactDir, name, request.Metadata["storyID"], attempt+1, err)
+			_ = appendProviderError(artifactDir, name, request.Metadata["storyID"], iteration, err)
 			if !providers.IsRetryable(err) {
 				return lastResult, lastAttempt, err
 			}
 		} else {
-			summary, runtimeErr, consumeErr := consumeProviderEvents(artifactDir, name, request.Metadata["storyID"], attempt+1, events)
+			if onSession != nil && result.ProviderRunID != "" {
+				onSession(provider.Name(), result.ProviderRunID)
+			}
+			summary, usage, runtimeErr, consumeErr := consumeProviderEvents(artifactDir, name, request.Metadata["storyID"], iteration, events)
+			if timeoutErr := phaseTimeoutError(ctx, turnCtx, request.Metadata["phase"], timeout); timeoutErr != nil && consumeErr == nil {
+				runtimeErr = timeoutErr
+				_ = appendProviderError(artifactDir, name, request.Metadata["storyID"], iteration, timeoutErr)
+			}
+			cancelTurn()
 			if consumeErr != nil {
 				return lastResult, lastAttempt, consumeErr
 			}
 			if summary != "" {
 				lastResult.Summary = summary
 			}
+			if usage != nil {
+				lastResult.Usage = *usage
+				m.recordUsage(artifactDir, name, usageRecord{
+					StoryID:   request.Metadata["storyID"],
+					Phase:     request.Metadata["phase"],
+					Provider:  provider.Name(),
+					Model:     request.Model,
+					Iteration: iteration,
+					Usage:     *usage,
+				})
+				if err := m.checkBudget(artifactDir, name); err != nil {
+					return lastResult, lastAttempt, err
+				}
+			}
 			if runtimeErr == nil {
 				lastResult.Success = true
 				return lastResult, lastAttempt, nil
 			}
+			if ctx.Err() != nil {
+				// The run was stopped mid-turn: report that rather than how
+				// the turn ended, so the story keeps its run state.
+				return lastResult, lastAttempt, ctx.Err()
+			}
 			lastErr = runtimeErr
 			if !providers.IsRetryable(runtimeErr) {
 				return lastResult, lastAttempt, runtimeErr
@@ -366,16 +518,25 @@ func markStoryPassed(doc *prd.Document, storyID string) error {
 	return fmt.Errorf("story %q not found", storyID)
 }
 
-func consumeProviderEvents(workDir, name, storyID string, iteration int, events <-chan providers.Event) (summary string, runtimeErr error, err error) {
+// consumeProviderEvents records the iteration's events. Usage events are not
+// written here; they are returned so the caller can record them with the
+// provider and model.
+func consumeProviderEvents(workDir, name, storyID string, iteration int, events <-chan providers.Event) (summary string, usage *providers.Usage, runtimeErr error, err error) {
 	if events == nil {
-		return "", providers.NewConfigurationError("provider started without event stream", nil), nil
+		return "", nil, providers.NewConfigurationError("provider started without event stream", nil), nil
 	}
 	for event := range events {
+		if event.Type == providers.EventUsage {
+			if decoded, ok := providers.DecodeEventUsage(event.Message); ok {
+				usage = &decoded
+			}
+			continue
+		}
 		if err := appendEvent(workDir, name, storyID, iteration, event); err != nil {
-			return "", nil, err
+			return "", nil, nil, err
 		}
 		if err := appendAgentLog(workDir, name, fmt.Sprintf("[%s] %s\n", event.Type, event.Message)); err != nil {
-			return "", nil, err
+			return "", nil, nil, err
 		}
 		switch event.Type {
 		case providers.EventAssistantText:
@@ -384,7 +545,7 @@ func consumeProviderEvents(workDir, name, storyID string, iteration int, events
 			runtimeErr = providers.DecodeEventError(event.Message)
 		}
 	}
-	return summary, runtimeErr, nil
+	return summary, usage, runtimeErr, nil
 }
 
 func appendProviderError(workDir, name, storyID string, iteration int, err error) error {
@@ -432,17 +593,56 @@ func appendQualityReport(workDir, name, storyID string, iteration int, report qu
 			"duration":  result.Duration.String(),
 			"stdout":    result.Stdout,
 			"stderr":    result.Stderr,
-			"passed":    result.ExitCode == 0,
+			"passed":    result.Passed(),
+		}
+		if result.Name != "" && result.Name != result.Command {
+			payload["name"] = result.Name
+		}
+		if result.Group != "" {
+			payload["group"] = result.Group
+		}
+		if result.TimedOut {
+			payload["timedOut"] = true
+		}
+		if result.Skipped {
+			payload["skipped"] = true
+		}
+		if result.Unchanged {
+			payload["unchanged"] = true
+		}
+		if result.AllowFailure {
+			payload["allowFailure"] = true
+		}
+		testsLine := ""
+		if result.Tests != nil {
+			counts := result.TestCounts()
+			payload["format"] = result.Format
+			payload["tests"] = map[string]int{
+				"total":   counts.Total(),
+				"passed":  counts.Passed,
+				"failed":  counts.Failed,
+				"skipped": counts.Skipped,
+			}
+			testsLine = " tests=" + formatTestCounts(counts)
+			if counts.Failed > 0 {
+				failed := make([]map[string]string, 0, counts.Failed)
+				for _, test := range result.FailedTests() {
+					failed = append(failed, map[string]string{
+						"name":    test.FullName(),
+						"message": trimOutput(test.Message, testMessageLimit),
+					})
+				}
+				payload["failedTests"] = failed
+				names := failedTestNames(result, eventFailedTestNames)
+				payload["message"] = fmt.Sprintf("%s (%d of %d tests failed: %s)", payload["message"], counts.Failed, counts.Total(), names)
+				testsLine += " failing=" + names
+			}
 		}
 		if err := appendEventPayload(workDir, name, payload); err != nil {
 			return err
 		}
 
-		status := "passed"
-		if result.ExitCode != 0 {
-			status = "failed"
-		}
-		line := fmt.Sprintf("[quality] %s (%s) -> exit=%d duration=%s\n", result.Command, status, result.ExitCode, result.Duration)
+		line := fmt.Sprintf("[quality] %s (%s) -> exit=%d duration=%s%s\n", result.Command, qualityStatus(result), result.ExitCode, result.Duration, testsLine)
 		if err := appendAgentLog(workDir, name, line); err != nil {
 			return err
 		}
@@ -521,19 +721,118 @@ func formatQualitySummary(report quality.Report) string {
 		builder.WriteString("  Exit code: ")
 		builder.WriteString(strconv.Itoa(result.ExitCode))
 		builder.WriteString("\n")
+		if result.Skipped || result.Unchanged || result.AllowFailure {
+			builder.WriteString("  Status: ")
+			builder.WriteString(qualityStatus(result))
+			builder.WriteString("\n")
+		}
 		builder.WriteString("  Duration: ")
 		builder.WriteString(result.Duration.String())
 		builder.WriteString("\n")
-		builder.WriteString("  Stdout:\n")
-		builder.WriteString(indentedBlock(strings.TrimSpace(result.Stdout)))
-		builder.WriteString("\n")
+		if result.TestsExplain() {
+			// The parsed tests replace the raw test log.
+			builder.WriteString("  Tests: ")
+			builder.WriteString(formatTestCounts(result.TestCounts()))
+			builder.WriteString("\n")
+			writeFailedTests(&builder, result, testMessageLimit)
+		} else {
+			builder.WriteString("  Stdout:\n")
+			builder.WriteString(indentedBlock(strings.TrimSpace(result.Stdout)))
+			builder.WriteString("\n")
+		}
 		builder.WriteString("  Stderr:\n")
 		builder.WriteString(indentedBlock(strings.TrimSpace(result.Stderr)))
 		builder.WriteString("\n")
 	}
+	if report.Coverage != nil {
+		builder.WriteString(formatCoverageSummary(*report.Coverage))
+	}
 	return strings.TrimSpace(builder.String())
 }
 
+// formatQualityTable renders the quality commands of report as a markdown
+// table, without their output.
+func formatQualityTable(report quality.Report) string {
+	builder := strings.Builder{}
+	builder.WriteString("| Check | Result | Duration |\n")
+	builder.WriteString("| --- | --- | --- |\n")
+	for _, result := range report.Results {
+		status := qualityStatus(result)
+		if result.ExitCode != 0 && !result.TimedOut {
+			status = fmt.Sprintf("%s, exit %d", status, result.ExitCode)
+		}
+		check := "`" + strings.ReplaceAll(result.Command, "|", "\\|") + "`"
+		if result.Name != "" && result.Name != result.Command {
+			check = strings.ReplaceAll(result.Name, "|", "\\|") + " (" + check + ")"
+		}
+		fmt.Fprintf(&builder, "| %s | %s | %s |\n", check, status, result.Duration.Round(time.Millisecond))
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// formatTestCounts summarizes parsed tests, as in "40 tests: 37 passed, 2
+// failed, 1 skipped".
+func formatTestCounts(counts quality.TestCounts) string {
+	text := fmt.Sprintf("%d tests: %d passed, %d failed", counts.Total(), counts.Passed, counts.Failed)
+	if counts.Skipped > 0 {
+		text += fmt.Sprintf(", %d skipped", counts.Skipped)
+	}
+	return text
+}
+
+// writeFailedTests lists the failed tests of result, at most failedTestLimit
+// of them, each with the end of its message.
+func writeFailedTests(builder *strings.Builder, result quality.Result, messageLimit int) {
+	failed := result.FailedTests()
+	if len(failed) == 0 {
+		return
+	}
+	builder.WriteString("  Failed tests:\n")
+	for i, test := range failed {
+		if i == failedTestLimit {
+			fmt.Fprintf(builder, "  - ... and %d more\n", len(failed)-i)
+			break
+		}
+		fmt.Fprintf(builder, "  - %s\n", test.FullName())
+		if message := trimOutput(test.Message, messageLimit); message != "" {
+			builder.WriteString(indentedBlock(message))
+			builder.WriteString("\n")
+		}
+	}
+}
+
+// failedTestNames lists up to limit failed test names of result.
+func failedTestNames(result quality.Result, limit int) string {
+	failed := result.FailedTests()
+	names := make([]string, 0, limit)
+	for i, test := range failed {
+		if i == limit {
+			names = append(names, fmt.Sprintf("and %d more", len(failed)-i))
+			break
+		}
+		names = append(names, test.FullName())
+	}
+	return strings.Join(names, ", ")
+}
+
+func qualityStatus(result quality.Result) string {
+	status := "passed"
+	switch {
+	case result.Skipped:
+		status = "skipped"
+	case result.Unchanged:
+		status = "not run (no changes)"
+	case result.TimedOut:
+		status = "timed out"
+	case result.ExitCode != 0:
+		status = "failed"
+	}
+	if !result.Passed() && result.AllowFailure {
+		status += " (allowed)"
+	}
+	return status
+}
+
 func indentedBlock(text string) string {
 	if text == "" {
 		return "    (empty)"
@@ -618,31 +917,52 @@ func (m Manager) runPlanPhase(
 	planPath := project.PRDPlanPath(artifactDir, prdName, story.ID)
 	prompt := buildPlanPrompt(doc, story)
 
+	provider, options, _ := m.phaseRoute(PhasePlan)
 	request := providers.IterationRequest{
 		WorkDir:        workDir,
 		Prompt:         prompt,
 		ContextFiles:   contextFiles,
-		ApprovalPolicy: m.iteration.ApprovalPolicy,
-		SandboxPolicy:  m.iteration.SandboxPolicy,
-		Model:          m.iteration.Model,
+		ApprovalPolicy: options.ApprovalPolicy,
+		SandboxPolicy:  options.SandboxPolicy,
+		Model:          options.Model,
 		Metadata: map[string]string{
 			"storyID": story.ID,
 			"phase":   "plan",
 		},
 	}
+	request.Approver = m.loggedApprover(artifactDir, prdName, story.ID, "plan", 1)
 
-	events, result, err := m.provider.RunIteration(ctx, request)
+	planCtx, cancelPlan, timeout := m.withPhaseTimeout(ctx, "plan")
+	defer cancelPlan()
+	events, result, err := provider.RunIteration(planCtx, request)
 	if err != nil {
 		return "", fmt.Errorf("plan phase provider error: %w", err)
 	}
 
 	var planText strings.Builder
 	for event := range events {
-		if event.Type == providers.EventAssistantText {
+		switch event.Type {
+		case providers.EventAssistantText:
 			planText.WriteString(event.Message)
+		case providers.EventUsage:
+			if usage, ok := providers.DecodeEventUsage(event.Message); ok {
+				m.recordUsage(artifactDir, prdName, usageRecord{
+					StoryID:   story.ID,
+					Phase:     "plan",
+					Provider:  provider.Name(),
+					Model:     request.Model,
+					Iteration: 1,
+					Usage:     usage,
+				})
+			}
 		}
 	}
 
+	if timeoutErr := phaseTimeoutError(ctx, planCtx, "plan", timeout); timeoutErr != nil {
+		_ = appendProviderError(artifactDir, prdName, story.ID, 1, timeoutErr)
+		return "", fmt.Errorf("plan phase provider error: %w", timeoutErr)
+	}
+
 	planContent := strings.TrimSpace(planText.String())
 	if planContent == "" {
 		planContent = strings.TrimSpace(result.Summary)
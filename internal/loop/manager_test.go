@@ -56,7 +56,7 @@ type fakeChecker struct {
 	err    error
 }
 
-func (c fakeChecker) Run(ctx context.Context, workDir string, commands []string) (quality.Report, error) {
+func (c fakeChecker) Run(ctx context.Context, workDir string, checks []quality.Check) (quality.Report, error) {
 	if c.err != nil {
 		return quality.Report{}, c.err
 	}
@@ -66,15 +66,55 @@ func (c fakeChecker) Run(ctx context.Context, workDir string, commands []string)
 type fakeCommitter struct {
 	result daedalusgit.CommitResult
 	err    error
+	got    *daedalusgit.StoryCommit
 }
 
-func (c fakeCommitter) CommitStory(ctx context.Context, workDir, storyID, storyTitle string) (daedalusgit.CommitResult, error) {
+func (c fakeCommitter) CommitStory(ctx context.Context, workDir string, story daedalusgit.StoryCommit) (daedalusgit.CommitResult, error) {
+	if c.got != nil {
+		*c.got = story
+	}
 	if c.err != nil {
 		return daedalusgit.CommitResult{}, c.err
 	}
 	return c.result, nil
 }
 
+// noRetries fails a story on its first provider error.
+var noRetries = RetryPolicy{MaxRetries: 0, Delays: []time.Duration{0}}
+
+// newTestManager creates the "main" PRD in a temp dir and returns a
+// newStoreManager for it, with the store and the dir.
+func newTestManager(t *testing.T, provider providers.Provider, checker qualityChecker, retry RetryPolicy) (Manager, prd.Store, string) {
+	t.Helper()
+	baseDir := t.TempDir()
+	store := prd.NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	return newStoreManager(store, provider, checker, retry), store, baseDir
+}
+
+// newStoreManager returns a manager for store that runs `go test ./...`
+// through checker and commits through a fakeCommitter. Tests adjust the rest
+// with setters or by setting fields.
+func newStoreManager(store prd.Store, provider providers.Provider, checker qualityChecker, retry RetryPolicy) Manager {
+	return NewManager(
+		store,
+		provider,
+		retry,
+		IterationOptions{},
+		checker,
+		[]string{"go test ./..."},
+		fakeCommitter{result: daedalusgit.CommitResult{Committed: true, CommitSHA: "abc123"}},
+		CompletionPolicy{},
+		nil,
+		false,
+		nil,
+		nil,
+		false,
+	)
+}
+
 func TestRunOnceFailsWhenQualityChecksFail(t *testing.T) {
 	t.Parallel()
 
@@ -147,6 +187,54 @@ func TestRunOnceSucceedsWhenQualityChecksPass(t *testing.T) {
 	}
 }
 
+func TestRunOnceCommitsWithStoryDetails(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := prd.NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	if err := store.Update("main", func(doc *prd.Document) error {
+		doc.UserStories[0].Title = "Fix the CSV exporter"
+		return nil
+	}); err != nil {
+		t.Fatalf("update PRD: %v", err)
+	}
+
+	var got daedalusgit.StoryCommit
+	manager := NewManager(
+		store,
+		fakeProvider{events: []providers.Event{{Type: providers.EventAssistantText, Message: "Quoted fields that contain commas."}}},
+		RetryPolicy{MaxRetries: 0, Delays: []time.Duration{0}},
+		IterationOptions{},
+		fakeChecker{report: quality.Report{Passed: true}},
+		[]string{"go test ./..."},
+		fakeCommitter{result: daedalusgit.CommitResult{Committed: true, CommitSHA: "abc123"}, got: &got},
+		CompletionPolicy{},
+		nil,
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("expected success, got error: %v", err)
+	}
+	want := daedalusgit.StoryCommit{
+		StoryID:  "US-001",
+		Title:    "Fix the CSV exporter",
+		Type:     "fix",
+		Summary:  "Quoted fields that contain commas.",
+		PRD:      "main",
+		Provider: "fake",
+	}
+	if got != want {
+		t.Fatalf("unexpected commit details:\n got %+v\nwant %+v", got, want)
+	}
+}
+
 func TestRunOnceWritesProviderEventsToArtifacts(t *testing.T) {
 	t.Parallel()
 
@@ -403,6 +491,8 @@ type fakeCompletionExecutor struct {
 	prCalled   int
 	pushErr    error
 	prErr      error
+	pr         daedalusgit.PullRequest
+	prResult   daedalusgit.PullRequestResult
 }
 
 func (e *fakeCompletionExecutor) PushBranch(_ context.Context, _ string) error {
@@ -410,9 +500,10 @@ func (e *fakeCompletionExecutor) PushBranch(_ context.Context, _ string) error {
 	return e.pushErr
 }
 
-func (e *fakeCompletionExecutor) CreatePR(_ context.Context, _ string) error {
+func (e *fakeCompletionExecutor) CreatePR(_ context.Context, _ string, pr daedalusgit.PullRequest) (daedalusgit.PullRequestResult, error) {
+	e.pr = pr
 	e.prCalled++
-	return e.prErr
+	return e.prResult, e.prErr
 }
 
 func TestRunOnceCallsPushWhenConfigured(t *testing.T) {
@@ -461,16 +552,16 @@ func TestRunOnceCallsPRAfterPush(t *testing.T) {
 		t.Fatalf("create PRD: %v", err)
 	}
 
-	exec := &fakeCompletionExecutor{}
+	exec := &fakeCompletionExecutor{prResult: daedalusgit.PullRequestResult{Number: 7, URL: "https://git.example.com/acme/shop/pulls/7"}}
 	manager := NewManager(
 		store,
-		fakeProvider{},
+		fakeProvider{events: []providers.Event{{Type: providers.EventAssistantText, Message: "Added the first story."}}},
 		RetryPolicy{MaxRetries: 0, Delays: []time.Duration{0}},
 		IterationOptions{},
-		fakeChecker{report: quality.Report{Passed: true}},
+		fakeChecker{report: quality.Report{Passed: true, Results: []quality.Result{{Command: "go test ./...", Duration: time.Second}}}},
 		[]string{"go test ./..."},
 		fakeCommitter{result: daedalusgit.CommitResult{Committed: true, CommitSHA: "abc123"}},
-		CompletionPolicy{PushOnComplete: true, AutoPROnComplete: true},
+		CompletionPolicy{PushOnComplete: true, AutoPROnComplete: true, PRDraft: true, PRLabels: []string{"daedalus"}},
 		exec,
 		false,
 		nil,
@@ -487,6 +578,21 @@ func TestRunOnceCallsPRAfterPush(t *testing.T) {
 	if exec.prCalled != 1 {
 		t.Fatalf("expected PR creation to be called once, got %d", exec.prCalled)
 	}
+	if exec.pr.Title != "main" || !exec.pr.Draft || len(exec.pr.Labels) != 1 {
+		t.Fatalf("unexpected pull request %+v", exec.pr)
+	}
+	for _, fragment := range []string{"### US-001: Define first implementation story", "Added the first story.", "| `go test ./...` | passed | 1s |"} {
+		if !strings.Contains(exec.pr.Body, fragment) {
+			t.Fatalf("expected %q in PR body, got:\n%s", fragment, exec.pr.Body)
+		}
+	}
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if !strings.Contains(string(progress), "## Iteration - US-001 - pull request") || !strings.Contains(string(progress), "Pull request opened: https://git.example.com/acme/shop/pulls/7") {
+		t.Fatalf("expected the PR URL in progress, got:\n%s", progress)
+	}
 }
 
 func TestRunOnceSkipsPushWhenNoCommit(t *testing.T) {
@@ -915,7 +1021,11 @@ func (r *failingReviewer) RunReview(_ context.Context, _ string, _ []string, _ [
 	return quality.ReviewReport{
 		Passed: false,
 		Reviews: []providers.PerspectiveReview{
-			{Perspective: "security", Findings: []string{"hardcoded secret found"}},
+			{
+				Perspective: "security",
+				Findings:    []providers.ReviewFinding{{Severity: providers.SeverityHigh, Message: "hardcoded secret found"}},
+				Blocked:     true,
+			},
 		},
 	}, nil
 }
@@ -0,0 +1,67 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+func TestRunOnceEndToEndWithMockProvider(t *testing.T) {
+	t.Cleanup(providers.CloseAllSessions)
+
+	provider := providers.NewMockProvider(providers.MockScenario{
+		Name: "work-review-remediate",
+		Prompts: []providers.MockPrompt{
+			{
+				Match: "Implement only this active story",
+				Updates: []providers.MockUpdate{
+					{Tool: "edit", Write: &providers.MockFileWrite{Path: "store.go", Content: "query := \"SELECT \" + input\n"}},
+				},
+				Response: "Implemented the store",
+			},
+			{
+				Match:    `"security" perspective`,
+				Response: "```json\n{\"findings\": [{\"severity\": \"high\", \"file\": \"store.go\", \"line\": 1, \"message\": \"SQL built from input\"}]}\n```",
+			},
+			{
+				Match: "Code review found issues",
+				Updates: []providers.MockUpdate{
+					{Tool: "edit", Write: &providers.MockFileWrite{Path: "store.go", Content: "query := \"SELECT ?\"\n"}},
+				},
+				Response: "Parameterized the query",
+			},
+			{
+				Match:    `"security" perspective`,
+				Response: "```json\n{\"findings\": []}\n```",
+			},
+		},
+	})
+
+	manager, store, baseDir := newTestManager(t, provider, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.reviewer = quality.NewParallelReviewer(provider, quality.DefaultFailOn)
+	manager.reviewPerspectives = []string{"security"}
+	manager.SetReviewRemediationRounds(1)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(baseDir, "store.go"))
+	if err != nil {
+		t.Fatalf("read scripted file: %v", err)
+	}
+	if string(written) != "query := \"SELECT ?\"\n" {
+		t.Fatalf("expected remediation write to land, got %q", written)
+	}
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if !doc.UserStories[0].Passes {
+		t.Fatal("expected story to pass")
+	}
+}
@@ -0,0 +1,206 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/worktree"
+)
+
+// storyWorkspaces creates, merges and removes the per-story worktrees used by
+// parallel runs.
+type storyWorkspaces interface {
+	EnsureStory(ctx context.Context, baseDir, prdName, storyID, fromDir string) (worktree.SetupResult, error)
+	MergeStory(ctx context.Context, intoDir, branch string) error
+	RemoveStory(ctx context.Context, baseDir, prdName, storyID string, deleteBranch bool) error
+}
+
+// SetStoryWorkspaces sets the worktree manager used when DrainOptions.Parallel
+// is greater than one.
+func (m *Manager) SetStoryWorkspaces(workspaces storyWorkspaces) {
+	m.workspaces = workspaces
+}
+
+type parallelResult struct {
+	storyID string
+	branch  string
+	err     error
+}
+
+// runParallel drains the PRD with up to opts.Parallel stories in flight. Each
+// story runs in its own worktree and branch, branched from the current HEAD of
+// workDir. Passing stories are merged back into workDir one at a time, in the
+// order they finish; a merge conflict turns the story into a failure.
+//
+// Per-story push and PR creation are skipped. When completion is enabled the
+// PRD branch is pushed once after the run if any story was merged.
+func (m Manager) runParallel(ctx context.Context, name, artifactDir, workDir string, opts DrainOptions) (DrainSummary, error) {
+	if m.workspaces == nil {
+		return DrainSummary{}, fmt.Errorf("parallel runs require story worktrees")
+	}
+	if artifactDir == "" {
+		artifactDir = workDir
+	}
+
+	storyManager := m
+	storyManager.completion = CompletionPolicy{}
+
+	summary := DrainSummary{}
+	failed := make(map[string]struct{})
+	running := make(map[string]struct{})
+	results := make(chan parallelResult)
+	started := 0
+	scheduling := true
+	var runErr error
+
+	stopScheduling := func(err error) {
+		scheduling = false
+		if err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+
+	finish := func(outcome StoryOutcome) {
+		if opts.OnStoryFinished != nil {
+			opts.OnStoryFinished(outcome)
+		}
+		if outcome.Passed {
+			summary.Passed = append(summary.Passed, outcome.StoryID)
+			return
+		}
+		summary.Failed = append(summary.Failed, outcome)
+		failed[outcome.StoryID] = struct{}{}
+		if stopsRun(outcome.Err) || !opts.ContinueOnFailure {
+			stopScheduling(outcome.Err)
+		}
+	}
+
+	for {
+		if scheduling && opts.ShouldStop != nil && opts.ShouldStop() {
+			summary.Stopped = true
+			stopScheduling(nil)
+		}
+		if scheduling {
+			if err := ctx.Err(); err != nil {
+				stopScheduling(err)
+			}
+		}
+
+		for scheduling && len(running) < opts.Parallel && (opts.MaxStories <= 0 || started < opts.MaxStories) {
+			if err := m.checkBudget(artifactDir, name); err != nil {
+				stopScheduling(err)
+				break
+			}
+			doc, err := m.store.Load(name)
+			if err != nil {
+				stopScheduling(err)
+				break
+			}
+			story := nextParallelStory(&doc, failed, running)
+			if story == nil {
+				break
+			}
+			started++
+
+			setup, err := m.workspaces.EnsureStory(ctx, artifactDir, name, story.ID, workDir)
+			if err != nil {
+				err = fmt.Errorf("failed to prepare story worktree: %w", err)
+				_ = appendProgress(artifactDir, name, story.ID, "error", err.Error())
+				finish(StoryOutcome{StoryID: story.ID, Err: err})
+				continue
+			}
+			_ = appendAgentLog(artifactDir, name, fmt.Sprintf("[parallel] %s started in %s on %s\n", story.ID, setup.Path, setup.Branch))
+
+			running[story.ID] = struct{}{}
+			go func(doc prd.Document, story prd.UserStory, dir, branch string) {
+				err := storyManager.runStory(ctx, name, artifactDir, dir, doc, &story)
+				results <- parallelResult{storyID: story.ID, branch: branch, err: err}
+			}(doc, *story, setup.Path, setup.Branch)
+		}
+
+		if len(running) == 0 {
+			break
+		}
+
+		result := <-results
+		delete(running, result.storyID)
+
+		err := result.err
+		if err == nil {
+			err = m.mergeParallelStory(ctx, name, artifactDir, workDir, result)
+		}
+		_ = m.workspaces.RemoveStory(ctx, artifactDir, name, result.storyID, err == nil)
+		finish(StoryOutcome{StoryID: result.storyID, Passed: err == nil, Err: err})
+	}
+
+	if len(summary.Passed) > 0 {
+		m.completeParallelRun(ctx, name, artifactDir, workDir)
+	}
+
+	skipped, err := m.pendingStories(name, summary)
+	if err != nil && runErr == nil {
+		runErr = err
+	}
+	summary.Skipped = skipped
+	return summary, runErr
+}
+
+// nextParallelStory picks the next story to start. Stories already running are
+// treated as not yet passed, so their dependents wait until they are merged.
+func nextParallelStory(doc *prd.Document, failed, running map[string]struct{}) *prd.UserStory {
+	excluded := make(map[string]struct{}, len(failed)+len(running))
+	for id := range failed {
+		excluded[id] = struct{}{}
+	}
+	for id := range running {
+		excluded[id] = struct{}{}
+	}
+	for i := range doc.UserStories {
+		if _, ok := running[doc.UserStories[i].ID]; ok {
+			doc.UserStories[i].Passes = false
+		}
+	}
+	return doc.NextStoryExcluding(excluded)
+}
+
+// mergeParallelStory merges a passing story branch into workDir. On failure
+// the story is marked as not passed again and the branch is left in place.
+func (m Manager) mergeParallelStory(ctx context.Context, name, artifactDir, workDir string, result parallelResult) error {
+	mergeErr := m.workspaces.MergeStory(ctx, workDir, result.branch)
+	if mergeErr == nil {
+		_ = appendAgentLog(artifactDir, name, fmt.Sprintf("[parallel] merged %s\n", result.branch))
+		return nil
+	}
+
+	_ = m.store.Update(name, func(doc *prd.Document) error {
+		for i := range doc.UserStories {
+			if doc.UserStories[i].ID == result.storyID {
+				doc.UserStories[i].Passes = false
+				doc.UserStories[i].InProgress = false
+			}
+		}
+		return nil
+	})
+	_ = appendProgress(artifactDir, name, result.storyID, "failed", "merge into PRD branch failed: "+mergeErr.Error()+"\nBranch kept: "+result.branch)
+	return fmt.Errorf("merge failed: %w", mergeErr)
+}
+
+func (m Manager) completeParallelRun(ctx context.Context, name, artifactDir, workDir string) {
+	if !m.completion.PushOnComplete || m.completionExec == nil {
+		return
+	}
+	if pushErr := m.completionExec.PushBranch(ctx, workDir); pushErr != nil {
+		_ = appendAgentLog(artifactDir, name, "[completion] push failed: "+pushErr.Error()+"\n")
+		return
+	}
+	if m.completion.AutoPROnComplete {
+		// The run merged several stories, so the entry is filed under the PRD.
+		m.openPullRequest(ctx, artifactDir, name, workDir, name)
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
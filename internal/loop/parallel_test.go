@@ -0,0 +1,254 @@
+package loop
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+	"github.com/EstebanForge/daedalus/internal/worktree"
+)
+
+// concurrentProvider blocks each iteration until `target` iterations are in
+// flight (or a short timeout passes) and records the peak concurrency.
+type concurrentProvider struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	target    int
+	workDirs  []string
+}
+
+func newConcurrentProvider(target int) *concurrentProvider {
+	return &concurrentProvider{target: target}
+}
+
+func (p *concurrentProvider) reachedTarget() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.maxActive >= p.target
+}
+
+func (p *concurrentProvider) Name() string {
+	return "concurrent"
+}
+
+func (p *concurrentProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p *concurrentProvider) RunIteration(_ context.Context, request providers.IterationRequest) (<-chan providers.Event, providers.IterationResult, error) {
+	p.mu.Lock()
+	p.active++
+	if p.active > p.maxActive {
+		p.maxActive = p.active
+	}
+	p.workDirs = append(p.workDirs, request.WorkDir)
+	p.mu.Unlock()
+
+	deadline := time.After(2 * time.Second)
+wait:
+	for !p.reachedTarget() {
+		select {
+		case <-deadline:
+			break wait
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+
+	events := make(chan providers.Event)
+	close(events)
+	return events, providers.IterationResult{Success: true}, nil
+}
+
+type fakeWorkspaces struct {
+	mu         sync.Mutex
+	root       string
+	conflictOn string
+	log        []string
+	removed    map[string]bool
+}
+
+func (w *fakeWorkspaces) EnsureStory(_ context.Context, _, prdName, storyID, _ string) (worktree.SetupResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.log = append(w.log, "ensure "+storyID)
+	return worktree.SetupResult{
+		Path:    filepath.Join(w.root, storyID),
+		Branch:  worktree.StoryBranch(prdName, storyID),
+		Created: true,
+	}, nil
+}
+
+func (w *fakeWorkspaces) MergeStory(_ context.Context, _, branch string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conflictOn != "" && strings.HasSuffix(branch, "--"+w.conflictOn) {
+		w.log = append(w.log, "conflict "+branch)
+		return &worktree.MergeConflictError{Branch: branch, Files: []string{"main.go"}}
+	}
+	w.log = append(w.log, "merge "+branch)
+	return nil
+}
+
+func (w *fakeWorkspaces) RemoveStory(_ context.Context, _, _, storyID string, deleteBranch bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.removed == nil {
+		w.removed = make(map[string]bool)
+	}
+	w.removed[storyID] = deleteBranch
+	return nil
+}
+
+func (w *fakeWorkspaces) indexOf(entry string) int {
+	for i, item := range w.log {
+		if item == entry {
+			return i
+		}
+	}
+	return -1
+}
+
+func newParallelManager(store prd.Store, provider providers.Provider, workspaces storyWorkspaces) Manager {
+	manager := newStoreManager(store, provider, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.committer = storyFailingCommitter{}
+	manager.SetStoryWorkspaces(workspaces)
+	return manager
+}
+
+func TestRunUntilDoneParallelRunsStoriesInSeparateWorktrees(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002", "US-003")
+	provider := newConcurrentProvider(2)
+	workspaces := &fakeWorkspaces{root: t.TempDir()}
+	manager := newParallelManager(store, provider, workspaces)
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{Parallel: 2})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if len(summary.Passed) != 3 || len(summary.Failed) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if provider.maxActive != 2 {
+		t.Fatalf("expected two stories in flight, got %d", provider.maxActive)
+	}
+
+	seen := make(map[string]struct{})
+	for _, dir := range provider.workDirs {
+		if dir == baseDir {
+			t.Fatalf("expected story to run in its own worktree, got base dir")
+		}
+		seen[dir] = struct{}{}
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected three distinct worktrees, got %v", provider.workDirs)
+	}
+	for _, id := range []string{"US-001", "US-002", "US-003"} {
+		if workspaces.indexOf("merge daedalus/main--"+id) < 0 {
+			t.Fatalf("expected %s to be merged, log: %v", id, workspaces.log)
+		}
+		if !workspaces.removed[id] {
+			t.Fatalf("expected merged story %s branch to be deleted", id)
+		}
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if doc.CountComplete() != 3 {
+		t.Fatalf("expected concurrent updates to keep all stories passed, got %d", doc.CountComplete())
+	}
+}
+
+func TestRunUntilDoneParallelReportsMergeConflictAsFailure(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002")
+	workspaces := &fakeWorkspaces{root: t.TempDir(), conflictOn: "US-002"}
+	manager := newParallelManager(store, newConcurrentProvider(2), workspaces)
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{Parallel: 2, ContinueOnFailure: true})
+	if err != nil {
+		t.Fatalf("expected no run error when continuing past failures, got %v", err)
+	}
+	if len(summary.Passed) != 1 || summary.Passed[0] != "US-001" {
+		t.Fatalf("expected US-001 to pass, got %+v", summary.Passed)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].StoryID != "US-002" {
+		t.Fatalf("expected US-002 to fail, got %+v", summary.Failed)
+	}
+	if !strings.Contains(summary.Failed[0].Err.Error(), "merge conflict") {
+		t.Fatalf("expected merge conflict error, got %v", summary.Failed[0].Err)
+	}
+	if workspaces.removed["US-002"] {
+		t.Fatal("expected conflicting story branch to be kept")
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	for _, story := range doc.UserStories {
+		if story.ID == "US-002" && story.Passes {
+			t.Fatal("expected conflicting story to be marked as not passed")
+		}
+	}
+}
+
+func TestRunUntilDoneParallelWaitsForDependenciesToMerge(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002")
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	doc.UserStories[1].DependsOn = []string{"US-001"}
+	if err := store.Save("main", doc); err != nil {
+		t.Fatalf("save PRD: %v", err)
+	}
+
+	workspaces := &fakeWorkspaces{root: t.TempDir()}
+	manager := newParallelManager(store, newConcurrentProvider(1), workspaces)
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{Parallel: 2})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if len(summary.Passed) != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	merged := workspaces.indexOf("merge daedalus/main--US-001")
+	started := workspaces.indexOf("ensure US-002")
+	if merged < 0 || started < merged {
+		t.Fatalf("expected US-002 to start after US-001 merged, log: %v", workspaces.log)
+	}
+}
+
+func TestRunUntilDoneParallelRequiresWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001")
+	manager := newDrainManager(store, storyFailingCommitter{})
+
+	if _, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{Parallel: 2}); err == nil {
+		t.Fatal("expected error without story workspaces")
+	}
+}
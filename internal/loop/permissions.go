@@ -0,0 +1,88 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+// SetApprover sets who answers agent permission requests. Nil applies the
+// provider's default policy. Every decision is logged to events.jsonl.
+func (m *Manager) SetApprover(approver providers.Approver) {
+	m.approver = approver
+}
+
+// loggedApprover returns the approver for one iteration, wrapped so that each
+// decision lands in events.jsonl.
+func (m Manager) loggedApprover(artifactDir, name, storyID, phase string, iteration int) providers.Approver {
+	approver := m.approver
+	if approver == nil {
+		approver = providers.NewPolicyApprover(providers.PermissionPolicy{})
+	}
+	return permissionLogger{
+		next:        approver,
+		artifactDir: artifactDir,
+		name:        name,
+		storyID:     storyID,
+		phase:       phase,
+		iteration:   iteration,
+	}
+}
+
+type permissionLogger struct {
+	next        providers.Approver
+	artifactDir string
+	name        string
+	storyID     string
+	phase       string
+	iteration   int
+}
+
+func (l permissionLogger) RequestPermission(ctx context.Context, request providers.PermissionRequest) (providers.PermissionDecision, error) {
+	decision, err := l.next.RequestPermission(ctx, request)
+	if err != nil {
+		decision = providers.PermissionDecision{Allow: false, Source: "error", Reason: err.Error()}
+	}
+	_ = appendPermissionEvent(l.artifactDir, l.name, l.storyID, l.phase, l.iteration, request, decision)
+	return decision, err
+}
+
+func appendPermissionEvent(workDir, name, storyID, phase string, iteration int, request providers.PermissionRequest, decision providers.PermissionDecision) error {
+	verdict := "denied"
+	if decision.Allow {
+		verdict = "allowed"
+	}
+	message := fmt.Sprintf("%s %s (%s", verdict, request.Subject(), decision.Source)
+	if decision.Reason != "" {
+		message += ": " + decision.Reason
+	}
+	message += ")"
+
+	payload := map[string]interface{}{
+		"type":      string(providers.EventPermission),
+		"message":   message,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"iteration": iteration,
+		"storyID":   storyID,
+		"tool":      request.Title,
+		"kind":      request.Kind,
+		"decision":  verdict,
+		"source":    decision.Source,
+		"remember":  decision.Remember,
+	}
+	if phase != "" {
+		payload["phase"] = phase
+	}
+	if request.Command != "" {
+		payload["command"] = request.Command
+	}
+	if len(request.Paths) > 0 {
+		payload["paths"] = request.Paths
+	}
+	if decision.Reason != "" {
+		payload["reason"] = decision.Reason
+	}
+	return appendEventPayload(workDir, name, payload)
+}
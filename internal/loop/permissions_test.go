@@ -0,0 +1,78 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+func TestRunOnceLogsPermissionDecisions(t *testing.T) {
+	t.Cleanup(providers.CloseAllSessions)
+
+	permissionCall := func(command string) *providers.MockCall {
+		return &providers.MockCall{Method: "session/request_permission", Params: map[string]interface{}{
+			"toolCall": map[string]interface{}{
+				"toolCallId": command,
+				"title":      "Run " + command,
+				"kind":       "execute",
+				"rawInput":   map[string]interface{}{"command": command},
+			},
+			"options": []interface{}{
+				map[string]interface{}{"optionId": "allow", "name": "Allow", "kind": "allow_once"},
+				map[string]interface{}{"optionId": "reject", "name": "Reject", "kind": "reject_once"},
+			},
+		}}
+	}
+	provider := providers.NewMockProvider(providers.MockScenario{
+		Name: "permissions",
+		Prompts: []providers.MockPrompt{{
+			Updates: []providers.MockUpdate{
+				{Call: permissionCall("go test ./...")},
+				{Write: &providers.MockFileWrite{Path: "tested.txt", Content: "ok"}},
+				{Call: permissionCall("git push --force origin main")},
+				{Write: &providers.MockFileWrite{Path: "pushed.txt", Content: "oops"}},
+			},
+			Response: "done",
+		}},
+	})
+
+	manager, _, baseDir := newTestManager(t, provider, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.SetApprover(providers.NewPolicyApprover(providers.PermissionPolicy{
+		AllowTools:   []string{"execute"},
+		DenyPatterns: []string{"git push --force*"},
+		Default:      "deny",
+	}))
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(baseDir, "pushed.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected denied tool call to be skipped, got %v", err)
+	}
+	eventsData, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events.jsonl: %v", err)
+	}
+	var decisions []string
+	for _, line := range strings.Split(string(eventsData), "\n") {
+		if strings.Contains(line, `"type":"permission_decision"`) {
+			decisions = append(decisions, line)
+		}
+	}
+	if len(decisions) != 2 {
+		t.Fatalf("expected 2 permission decisions, got: %s", eventsData)
+	}
+	if !strings.Contains(decisions[0], `"decision":"allowed"`) || !strings.Contains(decisions[0], `"command":"go test ./..."`) || !strings.Contains(decisions[0], `"phase":"work"`) {
+		t.Fatalf("unexpected first decision: %s", decisions[0])
+	}
+	if !strings.Contains(decisions[1], `"decision":"denied"`) || !strings.Contains(decisions[1], `"source":"policy"`) || !strings.Contains(decisions[1], `"iteration":1`) {
+		t.Fatalf("unexpected second decision: %s", decisions[1])
+	}
+}
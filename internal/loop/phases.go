@@ -0,0 +1,41 @@
+package loop
+
+import "github.com/EstebanForge/daedalus/internal/providers"
+
+// Phases that can be routed to their own provider. Work, repair, and
+// remediation iterations always run on the Manager's provider (and its
+// fallbacks), since they share one agent session.
+const (
+	PhasePlan   = "plan"
+	PhaseReview = "review"
+)
+
+// PhaseRoute is the provider and iteration options used for one phase.
+type PhaseRoute struct {
+	Provider  providers.Provider
+	Iteration IterationOptions
+}
+
+// SetPhaseRoute routes phase to its own provider and options. A route with a
+// nil provider keeps the work provider but still applies the options. Review
+// iterations run on the Reviewer's provider; only their options come from here.
+func (m *Manager) SetPhaseRoute(phase string, route PhaseRoute) {
+	if m.phaseRoutes == nil {
+		m.phaseRoutes = map[string]PhaseRoute{}
+	}
+	m.phaseRoutes[phase] = route
+}
+
+// phaseRoute returns the route for phase, defaulting to the active work
+// provider and its options.
+func (m Manager) phaseRoute(phase string) (providers.Provider, IterationOptions, bool) {
+	provider, options, _ := m.activeProvider()
+	route, ok := m.phaseRoutes[phase]
+	if !ok {
+		return provider, options, false
+	}
+	if route.Provider != nil {
+		provider = route.Provider
+	}
+	return provider, route.Iteration, true
+}
@@ -0,0 +1,50 @@
+package loop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+type recordingReviewer struct {
+	baseOpts providers.IterationRequest
+}
+
+func (r *recordingReviewer) RunReview(_ context.Context, _ string, _ []string, _ []string, baseOpts providers.IterationRequest) (quality.ReviewReport, error) {
+	r.baseOpts = baseOpts
+	return quality.ReviewReport{Passed: true}, nil
+}
+
+func TestRunOnceRoutesPhasesToTheirProviders(t *testing.T) {
+	t.Parallel()
+
+	var workCalls, planCalls int
+	var workRequest, planRequest providers.IterationRequest
+	done := []providers.Event{{Type: providers.EventAssistantText, Message: "done"}}
+	work := countingProvider{fakeProvider: fakeProvider{events: done, gotRequest: &workRequest}, name: "codex", calls: &workCalls}
+	plan := countingProvider{fakeProvider: fakeProvider{events: done, gotRequest: &planRequest}, name: "claude", calls: &planCalls}
+	reviewer := &recordingReviewer{}
+
+	manager, _, baseDir := newTestManager(t, work, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.iteration.Model = "work-model"
+	manager.planEnabled = true
+	manager.reviewer = reviewer
+	manager.reviewPerspectives = []string{"security"}
+	manager.SetPhaseRoute(PhasePlan, PhaseRoute{Provider: plan, Iteration: IterationOptions{Model: "plan-model", ApprovalPolicy: "never", SandboxPolicy: "read-only"}})
+	manager.SetPhaseRoute(PhaseReview, PhaseRoute{Iteration: IterationOptions{Model: "review-model", ApprovalPolicy: "never", SandboxPolicy: "read-only"}})
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if planCalls != 1 || planRequest.Model != "plan-model" || planRequest.SandboxPolicy != "read-only" {
+		t.Fatalf("expected plan on its own provider and model, got %d calls with %+v", planCalls, planRequest)
+	}
+	if workCalls != 1 || workRequest.Model != "work-model" {
+		t.Fatalf("expected work on the work provider, got %d calls with model %q", workCalls, workRequest.Model)
+	}
+	if reviewer.baseOpts.Model != "review-model" || reviewer.baseOpts.ApprovalPolicy != "never" {
+		t.Fatalf("expected review options from the review route, got %+v", reviewer.baseOpts)
+	}
+}
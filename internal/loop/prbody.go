@@ -0,0 +1,351 @@
+package loop
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	daedalusgit "github.com/EstebanForge/daedalus/internal/git"
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+	"github.com/EstebanForge/daedalus/internal/templates"
+)
+
+// PRBodyData is what a pull request body template is executed with.
+type PRBodyData struct {
+	PRD         string
+	Project     string
+	Description string
+	// Sections holds the "## " sections of prd.md by heading, with the
+	// template's HTML comments removed. Empty sections are left out.
+	Sections map[string]string
+	// Stories are the stories that passed, in prd.json order.
+	Stories []PRBodyStory
+	// Remaining are the stories that have not passed yet.
+	Remaining []PRBodyStory
+	Total     int
+}
+
+// PRBodyStory is one story of the pull request.
+type PRBodyStory struct {
+	ID    string
+	Title string
+	Type  string
+	// Summary is the agent's summary from the story's last "passed" entry in
+	// progress.md.
+	Summary string
+	// Quality is a markdown table of the story's last quality run.
+	Quality string
+	// Review is a one-line outcome of the story's review, and ReviewSummary
+	// the findings of its last round.
+	Review        string
+	ReviewSummary string
+	// Plan is the story's plan file.
+	Plan string
+}
+
+// LoadPRBodyData collects the PRD, its progress, and the quality and review
+// events of its stories from the PRD's artifacts.
+func LoadPRBodyData(store prd.Store, artifactDir, name string) (PRBodyData, error) {
+	doc, err := store.Load(name)
+	if err != nil {
+		return PRBodyData{}, err
+	}
+	sections, err := loadPRDSections(project.PRDMarkdownPath(artifactDir, name))
+	if err != nil {
+		return PRBodyData{}, err
+	}
+	summaries, err := loadPassedSummaries(project.PRDProgressPath(artifactDir, name))
+	if err != nil {
+		return PRBodyData{}, err
+	}
+	outcomes, err := loadStoryOutcomes(project.PRDEventsPath(artifactDir, name))
+	if err != nil {
+		return PRBodyData{}, err
+	}
+
+	data := PRBodyData{
+		PRD:         name,
+		Project:     strings.TrimSpace(doc.Project),
+		Description: strings.TrimSpace(doc.Description),
+		Sections:    sections,
+		Total:       len(doc.UserStories),
+	}
+	for _, story := range doc.UserStories {
+		entry := PRBodyStory{ID: story.ID, Title: story.Title, Type: story.CommitType()}
+		if !story.Passes {
+			data.Remaining = append(data.Remaining, entry)
+			continue
+		}
+		entry.Summary = summaries[story.ID]
+		if outcome, ok := outcomes[story.ID]; ok {
+			if len(outcome.quality.Results) > 0 {
+				entry.Quality = formatQualityTable(outcome.quality)
+			}
+			if outcome.reviewRounds > 0 {
+				entry.Review = formatReviewOutcome(outcome)
+				entry.ReviewSummary = outcome.reviewSummary
+			}
+		}
+		if plan, err := os.ReadFile(project.PRDPlanPath(artifactDir, name, story.ID)); err == nil {
+			entry.Plan = strings.TrimSpace(string(plan))
+		}
+		data.Stories = append(data.Stories, entry)
+	}
+	return data, nil
+}
+
+var extraBlankLines = regexp.MustCompile(`\n{3,}`)
+
+// RenderPRBody executes tmpl, or the built-in pull request template when
+// tmpl is empty, with data.
+func RenderPRBody(tmpl string, data PRBodyData) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = templates.PRBody
+	}
+	parsed, err := template.New("pr-body").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid PR body template: %w", err)
+	}
+	var out bytes.Buffer
+	if err := parsed.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render PR body: %w", err)
+	}
+	body := extraBlankLines.ReplaceAllString(out.String(), "\n\n")
+	return strings.TrimSpace(body) + "\n", nil
+}
+
+// openPullRequest opens the PRD's pull request, or refreshes its body when
+// one is open, and links a new pull request from progress.md under entryID.
+// Failures are logged; the story stays passed.
+func (m Manager) openPullRequest(ctx context.Context, artifactDir, name, workDir, entryID string) {
+	result, err := m.completionExec.CreatePR(ctx, workDir, m.pullRequest(artifactDir, name))
+	if err != nil {
+		_ = appendAgentLog(artifactDir, name, "[completion] pr creation failed: "+err.Error()+"\n")
+		return
+	}
+	if result.Updated {
+		_ = appendAgentLog(artifactDir, name, "[completion] pull request updated: "+result.URL+"\n")
+		return
+	}
+	_ = appendAgentLog(artifactDir, name, "[completion] pull request opened: "+result.URL+"\n")
+	_ = appendProgress(artifactDir, name, entryID, "pull request", "Pull request opened: "+result.URL)
+}
+
+// pullRequest builds the pull request for the PRD. When the body cannot be
+// rendered the failure is logged and the body left empty.
+func (m Manager) pullRequest(artifactDir, name string) daedalusgit.PullRequest {
+	pr := daedalusgit.PullRequest{
+		Title:     name,
+		Draft:     m.completion.PRDraft,
+		Labels:    m.completion.PRLabels,
+		Reviewers: m.completion.PRReviewers,
+	}
+	data, err := LoadPRBodyData(m.store, artifactDir, name)
+	if err == nil {
+		if data.Project != "" {
+			pr.Title = data.Project
+		}
+		pr.Body, err = RenderPRBody(m.completion.PRBodyTemplate, data)
+	}
+	if err != nil {
+		_ = appendAgentLog(artifactDir, name, "[completion] pr body failed: "+err.Error()+"\n")
+		pr.Body = ""
+	}
+	return pr
+}
+
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+func loadPRDSections(path string) (map[string]string, error) {
+	sections := map[string]string{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return sections, nil
+		}
+		return nil, err
+	}
+	text := htmlComment.ReplaceAllString(string(data), "")
+	heading := ""
+	var body []string
+	flush := func() {
+		content := strings.TrimSpace(strings.Join(body, "\n"))
+		if heading != "" && content != "" {
+			sections[heading] = content
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			heading = strings.TrimSpace(strings.TrimPrefix(line, "## "))
+			body = nil
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections, nil
+}
+
+// loadPassedSummaries returns the summary of each story's last "passed"
+// entry in progress.md, without its quality check details.
+func loadPassedSummaries(path string) (map[string]string, error) {
+	summaries := map[string]string{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return summaries, nil
+		}
+		return nil, err
+	}
+	for _, block := range strings.Split(string(data), "\n## Iteration - ")[1:] {
+		header, rest, _ := strings.Cut(block, "\n")
+		storyID, status, ok := strings.Cut(header, " - ")
+		if !ok || status != "passed" {
+			continue
+		}
+		_, summary, ok := strings.Cut(rest, "Summary:\n")
+		if !ok {
+			continue
+		}
+		summary, _, _ = strings.Cut(summary, "\n\nQuality checks:")
+		summary = strings.TrimSpace(summary)
+		// Placeholders written when the agent gave no summary say nothing.
+		if summary == "story completed" || summary == "iteration completed" {
+			summary = ""
+		}
+		summaries[storyID] = summary
+	}
+	return summaries, nil
+}
+
+// storyOutcome is a story's last quality run and review, read from
+// events.jsonl.
+type storyOutcome struct {
+	quality        quality.Report
+	reviewRounds   int
+	reviewPassed   bool
+	perspectives   []string
+	reviewFindings int
+	reviewSummary  string
+}
+
+func loadStoryOutcomes(path string) (map[string]*storyOutcome, error) {
+	outcomes := map[string]*storyOutcome{}
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return outcomes, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			Type         string   `json:"type"`
+			StoryID      string   `json:"storyID"`
+			Phase        string   `json:"phase"`
+			Name         string   `json:"name"`
+			Group        string   `json:"group"`
+			Command      string   `json:"command"`
+			ExitCode     int      `json:"exitCode"`
+			Duration     string   `json:"duration"`
+			TimedOut     bool     `json:"timedOut"`
+			Skipped      bool     `json:"skipped"`
+			Unchanged    bool     `json:"unchanged"`
+			AllowFailure bool     `json:"allowFailure"`
+			Round        int      `json:"round"`
+			Perspectives []string `json:"perspectives"`
+			Findings     int      `json:"findings"`
+			Summary      string   `json:"summary"`
+			Passed       bool     `json:"passed"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.StoryID == "" {
+			continue
+		}
+		outcome, ok := outcomes[entry.StoryID]
+		if !ok {
+			outcome = &storyOutcome{}
+			outcomes[entry.StoryID] = outcome
+		}
+		switch {
+		case entry.Type == string(providers.EventCommandOutput) && entry.Phase == "quality":
+			name := entry.Name
+			if name == "" {
+				name = entry.Command
+			}
+			// Every run records each check once, so a check seen again
+			// starts a new run that replaces the previous one.
+			if outcome.quality.Results == nil || hasQualityCheck(outcome.quality, name) {
+				outcome.quality = quality.Report{Passed: true, Results: []quality.Result{}}
+			}
+			duration, _ := time.ParseDuration(entry.Duration)
+			result := quality.Result{
+				Name:         name,
+				Group:        entry.Group,
+				Command:      entry.Command,
+				ExitCode:     entry.ExitCode,
+				Duration:     duration,
+				TimedOut:     entry.TimedOut,
+				Skipped:      entry.Skipped,
+				Unchanged:    entry.Unchanged,
+				AllowFailure: entry.AllowFailure,
+			}
+			outcome.quality.Results = append(outcome.quality.Results, result)
+			if result.Blocks() {
+				outcome.quality.Passed = false
+			}
+		case entry.Type == string(providers.EventIterationDone) && (entry.Phase == "review" || entry.Phase == "re-review"):
+			outcome.reviewRounds = entry.Round + 1
+			outcome.reviewPassed = entry.Passed
+			outcome.perspectives = entry.Perspectives
+			outcome.reviewFindings = entry.Findings
+			outcome.reviewSummary = strings.TrimSpace(entry.Summary)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+func hasQualityCheck(report quality.Report, name string) bool {
+	for _, result := range report.Results {
+		if result.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatReviewOutcome(outcome *storyOutcome) string {
+	status := "Passed"
+	if !outcome.reviewPassed {
+		status = "Not passed"
+	}
+	rounds := "round"
+	if outcome.reviewRounds != 1 {
+		rounds = "rounds"
+	}
+	line := fmt.Sprintf("%s after %d %s", status, outcome.reviewRounds, rounds)
+	if len(outcome.perspectives) > 0 {
+		line += " (" + strings.Join(outcome.perspectives, ", ") + ")"
+	}
+	return fmt.Sprintf("%s, %d finding(s) in the last round.", line, outcome.reviewFindings)
+}
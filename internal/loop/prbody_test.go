@@ -0,0 +1,128 @@
+package loop
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+func TestLoadPRBodyDataCollectsStoryArtifacts(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := prd.NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	if err := store.Save("main", prd.Document{
+		Project:     "Checkout",
+		Description: "Faster checkout.",
+		UserStories: []prd.UserStory{
+			{ID: "US-001", Title: "Fix cart totals", Passes: true},
+			{ID: "US-002", Title: "Add coupons"},
+		},
+	}); err != nil {
+		t.Fatalf("save PRD: %v", err)
+	}
+	markdown := "# PRD: Checkout\n\n## Problem Statement\n\n<!-- hint -->\nCarts add up wrong.\n\n## Goals\n\n<!-- hint -->\n"
+	if err := os.WriteFile(project.PRDMarkdownPath(baseDir, "main"), []byte(markdown), 0o644); err != nil {
+		t.Fatalf("write prd.md: %v", err)
+	}
+	if err := os.MkdirAll(project.PRDPlansDir(baseDir, "main"), 0o755); err != nil {
+		t.Fatalf("mkdir plans: %v", err)
+	}
+	if err := os.WriteFile(project.PRDPlanPath(baseDir, "main", "US-001"), []byte("1. Round totals.\n"), 0o644); err != nil {
+		t.Fatalf("write plan: %v", err)
+	}
+
+	failing := quality.Report{Results: []quality.Result{{Command: "go test ./...", ExitCode: 1, Duration: time.Second}}}
+	passing := quality.Report{Passed: true, Results: []quality.Result{{Command: "go test ./...", Duration: 2 * time.Second}}}
+	for _, report := range []quality.Report{failing, passing} {
+		if err := appendQualityReport(baseDir, "main", "US-001", 1, report); err != nil {
+			t.Fatalf("append quality report: %v", err)
+		}
+	}
+	review := quality.ReviewReport{Passed: true, Reviews: []providers.PerspectiveReview{{
+		Perspective: "security",
+		Duration:    "3s",
+		Findings:    []providers.ReviewFinding{{Severity: "low", Message: "log the rounding mode"}},
+	}}}
+	if err := appendReviewRoundEvent(baseDir, "main", "US-001", "review", 0, review); err != nil {
+		t.Fatalf("append review event: %v", err)
+	}
+	if err := appendProgress(baseDir, "main", "US-001", "failed", "Quality checks failed."); err != nil {
+		t.Fatalf("append progress: %v", err)
+	}
+	if err := appendProgress(baseDir, "main", "US-001", "passed", "Rounded totals to cents.\n\n"+formatQualitySummary(passing)); err != nil {
+		t.Fatalf("append progress: %v", err)
+	}
+
+	data, err := LoadPRBodyData(store, baseDir, "main")
+	if err != nil {
+		t.Fatalf("load PR body data: %v", err)
+	}
+	if data.Sections["Problem Statement"] != "Carts add up wrong." {
+		t.Fatalf("unexpected problem statement: %q", data.Sections["Problem Statement"])
+	}
+	if _, ok := data.Sections["Goals"]; ok {
+		t.Fatal("expected the comment-only Goals section to be left out")
+	}
+	if len(data.Stories) != 1 || len(data.Remaining) != 1 || data.Total != 2 {
+		t.Fatalf("unexpected stories: %+v remaining %+v", data.Stories, data.Remaining)
+	}
+	story := data.Stories[0]
+	if story.Type != prd.CommitTypeFix || story.Summary != "Rounded totals to cents." || story.Plan != "1. Round totals." {
+		t.Fatalf("unexpected story: %+v", story)
+	}
+	if !strings.Contains(story.Quality, "| `go test ./...` | passed | 2s |") || strings.Contains(story.Quality, "failed") {
+		t.Fatalf("expected only the last quality run, got:\n%s", story.Quality)
+	}
+	if story.Review != "Passed after 1 round (security), 1 finding(s) in the last round." {
+		t.Fatalf("unexpected review: %q", story.Review)
+	}
+	if !strings.Contains(story.ReviewSummary, "log the rounding mode") {
+		t.Fatalf("expected review findings, got %q", story.ReviewSummary)
+	}
+
+	body, err := RenderPRBody("", data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, fragment := range []string{
+		"## Summary\n\nFaster checkout.\n\nCarts add up wrong.\n\n## Stories",
+		"### US-001: Fix cart totals\n\nRounded totals to cents.",
+		"**Review:** Passed after 1 round",
+		"<summary>Plan</summary>",
+		"## Remaining stories\n\n- US-002: Add coupons",
+		"1 of 2 stories complete",
+	} {
+		if !strings.Contains(body, fragment) {
+			t.Fatalf("expected %q in body, got:\n%s", fragment, body)
+		}
+	}
+	if strings.Contains(body, "\n\n\n") {
+		t.Fatalf("expected blank lines to be collapsed, got:\n%s", body)
+	}
+}
+
+func TestRenderPRBodyUsesCustomTemplate(t *testing.T) {
+	t.Parallel()
+
+	data := PRBodyData{PRD: "main", Stories: []PRBodyStory{{ID: "US-001", Title: "One"}, {ID: "US-002", Title: "Two"}}}
+	body, err := RenderPRBody("{{ range .Stories }}* {{ .ID }}\n{{ end }}", data)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if body != "* US-001\n* US-002\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+	if _, err := RenderPRBody("{{ .Nope", data); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
@@ -0,0 +1,207 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// SetReviewRemediationRounds sets how many times review findings are sent back
+// to the agent for fixing before the story fails. Zero disables remediation.
+func (m *Manager) SetReviewRemediationRounds(rounds int) {
+	if rounds < 0 {
+		rounds = 0
+	}
+	m.reviewRemediationRounds = rounds
+}
+
+// runReviewPhase reviews the story from every configured perspective. While
+// perspectives report blocking findings and remediation rounds remain, the
+// findings are sent back to the agent and only the perspectives that blocked
+// are reviewed again.
+func (m Manager) runReviewPhase(ctx context.Context, artifactDir, name, workDir string, story prd.UserStory, contextFiles []string, request providers.IterationRequest) error {
+	storyID := story.ID
+	perspectives := m.reviewPerspectives
+
+	for round := 0; ; round++ {
+		phase := "review"
+		if round > 0 {
+			phase = "re-review"
+		}
+		m.reportPhase("reviewing", storyID)
+		reviewRequest := request
+		if _, options, routed := m.phaseRoute(PhaseReview); routed {
+			reviewRequest.ApprovalPolicy = options.ApprovalPolicy
+			reviewRequest.SandboxPolicy = options.SandboxPolicy
+			reviewRequest.Model = options.Model
+		}
+		reviewRequest.Approver = m.loggedApprover(artifactDir, name, storyID, phase, round+1)
+		reviewCtx, cancelReview, timeout := m.withPhaseTimeout(ctx, phase)
+		reviewReport, reviewErr := m.reviewer.RunReview(reviewCtx, workDir, contextFiles, perspectives, reviewRequest)
+		if timeoutErr := phaseTimeoutError(ctx, reviewCtx, phase, timeout); timeoutErr != nil {
+			cancelReview()
+			_ = appendProviderError(artifactDir, name, storyID, round+1, timeoutErr)
+			_ = appendProgress(artifactDir, name, storyID, "error", "["+phase+"] "+timeoutErr.Error())
+			return fmt.Errorf("review failed: %w", timeoutErr)
+		}
+		cancelReview()
+		if reviewErr != nil {
+			_ = appendAgentLog(artifactDir, name, "[review] error: "+reviewErr.Error()+"\n")
+		}
+		summary := providers.SynthesizeReviewSummary(reviewReport.Reviews)
+		if summary != "" {
+			_ = appendAgentLog(artifactDir, name, "["+phase+"] summary:\n"+summary+"\n")
+		}
+		_ = appendReviewRoundEvent(artifactDir, name, storyID, phase, round, reviewReport)
+		for _, review := range reviewReport.Reviews {
+			if review.Usage == nil {
+				continue
+			}
+			m.recordUsage(artifactDir, name, usageRecord{
+				StoryID:     storyID,
+				Phase:       phase,
+				Perspective: review.Perspective,
+				Provider:    review.Provider,
+				Model:       review.Model,
+				Iteration:   round + 1,
+				Usage:       *review.Usage,
+			})
+		}
+		if err := m.checkBudget(artifactDir, name); err != nil {
+			return err
+		}
+		if reviewReport.Passed {
+			return nil
+		}
+
+		flagged := blockedReviews(reviewReport.Reviews)
+		if round >= m.reviewRemediationRounds || len(flagged) == 0 {
+			// If review found issues, treat as a quality failure.
+			if round > 0 {
+				summary = fmt.Sprintf("Review findings remain after %d remediation round(s).\n%s", round, summary)
+			}
+			_ = appendProgress(artifactDir, name, storyID, "failed", "[review] "+summary)
+			_ = m.appendLearnings(artifactDir, name, storyID, "review", summary)
+			return fmt.Errorf("review found issues")
+		}
+
+		remediationRound := round + 1
+		m.reportPhase("remediating", fmt.Sprintf("%s (round %d/%d)", storyID, remediationRound, m.reviewRemediationRounds))
+
+		remediationRequest := request
+		remediationRequest.Prompt = buildRemediationPrompt(story, flagged, remediationRound, m.reviewRemediationRounds)
+		// The session already holds the story context; only the findings are new.
+		remediationRequest.ContextFiles = nil
+		remediationRequest.Metadata = map[string]string{
+			"storyID": storyID,
+			"phase":   "remediation",
+			"round":   strconv.Itoa(remediationRound),
+		}
+
+		if _, _, err := m.runIterationWithRetry(ctx, artifactDir, name, remediationRequest, nil); err != nil {
+			_ = appendReviewRemediationEvent(artifactDir, name, storyID, remediationRound, flagged, err)
+			_ = appendProgress(artifactDir, name, storyID, "error", "review remediation failed: "+err.Error())
+			_ = m.appendLearnings(artifactDir, name, storyID, "remediation", err.Error())
+			return fmt.Errorf("review remediation failed: %w", err)
+		}
+		_ = appendReviewRemediationEvent(artifactDir, name, storyID, remediationRound, flagged, nil)
+
+		perspectives = make([]string, 0, len(flagged))
+		for _, review := range flagged {
+			perspectives = append(perspectives, review.Perspective)
+		}
+	}
+}
+
+// blockedReviews returns the reviews with findings at or above the fail_on
+// severity, in the order the reviewer returned them.
+func blockedReviews(reviews []providers.PerspectiveReview) []providers.PerspectiveReview {
+	var flagged []providers.PerspectiveReview
+	for _, review := range reviews {
+		if review.Blocked && len(review.Findings) > 0 {
+			flagged = append(flagged, review)
+		}
+	}
+	return flagged
+}
+
+func appendReviewRoundEvent(workDir, name, storyID, phase string, round int, report quality.ReviewReport) error {
+	perspectives := make([]string, 0, len(report.Reviews))
+	findings := 0
+	var blocking []string
+	for _, review := range report.Reviews {
+		perspectives = append(perspectives, review.Perspective)
+		findings += len(review.Findings)
+		if review.Blocked {
+			blocking = append(blocking, review.Perspective)
+		}
+	}
+	payload := map[string]interface{}{
+		"type":         string(providers.EventIterationDone),
+		"message":      fmt.Sprintf("%s round %d finished with %d finding(s)", phase, round, findings),
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+		"iteration":    round + 1,
+		"storyID":      storyID,
+		"phase":        phase,
+		"round":        round,
+		"perspectives": perspectives,
+		"findings":     findings,
+		"blocking":     blocking,
+		"passed":       report.Passed,
+		"summary":      providers.SynthesizeReviewSummary(report.Reviews),
+	}
+	return appendEventPayload(workDir, name, payload)
+}
+
+func appendReviewRemediationEvent(workDir, name, storyID string, round int, flagged []providers.PerspectiveReview, err error) error {
+	perspectives := make([]string, 0, len(flagged))
+	for _, review := range flagged {
+		perspectives = append(perspectives, review.Perspective)
+	}
+	payload := map[string]interface{}{
+		"type":         string(providers.EventIterationDone),
+		"message":      fmt.Sprintf("remediation round %d finished", round),
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+		"iteration":    round,
+		"storyID":      storyID,
+		"phase":        "remediation",
+		"round":        round,
+		"perspectives": perspectives,
+	}
+	if err != nil {
+		payload["type"] = string(providers.EventError)
+		payload["message"] = fmt.Sprintf("remediation round %d failed: %s", round, err.Error())
+	}
+	if appendErr := appendEventPayload(workDir, name, payload); appendErr != nil {
+		return appendErr
+	}
+	return appendAgentLog(workDir, name, fmt.Sprintf("[remediation] round %d: %s\n", round, strings.Join(perspectives, ", ")))
+}
+
+func buildRemediationPrompt(story prd.UserStory, flagged []providers.PerspectiveReview, round, maxRounds int) string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "Code review found issues in story %s (%s).\n", story.ID, story.Title)
+	fmt.Fprintf(&builder, "Remediation round %d of %d.\n\n", round, maxRounds)
+	builder.WriteString("Findings:\n")
+	for _, review := range flagged {
+		builder.WriteString("\n[")
+		builder.WriteString(review.Perspective)
+		builder.WriteString("]\n")
+		for _, finding := range review.Findings {
+			builder.WriteString("- ")
+			builder.WriteString(finding.String())
+			builder.WriteString("\n")
+		}
+	}
+	builder.WriteString("\nRules:\n")
+	builder.WriteString("- Fix every finding that is a real issue; leave code unchanged for findings that are not.\n")
+	builder.WriteString("- Keep the changes within the scope of the active story.\n")
+	builder.WriteString("- Do not execute destructive git operations.\n")
+	return builder.String()
+}
@@ -0,0 +1,142 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// scriptedReviewer returns findings per perspective for each call in turn and
+// records which perspectives were requested.
+type scriptedReviewer struct {
+	rounds    []map[string][]string
+	requested [][]string
+}
+
+func (r *scriptedReviewer) RunReview(_ context.Context, _ string, _ []string, perspectives []string, _ providers.IterationRequest) (quality.ReviewReport, error) {
+	index := len(r.requested)
+	r.requested = append(r.requested, append([]string(nil), perspectives...))
+	if index >= len(r.rounds) {
+		index = len(r.rounds) - 1
+	}
+
+	report := quality.ReviewReport{Passed: true}
+	for _, perspective := range perspectives {
+		review := providers.PerspectiveReview{Perspective: perspective}
+		for _, message := range r.rounds[index][perspective] {
+			review.Findings = append(review.Findings, providers.ReviewFinding{Severity: providers.SeverityHigh, Message: message})
+			review.Blocked = true
+			report.Passed = false
+		}
+		report.Reviews = append(report.Reviews, review)
+	}
+	return report, nil
+}
+
+func newRemediationManager(t *testing.T, requests *[]providers.IterationRequest, reviewer quality.Reviewer, rounds int) (Manager, prd.Store, string) {
+	t.Helper()
+	manager, store, baseDir := newTestManager(t, recordingProvider{requests: requests}, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.reviewer = reviewer
+	manager.reviewPerspectives = []string{"security", "performance", "complexity"}
+	manager.SetReviewRemediationRounds(rounds)
+	return manager, store, baseDir
+}
+
+func TestRunOnceRemediatesReviewFindingsAndReReviewsFlaggedPerspectives(t *testing.T) {
+	t.Parallel()
+
+	var requests []providers.IterationRequest
+	reviewer := &scriptedReviewer{rounds: []map[string][]string{
+		{"security": {"SQL built with string concatenation in store.go"}},
+		{},
+	}}
+	manager, store, baseDir := newRemediationManager(t, &requests, reviewer, 2)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("expected remediation to rescue the story, got %v", err)
+	}
+	if len(reviewer.requested) != 2 {
+		t.Fatalf("expected two review rounds, got %d", len(reviewer.requested))
+	}
+	if got := reviewer.requested[1]; len(got) != 1 || got[0] != "security" {
+		t.Fatalf("expected only the flagged perspective to be re-reviewed, got %v", got)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected work and one remediation iteration, got %d", len(requests))
+	}
+
+	remediation := requests[1]
+	if remediation.Metadata["phase"] != "remediation" {
+		t.Fatalf("expected remediation phase metadata, got %v", remediation.Metadata)
+	}
+	if !strings.Contains(remediation.Prompt, "SQL built with string concatenation") || !strings.Contains(remediation.Prompt, "[security]") {
+		t.Fatalf("expected findings in remediation prompt, got:\n%s", remediation.Prompt)
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if !doc.UserStories[0].Passes {
+		t.Fatal("expected story to pass after remediation")
+	}
+
+	events, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	for _, phase := range []string{`"phase":"review"`, `"phase":"remediation"`, `"phase":"re-review"`} {
+		if !strings.Contains(string(events), phase) {
+			t.Fatalf("expected %s in events, got:\n%s", phase, events)
+		}
+	}
+}
+
+func TestRunOnceFailsWhenFindingsRemainAfterRemediation(t *testing.T) {
+	t.Parallel()
+
+	var requests []providers.IterationRequest
+	reviewer := &scriptedReviewer{rounds: []map[string][]string{
+		{"performance": {"unbounded loop over all rows"}},
+	}}
+	manager, store, baseDir := newRemediationManager(t, &requests, reviewer, 1)
+
+	err := manager.RunOnce(context.Background(), "main", baseDir, baseDir)
+	if err == nil || !strings.Contains(err.Error(), "review found issues") {
+		t.Fatalf("expected review failure, got %v", err)
+	}
+	if len(reviewer.requested) != 2 || len(requests) != 2 {
+		t.Fatalf("expected one remediation round, got %d reviews and %d iterations", len(reviewer.requested), len(requests))
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if doc.UserStories[0].Passes {
+		t.Fatal("expected story to remain failed")
+	}
+}
+
+func TestRunOnceSkipsRemediationWhenDisabled(t *testing.T) {
+	t.Parallel()
+
+	var requests []providers.IterationRequest
+	reviewer := &scriptedReviewer{rounds: []map[string][]string{
+		{"complexity": {"needless interface"}},
+	}}
+	manager, _, baseDir := newRemediationManager(t, &requests, reviewer, 0)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected review failure")
+	}
+	if len(reviewer.requested) != 1 || len(requests) != 1 {
+		t.Fatalf("expected no remediation, got %d reviews and %d iterations", len(reviewer.requested), len(requests))
+	}
+}
@@ -0,0 +1,192 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// repairOutputLimit caps how much stdout/stderr from a failing quality command
+// is sent back to the agent. The tail is kept because that is where test
+// runners and compilers usually put the failure.
+const repairOutputLimit = 4000
+
+// When a quality command's output was parsed into tests, only the failed
+// tests are reported: at most failedTestLimit of them, each with the last
+// testMessageLimit bytes of its message. Event messages name
+// eventFailedTestNames of them.
+const (
+	failedTestLimit      = 20
+	testMessageLimit     = 1500
+	eventFailedTestNames = 5
+)
+
+// SetQualityRepairRounds sets how many times a failing quality gate is sent
+// back to the agent for fixing before the story fails. Zero disables repair.
+func (m *Manager) SetQualityRepairRounds(rounds int) {
+	if rounds < 0 {
+		rounds = 0
+	}
+	m.qualityRepairRounds = rounds
+}
+
+// SetQualityChecks replaces the quality commands given to NewManager with a
+// table of checks. An empty table keeps the commands.
+func (m *Manager) SetQualityChecks(checks []quality.Check) {
+	if len(checks) == 0 {
+		return
+	}
+	m.qualityChecks = checks
+}
+
+// runQualityPhase runs the quality gates, and while they fail and repair rounds
+// remain, asks the agent to fix the failures in the same session before running
+// them again. It returns the last report once the gates pass.
+func (m Manager) runQualityPhase(ctx context.Context, artifactDir, name, workDir string, story prd.UserStory, request providers.IterationRequest, iterationAttempt int, run qualityRun) (quality.Report, error) {
+	storyID := story.ID
+	for round := 0; ; round++ {
+		// Repairs can touch other files, so every round is scoped afresh.
+		checks, scoped := m.scopeChecks(ctx, artifactDir, name, workDir, run)
+		startedAt := time.Now()
+		report, err := m.qualityChecker.Run(ctx, workDir, checks)
+		if err != nil {
+			_ = appendQualityRunnerError(artifactDir, name, storyID, iterationAttempt, err)
+			_ = appendProgress(artifactDir, name, storyID, "error", err.Error())
+			return report, fmt.Errorf("quality checks failed to run: %w", err)
+		}
+		if err := appendQualityReport(artifactDir, name, storyID, iterationAttempt, report); err != nil {
+			return report, fmt.Errorf("failed to persist quality report: %w", err)
+		}
+		// A scoped run writes partial cover profiles; only full runs are
+		// held to the coverage gate.
+		if !scoped {
+			report = m.applyCoverageGate(workDir, run.coverageBaseline, startedAt, report)
+		}
+		if report.Coverage != nil {
+			_ = appendCoverageEvent(artifactDir, name, storyID, iterationAttempt, *report.Coverage)
+		}
+		if report.Passed {
+			return report, nil
+		}
+
+		if round >= m.qualityRepairRounds {
+			summary := formatQualitySummary(report)
+			if round > 0 {
+				summary = fmt.Sprintf("Quality checks still failing after %d repair round(s).\n\n%s", round, summary)
+			}
+			_ = appendProgress(artifactDir, name, storyID, "failed", summary)
+			_ = m.appendLearnings(artifactDir, name, storyID, "quality", formatQualitySummary(report))
+			return report, fmt.Errorf("quality checks failed")
+		}
+
+		repairRound := round + 1
+		m.reportPhase("repairing", fmt.Sprintf("%s (round %d/%d)", storyID, repairRound, m.qualityRepairRounds))
+		_ = appendRepairEvent(artifactDir, name, storyID, iterationAttempt, repairRound, m.qualityRepairRounds, report)
+
+		repairRequest := request
+		repairRequest.Prompt = buildRepairPrompt(story, report, repairRound, m.qualityRepairRounds)
+		// The session already holds the story context; only the failures are new.
+		repairRequest.ContextFiles = nil
+		repairRequest.Metadata = map[string]string{
+			"storyID": storyID,
+			"phase":   "repair",
+			"round":   strconv.Itoa(repairRound),
+		}
+
+		if _, _, err := m.runIterationWithRetry(ctx, artifactDir, name, repairRequest, nil); err != nil {
+			_ = appendProgress(artifactDir, name, storyID, "error", "quality repair failed: "+err.Error())
+			_ = m.appendLearnings(artifactDir, name, storyID, "repair", err.Error())
+			return report, fmt.Errorf("quality repair failed: %w", err)
+		}
+	}
+}
+
+func appendRepairEvent(workDir, name, storyID string, iteration, round, maxRounds int, report quality.Report) error {
+	failing := make([]string, 0, len(report.Results))
+	for _, result := range report.Results {
+		if result.Blocks() {
+			failing = append(failing, result.Command)
+		}
+	}
+	if report.Coverage != nil && !report.Coverage.Passed() {
+		failing = append(failing, "coverage")
+	}
+	payload := map[string]interface{}{
+		"type":      string(providers.EventIterationStarted),
+		"message":   fmt.Sprintf("quality repair round %d/%d started", round, maxRounds),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"iteration": iteration,
+		"storyID":   storyID,
+		"phase":     "repair",
+		"round":     round,
+		"failing":   failing,
+	}
+	if err := appendEventPayload(workDir, name, payload); err != nil {
+		return err
+	}
+	return appendAgentLog(workDir, name, fmt.Sprintf("[repair] round %d/%d: %s\n", round, maxRounds, strings.Join(failing, ", ")))
+}
+
+func buildRepairPrompt(story prd.UserStory, report quality.Report, round, maxRounds int) string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "Quality checks failed for story %s (%s).\n", story.ID, story.Title)
+	fmt.Fprintf(&builder, "Repair round %d of %d.\n\n", round, maxRounds)
+	listed := false
+	for _, result := range report.Results {
+		// Skipped checks clear up once the checks they depend on are fixed.
+		if !result.Blocks() || result.Skipped {
+			continue
+		}
+		if !listed {
+			builder.WriteString("Failing commands:\n")
+			listed = true
+		}
+		builder.WriteString("\n- Command: ")
+		builder.WriteString(result.Command)
+		builder.WriteString("\n  Exit code: ")
+		builder.WriteString(strconv.Itoa(result.ExitCode))
+		if result.TestsExplain() {
+			builder.WriteString("\n  Tests: ")
+			builder.WriteString(formatTestCounts(result.TestCounts()))
+			builder.WriteString("\n")
+			writeFailedTests(&builder, result, testMessageLimit)
+			builder.WriteString("  Stderr:\n")
+		} else {
+			builder.WriteString("\n  Stdout:\n")
+			builder.WriteString(indentedBlock(trimOutput(result.Stdout, repairOutputLimit)))
+			builder.WriteString("\n  Stderr:\n")
+		}
+		builder.WriteString(indentedBlock(trimOutput(result.Stderr, repairOutputLimit)))
+		builder.WriteString("\n")
+	}
+	if report.Coverage != nil && !report.Coverage.Passed() {
+		builder.WriteString("\nCoverage gate failed: ")
+		builder.WriteString(report.Coverage.Failure)
+		builder.WriteString("\nAdd or restore tests for the code this story changed; do not exclude code from coverage.\n")
+	}
+	builder.WriteString("\nRules:\n")
+	builder.WriteString("- Fix the cause of these failures without weakening or deleting the checks.\n")
+	builder.WriteString("- Keep the changes within the scope of the active story.\n")
+	builder.WriteString("- Do not execute destructive git operations.\n")
+	return builder.String()
+}
+
+// trimOutput keeps at most limit bytes from the end of text, cutting at a line
+// boundary where possible.
+func trimOutput(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+	tail := text[len(text)-limit:]
+	if index := strings.IndexByte(tail, '\n'); index >= 0 && index < len(tail)-1 {
+		tail = tail[index+1:]
+	}
+	return fmt.Sprintf("... (%d bytes trimmed)\n%s", len(text)-len(tail), tail)
+}
@@ -0,0 +1,283 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// recordingProvider captures every iteration request it receives.
+type recordingProvider struct {
+	requests *[]providers.IterationRequest
+}
+
+func (p recordingProvider) Name() string {
+	return "recording"
+}
+
+func (p recordingProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p recordingProvider) RunIteration(_ context.Context, request providers.IterationRequest) (<-chan providers.Event, providers.IterationResult, error) {
+	*p.requests = append(*p.requests, request)
+	events := make(chan providers.Event, 1)
+	events <- providers.Event{Type: providers.EventAssistantText, Message: "done"}
+	close(events)
+	return events, providers.IterationResult{Success: true}, nil
+}
+
+// sequenceChecker returns reports in order, repeating the last one.
+type sequenceChecker struct {
+	reports []quality.Report
+	calls   *int
+	checks  *[]quality.Check
+}
+
+func (c sequenceChecker) Run(_ context.Context, _ string, checks []quality.Check) (quality.Report, error) {
+	if c.checks != nil {
+		*c.checks = checks
+	}
+	index := *c.calls
+	*c.calls++
+	if index >= len(c.reports) {
+		index = len(c.reports) - 1
+	}
+	return c.reports[index], nil
+}
+
+func failingTestReport() quality.Report {
+	return quality.Report{
+		Passed: false,
+		Results: []quality.Result{
+			{Command: "go vet ./...", ExitCode: 0},
+			{Command: "go test ./...", ExitCode: 1, Stdout: "--- FAIL: TestWidget", Stderr: "widget_test.go:12: want 2, got 3"},
+		},
+	}
+}
+
+func newRepairManager(t *testing.T, requests *[]providers.IterationRequest, checker qualityChecker, rounds int) (Manager, prd.Store, string) {
+	t.Helper()
+	manager, store, baseDir := newTestManager(t, recordingProvider{requests: requests}, checker, noRetries)
+	manager.SetQualityChecks(quality.CommandChecks([]string{"go vet ./...", "go test ./..."}))
+	manager.SetQualityRepairRounds(rounds)
+	return manager, store, baseDir
+}
+
+func TestRunOnceRepairsFailingQualityChecksInSameSession(t *testing.T) {
+	t.Parallel()
+
+	var requests []providers.IterationRequest
+	calls := 0
+	checker := sequenceChecker{
+		reports: []quality.Report{failingTestReport(), {Passed: true}},
+		calls:   &calls,
+	}
+	manager, store, baseDir := newRepairManager(t, &requests, checker, 2)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("expected repair to rescue the story, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected quality checks to run twice, got %d", calls)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected work and one repair iteration, got %d", len(requests))
+	}
+
+	repair := requests[1]
+	if repair.WorkDir != requests[0].WorkDir {
+		t.Fatalf("expected repair in the same work dir, got %q", repair.WorkDir)
+	}
+	if len(repair.ContextFiles) != 0 {
+		t.Fatalf("expected repair prompt without context files, got %v", repair.ContextFiles)
+	}
+	if repair.Metadata["phase"] != "repair" {
+		t.Fatalf("expected repair phase metadata, got %v", repair.Metadata)
+	}
+	for _, fragment := range []string{"go test ./...", "Exit code: 1", "want 2, got 3", "Repair round 1 of 2"} {
+		if !strings.Contains(repair.Prompt, fragment) {
+			t.Fatalf("expected repair prompt to contain %q, got:\n%s", fragment, repair.Prompt)
+		}
+	}
+	if strings.Contains(repair.Prompt, "go vet ./...") {
+		t.Fatalf("expected passing commands to be left out of the repair prompt, got:\n%s", repair.Prompt)
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if !doc.UserStories[0].Passes {
+		t.Fatal("expected story to pass after repair")
+	}
+
+	events, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if !strings.Contains(string(events), `"phase":"repair"`) {
+		t.Fatalf("expected repair round in events, got:\n%s", events)
+	}
+}
+
+func TestRunOnceFailsAfterRepairRoundsRunOut(t *testing.T) {
+	t.Parallel()
+
+	var requests []providers.IterationRequest
+	calls := 0
+	checker := sequenceChecker{reports: []quality.Report{failingTestReport()}, calls: &calls}
+	manager, store, baseDir := newRepairManager(t, &requests, checker, 1)
+
+	err := manager.RunOnce(context.Background(), "main", baseDir, baseDir)
+	if err == nil || !strings.Contains(err.Error(), "quality checks failed") {
+		t.Fatalf("expected quality failure, got %v", err)
+	}
+	if calls != 2 || len(requests) != 2 {
+		t.Fatalf("expected one repair round, got %d checks and %d iterations", calls, len(requests))
+	}
+
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if !strings.Contains(string(progress), "after 1 repair round(s)") {
+		t.Fatalf("expected progress to mention exhausted repair rounds, got:\n%s", progress)
+	}
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if doc.UserStories[0].Passes {
+		t.Fatal("expected story to remain failed")
+	}
+}
+
+func TestRunOnceRunsConfiguredQualityChecks(t *testing.T) {
+	t.Parallel()
+
+	var requests []providers.IterationRequest
+	var checks []quality.Check
+	calls := 0
+	checker := sequenceChecker{reports: []quality.Report{{Passed: true}}, calls: &calls, checks: &checks}
+	manager, _, baseDir := newRepairManager(t, &requests, checker, 0)
+	table := []quality.Check{
+		{Name: "build", Command: "go build ./..."},
+		{Name: "test", Command: "go test ./...", DependsOn: []string{"build"}},
+	}
+	manager.SetQualityChecks(table)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if len(checks) != 2 || checks[1].DependsOn[0] != "build" {
+		t.Fatalf("expected the configured checks, got %+v", checks)
+	}
+}
+
+func TestBuildRepairPromptListsOnlyBlockingFailures(t *testing.T) {
+	t.Parallel()
+
+	report := quality.Report{Results: []quality.Result{
+		{Name: "build", Command: "go build ./...", ExitCode: 2, Stderr: "undefined: Widget"},
+		{Name: "test", Command: "go test ./...", Skipped: true, Stderr: `skipped: depends on "build", which failed`},
+		{Name: "audit", Command: "govulncheck ./...", ExitCode: 3, AllowFailure: true},
+	}}
+	prompt := buildRepairPrompt(prd.UserStory{ID: "US-001", Title: "Widget"}, report, 1, 2)
+	if !strings.Contains(prompt, "go build ./...") {
+		t.Fatalf("expected the failed build in the prompt, got:\n%s", prompt)
+	}
+	for _, unexpected := range []string{"go test ./...", "govulncheck"} {
+		if strings.Contains(prompt, unexpected) {
+			t.Fatalf("expected %q to be left out, got:\n%s", unexpected, prompt)
+		}
+	}
+}
+
+func TestTrimOutputKeepsTail(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Repeat("noise line\n", 100) + "FAIL: the real error"
+	trimmed := trimOutput(text, 60)
+	if !strings.HasSuffix(trimmed, "FAIL: the real error") {
+		t.Fatalf("expected tail to be kept, got %q", trimmed)
+	}
+	if !strings.HasPrefix(trimmed, "... (") {
+		t.Fatalf("expected trim marker, got %q", trimmed)
+	}
+	if got := trimOutput("short", 60); got != "short" {
+		t.Fatalf("expected short output untouched, got %q", got)
+	}
+}
+
+func TestBuildRepairPromptListsFailedTestsInsteadOfOutput(t *testing.T) {
+	t.Parallel()
+
+	report := quality.Report{Results: []quality.Result{{
+		Name:     "test",
+		Command:  "go test -json ./...",
+		ExitCode: 1,
+		Stdout:   strings.Repeat(`{"Action":"output","Output":"noise"}`+"\n", 500),
+		Format:   quality.FormatGoTestJSON,
+		Tests: []quality.TestCase{
+			{Suite: "example.com/app", Name: "TestAdd", Status: quality.TestPassed},
+			{Suite: "example.com/app", Name: "TestSub", Status: quality.TestFailed, Message: "app_test.go:12: got 1, want 2"},
+		},
+	}}}
+	prompt := buildRepairPrompt(prd.UserStory{ID: "US-001", Title: "Math"}, report, 1, 2)
+	for _, expected := range []string{"Tests: 2 tests: 1 passed, 1 failed", "- example.com/app.TestSub", "app_test.go:12: got 1, want 2", "Stderr:"} {
+		if !strings.Contains(prompt, expected) {
+			t.Fatalf("expected prompt to contain %q, got:\n%s", expected, prompt)
+		}
+	}
+	if strings.Contains(prompt, "noise") || strings.Contains(prompt, "TestAdd") {
+		t.Fatalf("expected raw output and passed tests to be left out, got:\n%s", prompt)
+	}
+}
+
+func TestRunOnceReportsFailedTestsInArtifacts(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	failing := fakeChecker{report: quality.Report{Passed: false, Results: []quality.Result{{
+		Command:  "go test -json ./...",
+		ExitCode: 1,
+		Stdout:   `{"Action":"output","Output":"megabytes of log"}`,
+		Format:   quality.FormatGoTestJSON,
+		Tests: []quality.TestCase{
+			{Suite: "example.com/app", Name: "TestAdd", Status: quality.TestPassed},
+			{Suite: "example.com/app", Name: "TestSub", Status: quality.TestFailed, Message: "got 1, want 2"},
+		},
+	}}}}
+	manager, _, baseDir := newTestManager(t, countingProvider{name: "fake", calls: &calls}, failing, noRetries)
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected quality checks to fail")
+	}
+
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if !strings.Contains(string(progress), "Failed tests:\n  - example.com/app.TestSub\n    got 1, want 2") || strings.Contains(string(progress), "megabytes of log") {
+		t.Fatalf("expected failed tests instead of output in progress, got:\n%s", progress)
+	}
+	events, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	for _, expected := range []string{
+		`(1 of 2 tests failed: example.com/app.TestSub)`,
+		`"tests":{"failed":1,"passed":1,"skipped":0,"total":2}`,
+		`"failedTests":[{"message":"got 1, want 2","name":"example.com/app.TestSub"}]`,
+	} {
+		if !strings.Contains(string(events), expected) {
+			t.Fatalf("expected events to contain %q, got:\n%s", expected, events)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+
+	daedalusgit "github.com/EstebanForge/daedalus/internal/git"
+)
+
+// RollbackPolicy decides what happens to a failed story's changes.
+type RollbackPolicy string
+
+const (
+	// RollbackKeep leaves the failed changes in the work dir.
+	RollbackKeep RollbackPolicy = "keep"
+	// RollbackRestore discards them, restoring the pre-story snapshot.
+	RollbackRestore RollbackPolicy = "restore"
+	// RollbackPark commits them to daedalus/failed/<story> and then restores
+	// the snapshot.
+	RollbackPark RollbackPolicy = "park"
+)
+
+type snapshotter interface {
+	Snapshot(ctx context.Context, workDir, ref, message string) (daedalusgit.Snapshot, error)
+	Restore(ctx context.Context, workDir string, snapshot daedalusgit.Snapshot) error
+	Park(ctx context.Context, workDir string, snapshot daedalusgit.Snapshot, branch, message string) (string, error)
+	DropSnapshot(ctx context.Context, workDir string, snapshot daedalusgit.Snapshot) error
+}
+
+// SetRollback snapshots the work dir before each story's work phase and,
+// when the story fails, applies policy to its changes. RollbackKeep or a nil
+// snapshotter disables snapshots.
+func (m *Manager) SetRollback(policy RollbackPolicy, snapshots snapshotter) {
+	if policy == RollbackKeep || snapshots == nil {
+		m.rollback = ""
+		m.snapshots = nil
+		return
+	}
+	m.rollback = policy
+	m.snapshots = snapshots
+}
+
+func snapshotRef(name, storyID string) string {
+	return "refs/daedalus/snapshots/" + name + "/" + storyID
+}
+
+func failedBranch(storyID string) string {
+	return "daedalus/failed/" + storyID
+}
+
+// takeSnapshot records the work dir before the work phase and notes the
+// snapshot ref in progress.md. A snapshot that cannot be taken (e.g. outside
+// a git repository) is logged and the story runs without one.
+func (m Manager) takeSnapshot(ctx context.Context, artifactDir, name, workDir, storyID string, state *runState) {
+	if m.snapshots == nil || state.Snapshot != nil {
+		return
+	}
+	snapshot, err := m.snapshots.Snapshot(ctx, workDir, snapshotRef(name, storyID), fmt.Sprintf("daedalus: snapshot before %s", storyID))
+	if err != nil {
+		_ = appendAgentLog(artifactDir, name, "[rollback] snapshot failed: "+err.Error()+"\n")
+		return
+	}
+	state.Snapshot = &snapshot
+	_ = appendProgress(artifactDir, name, storyID, "snapshot", fmt.Sprintf("Working tree snapshot: %s (%s)", snapshot.Ref, shortSHA(snapshot.Commit)))
+}
+
+// rollBack applies the rollback policy to a failed story's changes and then
+// drops the snapshot ref.
+func (m Manager) rollBack(ctx context.Context, artifactDir, name, workDir, storyID string, snapshot daedalusgit.Snapshot) {
+	if m.snapshots == nil {
+		return
+	}
+	var summary string
+	switch m.rollback {
+	case RollbackPark:
+		branch := failedBranch(storyID)
+		commit, err := m.snapshots.Park(ctx, workDir, snapshot, branch, fmt.Sprintf("daedalus: failed changes of %s", storyID))
+		if err != nil {
+			_ = appendAgentLog(artifactDir, name, "[rollback] park failed: "+err.Error()+"\n")
+			return
+		}
+		summary = fmt.Sprintf("Failed changes parked on %s (%s); working tree restored from %s.", branch, shortSHA(commit), snapshot.Ref)
+	case RollbackRestore:
+		if err := m.snapshots.Restore(ctx, workDir, snapshot); err != nil {
+			_ = appendAgentLog(artifactDir, name, "[rollback] restore failed: "+err.Error()+"\n")
+			return
+		}
+		summary = fmt.Sprintf("Failed changes discarded; working tree restored from %s (%s).", snapshot.Ref, shortSHA(snapshot.Commit))
+	default:
+		return
+	}
+	// The snapshot is only needed until the changes are back in place; when
+	// restoring fails, the ref stays for recovering them by hand.
+	_ = m.snapshots.DropSnapshot(ctx, workDir, snapshot)
+	_ = appendProgress(artifactDir, name, storyID, "rolled back", summary)
+}
+
+// dropSnapshot deletes the snapshot ref of a story that no longer needs it.
+func (m Manager) dropSnapshot(ctx context.Context, workDir string, state *runState) {
+	if m.snapshots == nil || state.Snapshot == nil {
+		return
+	}
+	_ = m.snapshots.DropSnapshot(ctx, workDir, *state.Snapshot)
+	state.Snapshot = nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,84 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	daedalusgit "github.com/EstebanForge/daedalus/internal/git"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+type fakeSnapshotter struct {
+	calls *[]string
+}
+
+func (s fakeSnapshotter) Snapshot(ctx context.Context, workDir, ref, message string) (daedalusgit.Snapshot, error) {
+	*s.calls = append(*s.calls, "snapshot "+ref)
+	return daedalusgit.Snapshot{Ref: ref, Commit: "0123456789abcdef0123", Head: "head"}, nil
+}
+
+func (s fakeSnapshotter) Restore(ctx context.Context, workDir string, snapshot daedalusgit.Snapshot) error {
+	*s.calls = append(*s.calls, "restore "+snapshot.Ref)
+	return nil
+}
+
+func (s fakeSnapshotter) Park(ctx context.Context, workDir string, snapshot daedalusgit.Snapshot, branch, message string) (string, error) {
+	*s.calls = append(*s.calls, "park "+branch)
+	return "fedcba9876543210fedc", nil
+}
+
+func (s fakeSnapshotter) DropSnapshot(ctx context.Context, workDir string, snapshot daedalusgit.Snapshot) error {
+	*s.calls = append(*s.calls, "drop "+snapshot.Ref)
+	return nil
+}
+
+func TestRunOnceParksFailedStoryChanges(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	failing := fakeChecker{report: quality.Report{Passed: false, Results: []quality.Result{{Command: "go test ./...", ExitCode: 1}}}}
+	manager, _, baseDir := newTestManager(t, countingProvider{name: "fake", calls: &calls}, failing, noRetries)
+	var snapshotCalls []string
+	manager.SetRollback(RollbackPark, fakeSnapshotter{calls: &snapshotCalls})
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected quality checks to fail")
+	}
+
+	want := []string{"snapshot refs/daedalus/snapshots/main/US-001", "park daedalus/failed/US-001", "drop refs/daedalus/snapshots/main/US-001"}
+	if strings.Join(snapshotCalls, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, snapshotCalls)
+	}
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	for _, expected := range []string{
+		"Working tree snapshot: refs/daedalus/snapshots/main/US-001 (0123456789ab)",
+		"Failed changes parked on daedalus/failed/US-001 (fedcba987654)",
+	} {
+		if !strings.Contains(string(progress), expected) {
+			t.Fatalf("expected progress to contain %q, got:\n%s", expected, progress)
+		}
+	}
+}
+
+func TestRunOnceDropsSnapshotOnceStoryIsCommitted(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	manager, _, baseDir := newTestManager(t, countingProvider{name: "fake", calls: &calls}, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	var snapshotCalls []string
+	manager.SetRollback(RollbackRestore, fakeSnapshotter{calls: &snapshotCalls})
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	want := []string{"snapshot refs/daedalus/snapshots/main/US-001", "drop refs/daedalus/snapshots/main/US-001"}
+	if strings.Join(snapshotCalls, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, snapshotCalls)
+	}
+}
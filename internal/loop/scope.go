@@ -0,0 +1,98 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+// changeLister finds the files a story changed, for quality checks scoped to
+// them. It is implemented by git.Committer.
+type changeLister interface {
+	Head(ctx context.Context, workDir string) (string, error)
+	ChangedFiles(ctx context.Context, workDir, ref string) ([]string, error)
+}
+
+// SetQualityScope lets quality checks with scope "changed" run on the files
+// and packages a story changed since it began. Every fullEvery-th story, and
+// the last story of the PRD, still runs the full suite; zero leaves only the
+// last.
+func (m *Manager) SetQualityScope(changes changeLister, fullEvery int) {
+	if fullEvery < 0 {
+		fullEvery = 0
+	}
+	m.changes = changes
+	m.qualityFullEvery = fullEvery
+}
+
+// qualityRun is what a story's quality phase takes from its run state.
+type qualityRun struct {
+	coverageBaseline *quality.Coverage
+	// startRef is the commit the story's changes are listed against. Empty
+	// runs the full suite.
+	startRef string
+	// fullSuite names why the full suite runs regardless; empty scopes
+	// checks to the story's changes.
+	fullSuite string
+}
+
+// captureStartRef records what the story starts from: the pre-work snapshot
+// when there is one, since it includes uncommitted files, or else HEAD.
+func (m Manager) captureStartRef(ctx context.Context, workDir string, state *runState) {
+	if m.changes == nil || state.StartRef != "" || !quality.HasScopedChecks(m.qualityChecks) {
+		return
+	}
+	if state.Snapshot != nil {
+		state.StartRef = state.Snapshot.Commit
+		return
+	}
+	if head, err := m.changes.Head(ctx, workDir); err == nil {
+		state.StartRef = head
+	}
+}
+
+// fullSuiteReason says why story runs the full quality suite: it is the last
+// story left in doc, or its turn in the quality.full_every cadence.
+func (m Manager) fullSuiteReason(doc prd.Document, storyID string) string {
+	passed, remaining := 0, 0
+	for _, story := range doc.UserStories {
+		if story.Passes && story.ID != storyID {
+			passed++
+		} else {
+			remaining++
+		}
+	}
+	if remaining <= 1 {
+		return "last story"
+	}
+	if m.qualityFullEvery > 0 && (passed+1)%m.qualityFullEvery == 0 {
+		return fmt.Sprintf("every %d stories", m.qualityFullEvery)
+	}
+	return ""
+}
+
+// scopeChecks fills in the placeholders of the quality checks for one run,
+// and reports whether they were scoped to the story's changes. When the
+// changed files cannot be listed, the full suite runs.
+func (m Manager) scopeChecks(ctx context.Context, artifactDir, name, workDir string, run qualityRun) ([]quality.Check, bool) {
+	if !quality.HasScopedChecks(m.qualityChecks) {
+		return quality.ScopeChecks(m.qualityChecks, nil), false
+	}
+	reason := run.fullSuite
+	if reason == "" && (m.changes == nil || run.startRef == "") {
+		reason = "story start unknown"
+	}
+	if reason == "" {
+		files, err := m.changes.ChangedFiles(ctx, workDir, run.startRef)
+		if err == nil {
+			changes := quality.NewChanges(workDir, files)
+			_ = appendAgentLog(artifactDir, name, fmt.Sprintf("[quality] scope: %d changed file(s), %d package(s)\n", len(changes.Files), len(changes.Packages)))
+			return quality.ScopeChecks(m.qualityChecks, &changes), true
+		}
+		reason = "changed files unavailable: " + err.Error()
+	}
+	_ = appendAgentLog(artifactDir, name, "[quality] scope: full suite ("+reason+")\n")
+	return quality.ScopeChecks(m.qualityChecks, nil), false
+}
@@ -0,0 +1,150 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+type fakeChanges struct {
+	files []string
+	refs  *[]string
+}
+
+func (c fakeChanges) Head(context.Context, string) (string, error) {
+	return "start123", nil
+}
+
+func (c fakeChanges) ChangedFiles(_ context.Context, _ string, ref string) ([]string, error) {
+	*c.refs = append(*c.refs, ref)
+	return c.files, nil
+}
+
+func newScopeTestManager(t *testing.T, storyIDs []string, checks *[]quality.Check, refs *[]string) (Manager, string) {
+	t.Helper()
+	calls := 0
+	checker := sequenceChecker{reports: []quality.Report{{Passed: true}}, calls: &calls, checks: checks}
+	manager, store, baseDir := newTestManager(t, fakeProvider{}, checker, noRetries)
+	doc := prd.Document{Project: "demo", Description: "demo"}
+	for i, id := range storyIDs {
+		doc.UserStories = append(doc.UserStories, prd.UserStory{ID: id, Title: "Story " + id, Description: "desc", AcceptanceCriteria: []string{"works"}, Priority: i + 1})
+	}
+	if err := store.Save("main", doc); err != nil {
+		t.Fatalf("save PRD: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(baseDir, "pkg"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(baseDir, "pkg", "a.go"), []byte("package pkg\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	manager.SetQualityChecks([]quality.Check{
+		{Command: "go test {{changed_packages}}", Scope: quality.ScopeChanged},
+		{Command: "go vet ./..."},
+	})
+	manager.SetQualityScope(fakeChanges{files: []string{"pkg/a.go"}, refs: refs}, 0)
+	return manager, baseDir
+}
+
+func TestRunOnceScopesChecksToChangedPackages(t *testing.T) {
+	t.Parallel()
+
+	var checks []quality.Check
+	var refs []string
+	manager, baseDir := newScopeTestManager(t, []string{"US-001", "US-002"}, &checks, &refs)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if len(refs) != 1 || refs[0] != "start123" {
+		t.Fatalf("expected changes listed against the story's start, got %v", refs)
+	}
+	if len(checks) != 2 || checks[0].Command != "go test ./pkg" || checks[1].Command != "go vet ./..." {
+		t.Fatalf("unexpected checks %+v", checks)
+	}
+}
+
+func TestRunOnceRunsFullSuiteOnLastStory(t *testing.T) {
+	t.Parallel()
+
+	var checks []quality.Check
+	var refs []string
+	manager, baseDir := newScopeTestManager(t, []string{"US-001"}, &checks, &refs)
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Fatalf("expected no change listing for the full suite, got %v", refs)
+	}
+	if len(checks) != 2 || checks[0].Command != "go test ./..." {
+		t.Fatalf("expected the full suite, got %+v", checks)
+	}
+}
+
+func TestFullSuiteReasonFollowsCadence(t *testing.T) {
+	t.Parallel()
+
+	doc := prd.Document{UserStories: []prd.UserStory{
+		{ID: "US-001", Passes: true},
+		{ID: "US-002"},
+		{ID: "US-003"},
+		{ID: "US-004"},
+	}}
+	manager := Manager{qualityFullEvery: 2}
+	if reason := manager.fullSuiteReason(doc, "US-002"); reason != "every 2 stories" {
+		t.Fatalf("expected the second story to run the full suite, got %q", reason)
+	}
+	doc.UserStories[1].Passes = true
+	if reason := manager.fullSuiteReason(doc, "US-003"); reason != "" {
+		t.Fatalf("expected the third story to be scoped, got %q", reason)
+	}
+	doc.UserStories[2].Passes = true
+	if reason := manager.fullSuiteReason(doc, "US-004"); reason != "last story" {
+		t.Fatalf("expected the last story to run the full suite, got %q", reason)
+	}
+}
+
+func TestCoverageGateOnlyJudgesFullSuiteRuns(t *testing.T) {
+	t.Parallel()
+
+	var checks []quality.Check
+	var refs []string
+	manager, baseDir := newScopeTestManager(t, []string{"US-001", "US-002"}, &checks, &refs)
+	manager.SetCoverageGate(quality.CoverageGate{Profiles: []string{"cover.out"}, Min: 90, MaxDrop: 1})
+	// A scoped run leaves a profile of the changed packages only.
+	writeCoverProfile(t, baseDir, 40)
+
+	state := runState{}
+	manager.captureCoverageBaseline(baseDir, "main", baseDir, &state)
+	if state.CoverageBaseline != nil {
+		t.Fatalf("expected no baseline from a possibly partial profile, got %+v", state.CoverageBaseline)
+	}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("expected the scoped run to skip the coverage gate, got %v", err)
+	}
+	if recorded := loadRecordedCoverage(baseDir, "main"); recorded != nil {
+		t.Fatalf("expected a scoped run not to record coverage, got %+v", recorded)
+	}
+
+	// US-002 is the last story, so it runs the full suite, which rewrites the
+	// profile, and is gated.
+	manager.qualityChecker = profileChecker{covered: 40}
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err == nil {
+		t.Fatal("expected the full suite to apply the coverage gate")
+	}
+	progress, err := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read progress: %v", err)
+	}
+	if !strings.Contains(string(progress), "below the minimum of 90.00%") {
+		t.Fatalf("expected the coverage failure in progress, got:\n%s", progress)
+	}
+}
@@ -0,0 +1,42 @@
+package loop
+
+import (
+	"errors"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/secrets"
+)
+
+// appendSecretFindings records the findings of a commit blocked by the secret
+// scanner. Findings carry only redacted secrets, so the event log does not
+// repeat what it is guarding.
+func appendSecretFindings(workDir, name, storyID string, iteration int, err error) {
+	var blocked *secrets.BlockedError
+	if !errors.As(err, &blocked) {
+		return
+	}
+	findings := make([]map[string]interface{}, 0, len(blocked.Findings))
+	for _, finding := range blocked.Findings {
+		entry := map[string]interface{}{
+			"path":        finding.Path,
+			"rule":        finding.RuleID,
+			"description": finding.Description,
+			"match":       finding.Redacted,
+		}
+		if finding.Line > 0 {
+			entry["line"] = finding.Line
+		}
+		findings = append(findings, entry)
+	}
+	_ = appendEventPayload(workDir, name, map[string]interface{}{
+		"type":      string(providers.EventError),
+		"message":   blocked.Error(),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"iteration": iteration,
+		"storyID":   storyID,
+		"phase":     "secrets",
+		"findings":  findings,
+	})
+	_ = appendAgentLog(workDir, name, "[secrets] "+blocked.Error()+"\n")
+}
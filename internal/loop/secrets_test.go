@@ -0,0 +1,47 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/quality"
+	"github.com/EstebanForge/daedalus/internal/secrets"
+)
+
+func TestRunOnceRecordsRedactedSecretFindings(t *testing.T) {
+	t.Parallel()
+
+	manager, _, baseDir := newTestManager(t, fakeProvider{}, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.committer = fakeCommitter{err: &secrets.BlockedError{Findings: []secrets.Finding{
+		{Path: ".env", RuleID: secrets.SensitiveFileRule, Description: "sensitive file", Redacted: ".env"},
+		{Path: "main.go", Line: 3, RuleID: "github-token", Description: "GitHub token", Redacted: "ghp_****"},
+	}}}
+
+	err := manager.RunOnce(context.Background(), "main", baseDir, baseDir)
+	if err == nil || !strings.Contains(err.Error(), "commit blocked") {
+		t.Fatalf("expected the blocked commit to fail the story, got %v", err)
+	}
+	events, readErr := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if readErr != nil {
+		t.Fatalf("read events: %v", readErr)
+	}
+	for _, expected := range []string{
+		`"phase":"secrets"`,
+		`{"description":"GitHub token","line":3,"match":"ghp_****","path":"main.go","rule":"github-token"}`,
+		`{"description":"sensitive file","match":".env","path":".env","rule":"sensitive-file"}`,
+	} {
+		if !strings.Contains(string(events), expected) {
+			t.Fatalf("expected events to contain %s, got:\n%s", expected, events)
+		}
+	}
+	progress, readErr := os.ReadFile(project.PRDProgressPath(baseDir, "main"))
+	if readErr != nil {
+		t.Fatalf("read progress: %v", readErr)
+	}
+	if !strings.Contains(string(progress), "main.go:3: GitHub token (ghp_****)") {
+		t.Fatalf("expected the report in progress, got:\n%s", progress)
+	}
+}
@@ -0,0 +1,60 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+// PhaseTimeouts bounds the wall-clock time of one provider turn per phase.
+// Remediation and repair turns count as work. Zero disables a limit.
+type PhaseTimeouts struct {
+	Plan   time.Duration
+	Work   time.Duration
+	Review time.Duration
+}
+
+// SetTimeouts sets the per-phase turn timeouts. When a turn runs out of time
+// its ACP session is cancelled and the turn fails with a timeout error, which
+// the retry policy treats like any other timeout.
+func (m *Manager) SetTimeouts(timeouts PhaseTimeouts) {
+	m.timeouts = timeouts
+}
+
+func (m Manager) phaseTimeout(phase string) time.Duration {
+	switch phase {
+	case "plan":
+		return m.timeouts.Plan
+	case "review", "re-review":
+		return m.timeouts.Review
+	default:
+		return m.timeouts.Work
+	}
+}
+
+// withPhaseTimeout derives a context that expires after the phase's timeout.
+// The returned duration is zero when the phase has no limit.
+func (m Manager) withPhaseTimeout(ctx context.Context, phase string) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := m.phaseTimeout(phase)
+	if timeout <= 0 {
+		return ctx, func() {}, 0
+	}
+	turnCtx, cancel := context.WithTimeout(ctx, timeout)
+	return turnCtx, cancel, timeout
+}
+
+// phaseTimeoutError returns a timeout error when turnCtx ran out of time while
+// the parent context is still live, and nil otherwise. It does not wrap
+// context.DeadlineExceeded, so a timed-out turn does not end a drain run.
+func phaseTimeoutError(parent, turnCtx context.Context, phase string, timeout time.Duration) error {
+	if timeout <= 0 || parent.Err() != nil || !errors.Is(turnCtx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	return providers.ProviderError{
+		Category: providers.ErrorTimeout,
+		Message:  fmt.Sprintf("%s turn timed out after %s", phase, timeout),
+	}
+}
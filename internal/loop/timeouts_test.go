@@ -0,0 +1,69 @@
+package loop
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+func TestRunOnceRetriesWorkTurnThatTimesOut(t *testing.T) {
+	t.Cleanup(providers.CloseAllSessions)
+
+	provider := providers.NewMockProvider(providers.MockScenario{
+		Name: "slow-then-done",
+		Prompts: []providers.MockPrompt{
+			{Match: "Implement only this active story", Delay: "30s", Response: "too late"},
+			{Match: "Implement only this active story", Response: "Implemented on retry"},
+		},
+	})
+
+	manager, store, baseDir := newTestManager(t, provider, fakeChecker{report: quality.Report{Passed: true}}, RetryPolicy{MaxRetries: 1, Delays: []time.Duration{0}})
+	manager.SetTimeouts(PhaseTimeouts{Work: 500 * time.Millisecond})
+
+	started := time.Now()
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 20*time.Second {
+		t.Fatalf("expected the slow turn to be cut short, took %s", elapsed)
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if !doc.UserStories[0].Passes {
+		t.Fatal("expected story to pass after the retry")
+	}
+	events, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if !strings.Contains(string(events), "work turn timed out after 500ms") {
+		t.Fatalf("expected a timeout error event, got:\n%s", events)
+	}
+}
+
+func TestPhaseTimeoutErrorIgnoresCancelledParent(t *testing.T) {
+	t.Parallel()
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	turnCtx, cancelTurn := context.WithTimeout(parent, time.Nanosecond)
+	defer cancelTurn()
+	<-turnCtx.Done()
+
+	err := phaseTimeoutError(parent, turnCtx, "review", time.Nanosecond)
+	if !providers.IsRetryable(err) || !strings.Contains(err.Error(), "review turn timed out") {
+		t.Fatalf("expected a retryable timeout error, got %v", err)
+	}
+	cancelParent()
+	if err := phaseTimeoutError(parent, turnCtx, "review", time.Nanosecond); err != nil {
+		t.Fatalf("expected no timeout error once the run is cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DrainOptions controls RunUntilDone.
+type DrainOptions struct {
+	// MaxStories caps the number of stories attempted. Zero means no limit.
+	MaxStories int
+	// ContinueOnFailure keeps draining after a story fails instead of stopping.
+	ContinueOnFailure bool
+	// ShouldStop is polled between stories. Returning true ends the run cleanly
+	// before the next story starts; the story in flight is never interrupted.
+	ShouldStop func() bool
+	// OnStoryFinished is called after each attempted story with its outcome.
+	OnStoryFinished func(outcome StoryOutcome)
+	// Parallel is the number of stories run at once, each in its own worktree.
+	// Values below two run stories one after another in workDir.
+	Parallel int
+}
+
+// StoryOutcome is the result of a single story attempted during a drain run.
+type StoryOutcome struct {
+	StoryID string
+	Passed  bool
+	Err     error
+}
+
+// DrainSummary reports what a RunUntilDone call did with each story.
+type DrainSummary struct {
+	Passed  []string
+	Failed  []StoryOutcome
+	Skipped []string
+	// Stopped is true when ShouldStop ended the run early.
+	Stopped bool
+}
+
+// Attempted returns the number of stories that were run.
+func (s DrainSummary) Attempted() int {
+	return len(s.Passed) + len(s.Failed)
+}
+
+// String renders the summary as a short, human-readable report.
+func (s DrainSummary) String() string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "Passed: %d\n", len(s.Passed))
+	for _, id := range s.Passed {
+		builder.WriteString("  - ")
+		builder.WriteString(id)
+		builder.WriteString("\n")
+	}
+	fmt.Fprintf(&builder, "Failed: %d\n", len(s.Failed))
+	for _, outcome := range s.Failed {
+		builder.WriteString("  - ")
+		builder.WriteString(outcome.StoryID)
+		if outcome.Err != nil {
+			builder.WriteString(": ")
+			builder.WriteString(outcome.Err.Error())
+		}
+		builder.WriteString("\n")
+	}
+	fmt.Fprintf(&builder, "Skipped: %d\n", len(s.Skipped))
+	for _, id := range s.Skipped {
+		builder.WriteString("  - ")
+		builder.WriteString(id)
+		builder.WriteString("\n")
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// RunUntilDone runs stories one after another until the PRD has no runnable
+// stories left, MaxStories is reached, a story fails (unless ContinueOnFailure
+// is set), or ShouldStop asks the run to end. Stories that were not attempted
+// are reported as skipped.
+//
+// The returned error is the first story failure when the run stopped because
+// of it, or a context error when the run was cancelled.
+func (m Manager) RunUntilDone(ctx context.Context, name, artifactDir, workDir string, opts DrainOptions) (DrainSummary, error) {
+	if opts.Parallel > 1 {
+		return m.runParallel(ctx, name, artifactDir, workDir, opts)
+	}
+
+	summary := DrainSummary{}
+	failed := make(map[string]struct{})
+	var runErr error
+
+	for {
+		if opts.MaxStories > 0 && summary.Attempted() >= opts.MaxStories {
+			break
+		}
+		if opts.ShouldStop != nil && opts.ShouldStop() {
+			summary.Stopped = true
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+
+		storyID, err := m.runNextStory(ctx, name, artifactDir, workDir, failed)
+		if storyID == "" {
+			if err != nil {
+				runErr = err
+			}
+			break
+		}
+
+		outcome := StoryOutcome{StoryID: storyID, Passed: err == nil, Err: err}
+		if opts.OnStoryFinished != nil {
+			opts.OnStoryFinished(outcome)
+		}
+		if err == nil {
+			summary.Passed = append(summary.Passed, storyID)
+			continue
+		}
+
+		summary.Failed = append(summary.Failed, outcome)
+		failed[storyID] = struct{}{}
+		if stopsRun(err) || !opts.ContinueOnFailure {
+			runErr = err
+			break
+		}
+	}
+
+	skipped, err := m.pendingStories(name, summary)
+	if err != nil && runErr == nil {
+		runErr = err
+	}
+	summary.Skipped = skipped
+	return summary, runErr
+}
+
+// pendingStories lists stories that have not passed and were not attempted in
+// this run, in document order.
+func (m Manager) pendingStories(name string, summary DrainSummary) ([]string, error) {
+	doc, err := m.store.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	attempted := make(map[string]struct{}, summary.Attempted())
+	for _, id := range summary.Passed {
+		attempted[id] = struct{}{}
+	}
+	for _, outcome := range summary.Failed {
+		attempted[outcome.StoryID] = struct{}{}
+	}
+
+	var skipped []string
+	for _, story := range doc.UserStories {
+		if story.Passes {
+			continue
+		}
+		if _, ok := attempted[story.ID]; ok {
+			continue
+		}
+		skipped = append(skipped, story.ID)
+	}
+	return skipped, nil
+}
@@ -0,0 +1,144 @@
+package loop
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	daedalusgit "github.com/EstebanForge/daedalus/internal/git"
+	"github.com/EstebanForge/daedalus/internal/prd"
+	"github.com/EstebanForge/daedalus/internal/quality"
+)
+
+type storyFailingCommitter struct {
+	failStoryID string
+}
+
+func (c storyFailingCommitter) CommitStory(_ context.Context, _ string, story daedalusgit.StoryCommit) (daedalusgit.CommitResult, error) {
+	if story.StoryID == c.failStoryID {
+		return daedalusgit.CommitResult{}, fmt.Errorf("commit rejected for %s", story.StoryID)
+	}
+	return daedalusgit.CommitResult{Committed: true, CommitSHA: "abc123"}, nil
+}
+
+func newDrainStore(t *testing.T, baseDir string, storyIDs ...string) prd.Store {
+	t.Helper()
+
+	store := prd.NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+	doc := prd.Document{Project: "demo", Description: "demo"}
+	for i, id := range storyIDs {
+		doc.UserStories = append(doc.UserStories, prd.UserStory{
+			ID:                 id,
+			Title:              "Story " + id,
+			Description:        "desc",
+			AcceptanceCriteria: []string{"works"},
+			Priority:           i + 1,
+		})
+	}
+	if err := store.Save("main", doc); err != nil {
+		t.Fatalf("save PRD: %v", err)
+	}
+	return store
+}
+
+func newDrainManager(store prd.Store, commit committer) Manager {
+	manager := newStoreManager(store, fakeProvider{}, fakeChecker{report: quality.Report{Passed: true}}, noRetries)
+	manager.committer = commit
+	return manager
+}
+
+func TestRunUntilDoneDrainsAllStories(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002", "US-003")
+	manager := newDrainManager(store, storyFailingCommitter{})
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if len(summary.Passed) != 3 || len(summary.Failed) != 0 || len(summary.Skipped) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	doc, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if doc.CountComplete() != 3 {
+		t.Fatalf("expected all stories passed, got %d", doc.CountComplete())
+	}
+}
+
+func TestRunUntilDoneStopsOnFirstFailure(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002", "US-003")
+	manager := newDrainManager(store, storyFailingCommitter{failStoryID: "US-002"})
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{})
+	if err == nil {
+		t.Fatal("expected failure error")
+	}
+	if len(summary.Passed) != 1 || summary.Passed[0] != "US-001" {
+		t.Fatalf("expected US-001 to pass, got %+v", summary.Passed)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].StoryID != "US-002" {
+		t.Fatalf("expected US-002 to fail, got %+v", summary.Failed)
+	}
+	if len(summary.Skipped) != 1 || summary.Skipped[0] != "US-003" {
+		t.Fatalf("expected US-003 to be skipped, got %+v", summary.Skipped)
+	}
+}
+
+func TestRunUntilDoneContinuesOnFailureWhenConfigured(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002", "US-003")
+	manager := newDrainManager(store, storyFailingCommitter{failStoryID: "US-002"})
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{ContinueOnFailure: true})
+	if err != nil {
+		t.Fatalf("expected no run error when continuing past failures, got %v", err)
+	}
+	if len(summary.Passed) != 2 || len(summary.Failed) != 1 || len(summary.Skipped) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestRunUntilDoneHonoursMaxStoriesAndStopRequests(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002", "US-003")
+	manager := newDrainManager(store, storyFailingCommitter{})
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{MaxStories: 1})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if len(summary.Passed) != 1 || len(summary.Skipped) != 2 {
+		t.Fatalf("expected one story run and two skipped, got %+v", summary)
+	}
+
+	finished := 0
+	summary, err = manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{
+		ShouldStop:      func() bool { return finished >= 1 },
+		OnStoryFinished: func(StoryOutcome) { finished++ },
+	})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if !summary.Stopped {
+		t.Fatal("expected drain to report a stop request")
+	}
+	if len(summary.Passed) != 1 || len(summary.Skipped) != 1 {
+		t.Fatalf("expected stop between stories, got %+v", summary)
+	}
+}
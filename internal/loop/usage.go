@@ -0,0 +1,241 @@
+package loop
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+// ErrBudgetExceeded is returned once a PRD's recorded cost reaches the
+// configured budget. No further stories are started.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// usageBudget tracks what each PRD has cost so far. It is shared by every
+// copy of the Manager, so parallel stories count against one budget.
+type usageBudget struct {
+	mu     sync.Mutex
+	maxUSD float64
+	// spent holds the cost per PRD, loaded from events.jsonl on first use.
+	spent map[string]float64
+}
+
+// SetBudget stops the loop once a PRD's recorded cost reaches maxUSD. Costs
+// from earlier runs, read from events.jsonl, count toward it. Zero disables
+// the budget.
+func (m *Manager) SetBudget(maxUSD float64) {
+	if maxUSD <= 0 {
+		m.budget = nil
+		return
+	}
+	m.budget = &usageBudget{maxUSD: maxUSD, spent: map[string]float64{}}
+}
+
+// checkBudget returns ErrBudgetExceeded when the PRD has used up its budget.
+func (m Manager) checkBudget(artifactDir, name string) error {
+	if m.budget == nil {
+		return nil
+	}
+	spent, err := m.budget.load(artifactDir, name)
+	if err != nil {
+		return err
+	}
+	if spent >= m.budget.maxUSD {
+		return fmt.Errorf("%w: spent $%.4f of $%.2f", ErrBudgetExceeded, spent, m.budget.maxUSD)
+	}
+	return nil
+}
+
+func (b *usageBudget) load(artifactDir, name string) (float64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if spent, ok := b.spent[name]; ok {
+		return spent, nil
+	}
+	report, err := LoadUsageReport(artifactDir, name)
+	if err != nil {
+		return 0, err
+	}
+	b.spent[name] = report.Total.Usage.CostUSD
+	return b.spent[name], nil
+}
+
+// add counts cost against a PRD whose spend has already been loaded.
+func (b *usageBudget) add(name string, cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.spent[name]; ok {
+		b.spent[name] += cost
+	}
+}
+
+// usageRecord is one usage event: who ran the iteration and what it cost.
+type usageRecord struct {
+	StoryID     string
+	Phase       string
+	Perspective string
+	Provider    string
+	Model       string
+	Iteration   int
+	Usage       providers.Usage
+}
+
+// recordUsage appends a usage event and counts its cost against the budget.
+func (m Manager) recordUsage(artifactDir, name string, record usageRecord) {
+	if m.budget != nil {
+		// Load earlier spend first, so the new event is counted once.
+		_, _ = m.budget.load(artifactDir, name)
+	}
+	_ = appendUsageEvent(artifactDir, name, record)
+	if m.budget != nil {
+		m.budget.add(name, record.Usage.CostUSD)
+	}
+}
+
+func appendUsageEvent(workDir, name string, record usageRecord) error {
+	usage := record.Usage
+	payload := map[string]interface{}{
+		"type":          string(providers.EventUsage),
+		"message":       formatUsage(usage),
+		"timestamp":     time.Now().UTC().Format(time.RFC3339),
+		"iteration":     record.Iteration,
+		"storyID":       record.StoryID,
+		"phase":         record.Phase,
+		"provider":      record.Provider,
+		"model":         record.Model,
+		"inputTokens":   usage.InputTokens,
+		"outputTokens":  usage.OutputTokens,
+		"costUSD":       usage.CostUSD,
+		"estimated":     usage.Estimated,
+		"costEstimated": usage.CostEstimated,
+	}
+	if record.Perspective != "" {
+		payload["perspective"] = record.Perspective
+	}
+	return appendEventPayload(workDir, name, payload)
+}
+
+// formatUsage renders usage as "1200 in / 300 out tokens, $0.0123", marking
+// estimated values with "~".
+func formatUsage(usage providers.Usage) string {
+	tokens := fmt.Sprintf("%d in / %d out tokens", usage.InputTokens, usage.OutputTokens)
+	if usage.Estimated {
+		tokens = "~" + tokens
+	}
+	cost := fmt.Sprintf("$%.4f", usage.CostUSD)
+	if usage.CostEstimated {
+		cost = "~" + cost
+	}
+	return tokens + ", " + cost
+}
+
+// UsageTotal is the usage of a group of iterations.
+type UsageTotal struct {
+	Key        string
+	Iterations int
+	Usage      providers.Usage
+}
+
+func (t *UsageTotal) add(usage providers.Usage) {
+	t.Iterations++
+	t.Usage.Add(usage)
+}
+
+// UsageReport sums a PRD's usage events per story and per provider.
+type UsageReport struct {
+	Total      UsageTotal
+	ByStory    []UsageTotal
+	ByProvider []UsageTotal
+}
+
+// LoadUsageReport reads the usage events of the PRD's events.jsonl. A missing
+// file is an empty report.
+func LoadUsageReport(artifactDir, name string) (UsageReport, error) {
+	file, err := os.Open(project.PRDEventsPath(artifactDir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return UsageReport{Total: UsageTotal{Key: "total"}}, nil
+		}
+		return UsageReport{}, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	report := UsageReport{Total: UsageTotal{Key: "total"}}
+	stories := map[string]*UsageTotal{}
+	providerTotals := map[string]*UsageTotal{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !strings.Contains(string(line), `"type":"usage"`) {
+			continue
+		}
+		var entry struct {
+			StoryID       string  `json:"storyID"`
+			Provider      string  `json:"provider"`
+			InputTokens   int     `json:"inputTokens"`
+			OutputTokens  int     `json:"outputTokens"`
+			CostUSD       float64 `json:"costUSD"`
+			Estimated     bool    `json:"estimated"`
+			CostEstimated bool    `json:"costEstimated"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		usage := providers.Usage{
+			InputTokens:   entry.InputTokens,
+			OutputTokens:  entry.OutputTokens,
+			CostUSD:       entry.CostUSD,
+			Estimated:     entry.Estimated,
+			CostEstimated: entry.CostEstimated,
+		}
+		report.Total.add(usage)
+		usageTotalFor(stories, entry.StoryID).add(usage)
+		usageTotalFor(providerTotals, entry.Provider).add(usage)
+	}
+	if err := scanner.Err(); err != nil {
+		return UsageReport{}, err
+	}
+	report.ByStory = sortedUsageTotals(stories)
+	report.ByProvider = sortedUsageTotals(providerTotals)
+	return report, nil
+}
+
+func usageTotalFor(totals map[string]*UsageTotal, key string) *UsageTotal {
+	if strings.TrimSpace(key) == "" {
+		key = "(none)"
+	}
+	total, ok := totals[key]
+	if !ok {
+		total = &UsageTotal{Key: key}
+		totals[key] = total
+	}
+	return total
+}
+
+func sortedUsageTotals(totals map[string]*UsageTotal) []UsageTotal {
+	sorted := make([]UsageTotal, 0, len(totals))
+	for _, total := range totals {
+		sorted = append(sorted, *total)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+// stopsRun reports whether err should end a drain run even when
+// ContinueOnFailure is set.
+func stopsRun(err error) bool {
+	return isContextError(err) || errors.Is(err, ErrBudgetExceeded)
+}
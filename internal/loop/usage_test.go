@@ -0,0 +1,77 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+func usageEvents(cost float64) []providers.Event {
+	return []providers.Event{
+		{Type: providers.EventAssistantText, Message: "done"},
+		{Type: providers.EventUsage, Message: providers.EncodeEventUsage(providers.Usage{InputTokens: 1000, OutputTokens: 200, CostUSD: cost})},
+	}
+}
+
+func TestRunOnceRecordsUsagePerIteration(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001")
+	manager := newDrainManager(store, storyFailingCommitter{})
+	manager.provider = fakeProvider{events: usageEvents(0.05)}
+
+	if err := manager.RunOnce(context.Background(), "main", baseDir, baseDir); err != nil {
+		t.Fatalf("run once: %v", err)
+	}
+
+	eventsData, err := os.ReadFile(project.PRDEventsPath(baseDir, "main"))
+	if err != nil {
+		t.Fatalf("read events.jsonl: %v", err)
+	}
+	if strings.Count(string(eventsData), `"type":"usage"`) != 1 || !strings.Contains(string(eventsData), `"provider":"fake"`) || !strings.Contains(string(eventsData), `"phase":"work"`) {
+		t.Fatalf("expected one enriched usage event, got: %s", eventsData)
+	}
+
+	report, err := LoadUsageReport(baseDir, "main")
+	if err != nil {
+		t.Fatalf("load usage: %v", err)
+	}
+	if report.Total.Iterations != 1 || report.Total.Usage.TotalTokens() != 1200 || math.Abs(report.Total.Usage.CostUSD-0.05) > 1e-9 {
+		t.Fatalf("unexpected total: %+v", report.Total)
+	}
+	if len(report.ByStory) != 1 || report.ByStory[0].Key != "US-001" || len(report.ByProvider) != 1 || report.ByProvider[0].Key != "fake" {
+		t.Fatalf("unexpected breakdown: %+v", report)
+	}
+}
+
+func TestRunUntilDoneStopsWhenBudgetIsExceeded(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := newDrainStore(t, baseDir, "US-001", "US-002", "US-003")
+	manager := newDrainManager(store, storyFailingCommitter{})
+	manager.provider = fakeProvider{events: usageEvents(0.6)}
+	manager.SetBudget(1.0)
+
+	summary, err := manager.RunUntilDone(context.Background(), "main", baseDir, baseDir, DrainOptions{ContinueOnFailure: true})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if len(summary.Passed) != 1 || len(summary.Failed) != 1 || len(summary.Skipped) != 1 {
+		t.Fatalf("expected the second story to hit the budget and the third to be skipped, got %+v", summary)
+	}
+
+	// A new run counts the spend recorded by earlier runs.
+	fresh := newDrainManager(store, storyFailingCommitter{})
+	fresh.SetBudget(1.0)
+	if _, err := fresh.runNextStory(context.Background(), "main", baseDir, baseDir, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected earlier spend to count toward the budget, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package prd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Commit types a story may declare in its type field.
+const (
+	CommitTypeFeat     = "feat"
+	CommitTypeFix      = "fix"
+	CommitTypeRefactor = "refactor"
+	CommitTypeTest     = "test"
+)
+
+// commitTypeKeywords maps title words to commit types. Types are checked in
+// order, so "Fix flaky tests" is a fix rather than a test.
+var commitTypeKeywords = []struct {
+	commitType string
+	words      []string
+}{
+	{CommitTypeFix, []string{"fix", "fixes", "fixed", "bug", "bugfix", "regression", "crash", "broken"}},
+	{CommitTypeRefactor, []string{"refactor", "refactoring", "restructure", "cleanup", "simplify", "extract", "rename"}},
+	{CommitTypeTest, []string{"test", "tests", "testing", "coverage"}},
+}
+
+// IsCommitType reports whether value is a commit type a story may declare.
+func IsCommitType(value string) bool {
+	switch value {
+	case CommitTypeFeat, CommitTypeFix, CommitTypeRefactor, CommitTypeTest:
+		return true
+	}
+	return false
+}
+
+// CommitType returns the conventional commit type for the story: its type
+// field when set, otherwise one detected from words in its title, defaulting
+// to feat.
+func (s UserStory) CommitType() string {
+	if declared := strings.ToLower(strings.TrimSpace(s.Type)); declared != "" {
+		return declared
+	}
+	words := map[string]struct{}{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(s.Title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[word] = struct{}{}
+	}
+	for _, candidate := range commitTypeKeywords {
+		for _, word := range candidate.words {
+			if _, ok := words[word]; ok {
+				return candidate.commitType
+			}
+		}
+	}
+	return CommitTypeFeat
+}
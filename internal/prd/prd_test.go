@@ -1,7 +1,10 @@
 package prd
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/EstebanForge/daedalus/internal/project"
@@ -76,3 +79,161 @@ func TestStoreCreateCreatesRuntimeArtifacts(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateRejectsUnknownDependenciesAndCycles(t *testing.T) {
+	t.Parallel()
+
+	story := func(id string, priority int, deps ...string) UserStory {
+		return UserStory{
+			ID:                 id,
+			Title:              id,
+			Description:        id,
+			AcceptanceCriteria: []string{"a"},
+			Priority:           priority,
+			DependsOn:          deps,
+		}
+	}
+
+	unknown := Validate(Document{Project: "demo", UserStories: []UserStory{
+		story("US-001", 1, "US-404"),
+	}})
+	if unknown.Valid() || !strings.Contains(strings.Join(unknown.Errors, "\n"), "unknown dependency US-404") {
+		t.Fatalf("expected unknown dependency error, got %v", unknown.Errors)
+	}
+
+	cyclic := Validate(Document{Project: "demo", UserStories: []UserStory{
+		story("US-001", 1, "US-003"),
+		story("US-002", 2, "US-001"),
+		story("US-003", 3, "US-002"),
+	}})
+	if cyclic.Valid() || !strings.Contains(strings.Join(cyclic.Errors, "\n"), "dependency cycle") {
+		t.Fatalf("expected dependency cycle error, got %v", cyclic.Errors)
+	}
+
+	acyclic := Validate(Document{Project: "demo", UserStories: []UserStory{
+		story("US-001", 1),
+		story("US-002", 2, "US-001"),
+		story("US-003", 3, "US-001", "US-002"),
+	}})
+	if !acyclic.Valid() {
+		t.Fatalf("expected valid document, got %v", acyclic.Errors)
+	}
+}
+
+func TestNextStoryWaitsForDependencies(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{
+		Project: "demo",
+		UserStories: []UserStory{
+			{ID: "US-001", Title: "one", Priority: 1, DependsOn: []string{"US-002"}},
+			{ID: "US-002", Title: "two", Priority: 2},
+		},
+	}
+
+	next := doc.NextStory()
+	if next == nil || next.ID != "US-002" {
+		t.Fatalf("expected US-002 to run first, got %+v", next)
+	}
+	if blockers := doc.BlockedStories()["US-001"]; len(blockers) != 1 || blockers[0] != "US-002" {
+		t.Fatalf("expected US-001 blocked by US-002, got %v", blockers)
+	}
+
+	doc.UserStories[1].Passes = true
+	next = doc.NextStory()
+	if next == nil || next.ID != "US-001" {
+		t.Fatalf("expected US-001 once dependency passed, got %+v", next)
+	}
+	if len(doc.BlockedStories()) != 0 {
+		t.Fatalf("expected no blocked stories, got %v", doc.BlockedStories())
+	}
+}
+
+func TestStoreUpdateIsSafeForConcurrentWriters(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+	store := NewStore(baseDir)
+	if err := store.Create("main"); err != nil {
+		t.Fatalf("create PRD: %v", err)
+	}
+
+	const storyCount = 16
+	doc := Document{Project: "demo", Description: "demo"}
+	for i := 0; i < storyCount; i++ {
+		doc.UserStories = append(doc.UserStories, UserStory{
+			ID:       fmt.Sprintf("US-%03d", i+1),
+			Title:    "Story",
+			Priority: i + 1,
+		})
+	}
+	if err := store.Save("main", doc); err != nil {
+		t.Fatalf("save PRD: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < storyCount; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			err := store.Update("main", func(current *Document) error {
+				current.UserStories[index].Passes = true
+				return nil
+			})
+			if err != nil {
+				t.Errorf("update story %d: %v", index, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := store.Load("main")
+	if err != nil {
+		t.Fatalf("load PRD: %v", err)
+	}
+	if loaded.CountComplete() != storyCount {
+		t.Fatalf("expected every concurrent update to persist, got %d of %d", loaded.CountComplete(), storyCount)
+	}
+}
+
+func TestCommitTypeUsesDeclaredTypeOrTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		story UserStory
+		want  string
+	}{
+		{story: UserStory{Title: "Add CSV export"}, want: "feat"},
+		{story: UserStory{Title: "Fix crash on empty input"}, want: "fix"},
+		{story: UserStory{Title: "Fix flaky tests"}, want: "fix"},
+		{story: UserStory{Title: "Refactor the session cache"}, want: "refactor"},
+		{story: UserStory{Title: "Raise test coverage of the parser"}, want: "test"},
+		{story: UserStory{Title: "Prefix output with a fixture name"}, want: "feat"},
+		{story: UserStory{Title: "Fix the importer", Type: "Refactor"}, want: "refactor"},
+	}
+	for _, tt := range tests {
+		if got := tt.story.CommitType(); got != tt.want {
+			t.Fatalf("CommitType(%q, type %q) = %q, want %q", tt.story.Title, tt.story.Type, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRejectsUnknownStoryType(t *testing.T) {
+	t.Parallel()
+
+	doc := Document{
+		Project: "demo",
+		UserStories: []UserStory{{
+			ID:                 "US-001",
+			Title:              "One",
+			Description:        "first",
+			AcceptanceCriteria: []string{"a"},
+			Priority:           1,
+			Type:               "chore",
+		}},
+	}
+	result := Validate(doc)
+	if result.Valid() || !strings.Contains(strings.Join(result.Errors, "\n"), "type must be one of") {
+		t.Fatalf("expected a type validation error, got %v", result.Errors)
+	}
+}
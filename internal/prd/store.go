@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/EstebanForge/daedalus/internal/project"
 	"github.com/EstebanForge/daedalus/internal/templates"
@@ -66,15 +67,71 @@ func (s Store) Create(name string) error {
 	return nil
 }
 
+// prdFileLocks serialises writers of the same prd.json within this process.
+// Keys are prd.json paths; values are *sync.Mutex.
+var prdFileLocks sync.Map
+
+func lockPRDFile(path string) func() {
+	value, _ := prdFileLocks.LoadOrStore(filepath.Clean(path), &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// Save replaces prd.json with doc. Writes are atomic, so concurrent readers
+// never observe a partially written file.
 func (s Store) Save(name string, doc Document) error {
+	filePath := project.PRDJSONPath(s.baseDir, name)
+	unlock := lockPRDFile(filePath)
+	defer unlock()
+	return writeDocument(filePath, doc)
+}
+
+// Update loads prd.json, applies mutate, and saves the result while holding
+// the PRD's write lock. Use it instead of Load+Save when other goroutines may
+// be updating the same PRD. If mutate returns an error nothing is written.
+func (s Store) Update(name string, mutate func(doc *Document) error) error {
+	filePath := project.PRDJSONPath(s.baseDir, name)
+	unlock := lockPRDFile(filePath)
+	defer unlock()
+
+	doc, err := s.Load(name)
+	if err != nil {
+		return err
+	}
+	if err := mutate(&doc); err != nil {
+		return err
+	}
+	return writeDocument(filePath, doc)
+}
+
+func writeDocument(filePath string, doc Document) error {
 	data, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal PRD JSON: %w", err)
 	}
 	data = append(data, '\n')
 
-	filePath := project.PRDJSONPath(s.baseDir, name)
-	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+	temp, err := os.CreateTemp(filepath.Dir(filePath), ".prd.json.*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write prd.json: %w", err)
+	}
+	tempPath := temp.Name()
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write prd.json: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write prd.json: %w", err)
+	}
+	if err := os.Chmod(tempPath, 0o644); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to write prd.json: %w", err)
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		_ = os.Remove(tempPath)
 		return fmt.Errorf("failed to write prd.json: %w", err)
 	}
 	return nil
@@ -8,6 +8,10 @@ type UserStory struct {
 	Priority           int      `json:"priority"`
 	Passes             bool     `json:"passes"`
 	InProgress         bool     `json:"inProgress,omitempty"`
+	DependsOn          []string `json:"dependsOn,omitempty"`
+	// Type is the conventional commit type of the story's commit: feat, fix,
+	// refactor, or test. Empty means it is detected from the title.
+	Type string `json:"type,omitempty"`
 }
 
 type Document struct {
@@ -37,8 +41,18 @@ func (d Document) CountInProgress() int {
 }
 
 func (d Document) NextStory() *UserStory {
+	return d.NextStoryExcluding(nil)
+}
+
+// NextStoryExcluding behaves like NextStory but never returns a story whose ID
+// is in excluded. Drain runs use it to move past stories that already failed.
+// Only stories whose dependencies have all passed are considered.
+func (d Document) NextStoryExcluding(excluded map[string]struct{}) *UserStory {
 	for i := range d.UserStories {
-		if d.UserStories[i].InProgress {
+		if _, skip := excluded[d.UserStories[i].ID]; skip {
+			continue
+		}
+		if d.UserStories[i].InProgress && len(d.BlockedBy(d.UserStories[i])) == 0 {
 			return &d.UserStories[i]
 		}
 	}
@@ -49,9 +63,50 @@ func (d Document) NextStory() *UserStory {
 		if story.Passes {
 			continue
 		}
+		if _, skip := excluded[story.ID]; skip {
+			continue
+		}
+		if len(d.BlockedBy(*story)) > 0 {
+			continue
+		}
 		if next == nil || story.Priority < next.Priority {
 			next = story
 		}
 	}
 	return next
 }
+
+// BlockedBy returns the IDs of the story's dependencies that have not passed
+// yet, in declaration order. Unknown dependency IDs are reported as blocking.
+func (d Document) BlockedBy(story UserStory) []string {
+	if len(story.DependsOn) == 0 {
+		return nil
+	}
+	passed := make(map[string]bool, len(d.UserStories))
+	for _, candidate := range d.UserStories {
+		passed[candidate.ID] = candidate.Passes
+	}
+
+	var blockers []string
+	for _, dependency := range story.DependsOn {
+		if !passed[dependency] {
+			blockers = append(blockers, dependency)
+		}
+	}
+	return blockers
+}
+
+// BlockedStories returns the pending stories that cannot run yet, keyed by
+// story ID, with the dependencies blocking each one.
+func (d Document) BlockedStories() map[string][]string {
+	blocked := make(map[string][]string)
+	for _, story := range d.UserStories {
+		if story.Passes {
+			continue
+		}
+		if blockers := d.BlockedBy(story); len(blockers) > 0 {
+			blocked[story.ID] = blockers
+		}
+	}
+	return blocked
+}
@@ -43,6 +43,9 @@ func Validate(doc Document) ValidationResult {
 		if len(story.AcceptanceCriteria) == 0 {
 			result.Errors = append(result.Errors, prefix+": acceptanceCriteria must not be empty")
 		}
+		if story.Type != "" && !IsCommitType(strings.ToLower(strings.TrimSpace(story.Type))) {
+			result.Errors = append(result.Errors, prefix+": type must be one of feat, fix, refactor, test")
+		}
 
 		if _, exists := ids[story.ID]; exists {
 			result.Errors = append(result.Errors, prefix+": duplicate id "+story.ID)
@@ -55,5 +58,80 @@ func Validate(doc Document) ValidationResult {
 		priorities[story.Priority] = struct{}{}
 	}
 
+	for i, story := range doc.UserStories {
+		prefix := fmt.Sprintf("userStories[%d]", i)
+		for _, dependency := range story.DependsOn {
+			if dependency == story.ID {
+				result.Errors = append(result.Errors, prefix+": story cannot depend on itself")
+				continue
+			}
+			if _, exists := ids[dependency]; !exists {
+				result.Errors = append(result.Errors, prefix+": unknown dependency "+dependency)
+			}
+		}
+	}
+
+	if cycle := findDependencyCycle(doc); len(cycle) > 0 {
+		result.Errors = append(result.Errors, "dependency cycle: "+strings.Join(cycle, " -> "))
+	}
+
 	return result
 }
+
+// findDependencyCycle returns the story IDs forming the first dependency cycle
+// found, with the starting ID repeated at the end, or nil when the graph is
+// acyclic. Self-references and unknown IDs are reported separately.
+func findDependencyCycle(doc Document) []string {
+	edges := make(map[string][]string, len(doc.UserStories))
+	for _, story := range doc.UserStories {
+		edges[story.ID] = story.DependsOn
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(doc.UserStories))
+	var stack []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dependency := range edges[id] {
+			if dependency == id {
+				continue
+			}
+			if _, known := edges[dependency]; !known {
+				continue
+			}
+			switch state[dependency] {
+			case visiting:
+				for i := range stack {
+					if stack[i] == dependency {
+						cycle := append([]string(nil), stack[i:]...)
+						return append(cycle, dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, story := range doc.UserStories {
+		if state[story.ID] != unvisited {
+			continue
+		}
+		if cycle := visit(story.ID); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
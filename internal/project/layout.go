@@ -44,6 +44,20 @@ func WorktreePath(baseDir, name string) string {
 	return filepath.Join(WorktreesPath(baseDir), name)
 }
 
+// StoryWorktreePath is the per-story worktree used by parallel runs. It sits
+// next to the PRD worktree rather than inside it.
+func StoryWorktreePath(baseDir, prdName, storyID string) string {
+	return filepath.Join(WorktreesPath(baseDir), prdName+"--"+storyID)
+}
+
+const ReviewDirectory = "review"
+
+// ReviewPerspectivesPath is the project directory holding <name>.md review
+// perspective definitions.
+func ReviewPerspectivesPath(baseDir string) string {
+	return filepath.Join(baseDir, DirectoryName, ReviewDirectory)
+}
+
 const OnboardingDirectory = "onboarding"
 const ACPSessionsFile = "acp-sessions.json"
 
@@ -82,3 +96,15 @@ func PRDPlanPath(workDir, name, storyID string) string {
 func PRDLearningsPath(workDir, name string) string {
 	return filepath.Join(PRDPath(workDir, name), "learnings.md")
 }
+
+func PRDRunsDir(workDir, name string) string {
+	return filepath.Join(PRDPath(workDir, name), "runs")
+}
+
+func PRDRunStatePath(workDir, name, storyID string) string {
+	return filepath.Join(PRDRunsDir(workDir, name), storyID+".json")
+}
+
+func PRDCoveragePath(workDir, name string) string {
+	return filepath.Join(PRDPath(workDir, name), "coverage.json")
+}
@@ -42,3 +42,21 @@ func TestPRDLearningsPath(t *testing.T) {
 		t.Fatalf("expected %q, got %q", want, got)
 	}
 }
+
+func TestPRDRunStatePath(t *testing.T) {
+	t.Parallel()
+	got := PRDRunStatePath("/proj", "main", "US-42")
+	want := "/proj/.daedalus/prds/main/runs/US-42.json"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestPRDCoveragePath(t *testing.T) {
+	t.Parallel()
+	got := PRDCoveragePath("/proj", "main")
+	want := "/proj/.daedalus/prds/main/coverage.json"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
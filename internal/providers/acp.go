@@ -20,6 +20,9 @@ import (
 )
 
 const acpInitTimeout = 30 * time.Second
+
+// acpCancelGrace is how long a cancelled prompt turn may take to wind down.
+const acpCancelGrace = 5 * time.Second
 const (
 	acpSessionIdleTimeout = 20 * time.Minute
 	acpSessionMaxAge      = 2 * time.Hour
@@ -30,8 +33,20 @@ type acpProvider struct {
 	cfg         config.GenericProviderConfig
 	providerKey string
 	command     acpCommand
+	// agent, when set, serves sessions in-process instead of spawning command.
+	agent acpAgentFactory
+	// contextBudget caps the context files sent with each prompt.
+	contextBudget ContextBudget
+}
+
+// acpAgent speaks ACP over a pair of streams. Serve returns when in is closed
+// or the agent decides to hang up.
+type acpAgent interface {
+	Serve(in io.Reader, out io.Writer)
 }
 
+type acpAgentFactory func(workDir string) (acpAgent, error)
+
 type acpCommand struct {
 	Binary string
 	Args   []string
@@ -59,8 +74,10 @@ type acpSessionState struct {
 	Stdin       io.WriteCloser
 	ReadResults <-chan acpReadResult
 	StderrLines <-chan string
-	startedAt   time.Time
-	lastUsedAt  time.Time
+	// done is closed when an in-process agent stops serving.
+	done       <-chan struct{}
+	startedAt  time.Time
+	lastUsedAt time.Time
 
 	requestMu sync.Mutex
 	writeMu   sync.Mutex
@@ -70,6 +87,17 @@ type acpSessionState struct {
 	messageID int
 
 	capabilities Capabilities
+
+	// Terminals started for the agent through terminal/create.
+	terminalMu  sync.Mutex
+	terminals   map[string]*acpTerminal
+	terminalSeq int
+
+	// approver answers session/request_permission for the iteration that
+	// holds requestMu.
+	approver Approver
+	// usage is what the agent reported about the current turn so far, or nil.
+	usage *Usage
 }
 
 type acpSessionCache struct {
@@ -110,9 +138,10 @@ func newACPProvider(cfg config.Config, providerKey string) Provider {
 	providerCfg := getProviderConfig(cfg, key)
 
 	return acpProvider{
-		cfg:         providerCfg,
-		providerKey: key,
-		command:     resolveACPCommand(key, providerCfg.ACPCommand),
+		cfg:           providerCfg,
+		providerKey:   key,
+		command:       resolveACPCommand(key, providerCfg.ACPCommand),
+		contextBudget: ContextBudgetFromConfig(cfg.Context),
 	}
 }
 
@@ -133,6 +162,8 @@ func getProviderConfig(cfg config.Config, agent string) config.GenericProviderCo
 		return cfg.Providers.Qwen
 	case "pi":
 		return cfg.Providers.Pi
+	case "mock":
+		return cfg.Providers.Mock
 	default:
 		return cfg.Providers.Codex
 	}
@@ -207,7 +238,7 @@ func (p acpProvider) RunIteration(ctx context.Context, request IterationRequest)
 		return nil, IterationResult{}, NewConfigurationError("acp prompt is required", nil)
 	}
 
-	session, sessionKey, err := p.ensureSession(request.WorkDir)
+	session, sessionKey, err := p.ensureSession(request.WorkDir, request.ResumeSessionID)
 	if err != nil {
 		return nil, IterationResult{}, err
 	}
@@ -223,17 +254,16 @@ func (p acpProvider) RunIteration(ctx context.Context, request IterationRequest)
 
 		session.requestMu.Lock()
 		defer session.requestMu.Unlock()
+		session.approver = request.Approver
+		session.usage = nil
+		defer func() { session.approver = nil }()
 
 		stderrStop := make(chan struct{})
 		go p.forwardStderr(session, events, stderrStop)
 		defer close(stderrStop)
 
-		fullPrompt, promptErr := p.buildPromptWithContext(request.WorkDir, request.Prompt, request.ContextFiles)
-		if promptErr != nil {
-			pushProviderEvent(events, EventError, EncodeEventError(promptErr))
-			pushProviderEvent(events, EventIterationDone, "acp iteration failed")
-			return
-		}
+		embedded := session.getCapabilities().EmbeddedContext
+		prompt := p.buildPromptBlocks(request.WorkDir, request.Prompt, request.ContextFiles, embedded, events)
 
 		var responseText strings.Builder
 		promptReq := acpJSONRPC{
@@ -242,9 +272,7 @@ func (p acpProvider) RunIteration(ctx context.Context, request IterationRequest)
 			Method:  "session/prompt",
 			Params: mustMarshalJSON(acpPromptParams{
 				SessionID: session.ID,
-				Prompt: []acpContentBlock{
-					{Type: "text", Text: fullPrompt},
-				},
+				Prompt:    prompt,
 			}),
 		}
 
@@ -269,6 +297,8 @@ func (p acpProvider) RunIteration(ctx context.Context, request IterationRequest)
 		if resultText := p.extractContentFromPromptResult(resp.Result); resultText != "" {
 			responseText.WriteString(resultText)
 		}
+		session.recordUsage(resp.Result)
+		usage := iterationUsage(session.usage, promptBlocksText(prompt), responseText.String(), p.tokenPrice())
 
 		summary := strings.TrimSpace(responseText.String())
 		if summary == "" {
@@ -278,20 +308,24 @@ func (p acpProvider) RunIteration(ctx context.Context, request IterationRequest)
 		session.markUsed(now)
 		_ = p.savePersistedSession(session.Cwd, sessionKey, session.ID, session.startedAt, now)
 		pushProviderEvent(events, EventAssistantText, summary)
+		pushProviderEvent(events, EventUsage, EncodeEventUsage(usage))
 		pushProviderEvent(events, EventIterationDone, "acp iteration finished")
 	}()
 
-	return events, IterationResult{Success: true}, nil
+	return events, IterationResult{Success: true, ProviderRunID: session.ID}, nil
 }
 
-func (p acpProvider) ensureSession(workDir string) (*acpSessionState, string, error) {
+// ensureSession returns the live session for workDir, or starts one. A new
+// process first tries to resume resumeID, then the session persisted for
+// workDir, and creates a fresh session when neither can be resumed.
+func (p acpProvider) ensureSession(workDir, resumeID string) (*acpSessionState, string, error) {
 	sessionKey := p.sessionKey(workDir)
 
 	acpSessionsMu.RLock()
 	existing := acpSessions[sessionKey]
 	acpSessionsMu.RUnlock()
 	now := time.Now()
-	if existing != nil && isProcessAlive(existing.Cmd) && !existing.isExpired(now) {
+	if existing != nil && existing.isAlive() && !existing.isExpired(now) {
 		existing.markUsed(now)
 		p.saveNegotiatedCapabilities(existing.getCapabilities())
 		_ = p.savePersistedSession(existing.Cwd, sessionKey, existing.ID, existing.startedAt, now)
@@ -313,9 +347,16 @@ func (p acpProvider) ensureSession(workDir string) (*acpSessionState, string, er
 		return nil, "", err
 	}
 
+	var candidates []string
+	if resumeID = strings.TrimSpace(resumeID); resumeID != "" {
+		candidates = append(candidates, resumeID)
+	}
 	persistedSessionID, hasPersisted := p.loadPersistedSession(workDir, sessionKey)
-	if hasPersisted {
-		if err := p.resumeSession(initCtx, session, persistedSessionID); err == nil {
+	if hasPersisted && persistedSessionID != resumeID {
+		candidates = append(candidates, persistedSessionID)
+	}
+	for _, candidate := range candidates {
+		if err := p.resumeSession(initCtx, session, candidate); err == nil {
 			now := time.Now()
 			session.markUsed(now)
 			_ = p.savePersistedSession(workDir, sessionKey, session.ID, session.startedAt, now)
@@ -324,6 +365,8 @@ func (p acpProvider) ensureSession(workDir string) (*acpSessionState, string, er
 			acpSessionsMu.Unlock()
 			return session, sessionKey, nil
 		}
+	}
+	if hasPersisted {
 		_ = p.deletePersistedSession(workDir, sessionKey)
 	}
 
@@ -343,6 +386,9 @@ func (p acpProvider) ensureSession(workDir string) (*acpSessionState, string, er
 }
 
 func (p acpProvider) startSession(workDir string) (*acpSessionState, error) {
+	if p.agent != nil {
+		return p.startInProcessSession(workDir)
+	}
 	resolvedWorkDir := canonicalWorkDir(workDir)
 
 	cmd := exec.Command(p.command.Binary, p.command.Args...)
@@ -390,6 +436,40 @@ func (p acpProvider) startSession(workDir string) (*acpSessionState, error) {
 	}, nil
 }
 
+func (p acpProvider) startInProcessSession(workDir string) (*acpSessionState, error) {
+	resolvedWorkDir := canonicalWorkDir(workDir)
+	agent, err := p.agent(resolvedWorkDir)
+	if err != nil {
+		return nil, err
+	}
+
+	agentIn, clientOut := io.Pipe()
+	clientIn, agentOut := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		agent.Serve(agentIn, agentOut)
+		_ = agentOut.Close()
+		_ = agentIn.Close()
+	}()
+
+	stderrLines := make(chan string)
+	close(stderrLines)
+
+	now := time.Now()
+	return &acpSessionState{
+		Cwd:          resolvedWorkDir,
+		Stdin:        clientOut,
+		ReadResults:  startJSONLineReader(clientIn),
+		StderrLines:  stderrLines,
+		done:         done,
+		startedAt:    now,
+		lastUsedAt:   now,
+		messageID:    1,
+		capabilities: p.defaultCapabilities(),
+	}, nil
+}
+
 func (p acpProvider) initializeTransport(ctx context.Context, session *acpSessionState) error {
 	initReq := acpJSONRPC{
 		JSONRPC: "2.0",
@@ -501,15 +581,42 @@ func (p acpProvider) requestRPC(
 		return acpJSONRPC{}, err
 	}
 
+	// Agent requests that wait on a command are answered in the background,
+	// so the agent's updates, output polls, and kills are still read
+	// meanwhile. They end with this request: cancelling waitCtx kills the
+	// commands they are still waiting on.
+	waitCtx, cancelWaits := context.WithCancel(ctx)
+	var waits sync.WaitGroup
+	defer func() {
+		cancelWaits()
+		waits.Wait()
+	}()
+
 	for {
 		line, err := p.readLine(ctx, session)
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 				p.cancelSession(session, session.ID)
+				if req.Method == "session/prompt" {
+					p.awaitCancelledTurn(session, req.ID)
+				}
 			}
 			return acpJSONRPC{}, err
 		}
 
+		if agentReq, ok := parseAgentRequest(line); ok {
+			if blocksOnCommand(agentReq.Method) {
+				waits.Add(1)
+				go func() {
+					defer waits.Done()
+					p.handleAgentRequest(waitCtx, session, agentReq, events)
+				}()
+				continue
+			}
+			p.handleAgentRequest(ctx, session, agentReq, events)
+			continue
+		}
+
 		var resp acpJSONRPC
 		if unmarshalErr := json.Unmarshal([]byte(line), &resp); unmarshalErr != nil {
 			pushProviderEvent(events, EventCommandOutput, line)
@@ -517,6 +624,7 @@ func (p acpProvider) requestRPC(
 		}
 
 		if resp.Method == "session/update" {
+			session.recordUsage(resp.Params)
 			p.handleSessionUpdate(resp.Params, events, responseText)
 			continue
 		}
@@ -531,8 +639,8 @@ func (p acpProvider) requestRPC(
 	}
 }
 
-func (p acpProvider) sendJSON(session *acpSessionState, req acpJSONRPC) error {
-	data, err := json.Marshal(req)
+func (p acpProvider) sendJSON(session *acpSessionState, message interface{}) error {
+	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
@@ -577,6 +685,36 @@ func (p acpProvider) cancelSession(session *acpSessionState, sessionID string) {
 	_ = p.sendJSON(session, cancelReq)
 }
 
+// awaitCancelledTurn waits up to acpCancelGrace for the agent to end a
+// cancelled prompt turn, so its late updates do not leak into the next turn.
+// Permission requests still arriving are answered as cancelled.
+func (p acpProvider) awaitCancelledTurn(session *acpSessionState, promptID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), acpCancelGrace)
+	defer cancel()
+	for {
+		line, err := p.readLine(ctx, session)
+		if err != nil {
+			return
+		}
+		if agentReq, ok := parseAgentRequest(line); ok {
+			if agentReq.Method == "session/request_permission" {
+				_ = p.sendJSON(session, acpAgentResponse{
+					JSONRPC: "2.0",
+					ID:      agentReq.ID,
+					Result:  mustMarshalJSON(acpPermissionResult{Outcome: acpPermissionOutcome{Outcome: "cancelled"}}),
+				})
+				continue
+			}
+			p.handleAgentRequest(ctx, session, agentReq, nil)
+			continue
+		}
+		var resp acpJSONRPC
+		if json.Unmarshal([]byte(line), &resp) == nil && resp.Method == "" && resp.ID == promptID {
+			return
+		}
+	}
+}
+
 func (p acpProvider) forwardStderr(session *acpSessionState, events chan Event, stop <-chan struct{}) {
 	for {
 		select {
@@ -621,6 +759,34 @@ func (p acpProvider) handleSessionUpdate(params json.RawMessage, events chan Eve
 	}
 }
 
+// recordUsage merges usage reported in a session/update or prompt result into
+// the current turn. Later reports replace the fields they set, since agents
+// report running totals for the turn.
+func (s *acpSessionState) recordUsage(raw json.RawMessage) {
+	reported, ok := parseReportedUsage(raw)
+	if !ok {
+		return
+	}
+	if s.usage == nil {
+		s.usage = &Usage{}
+	}
+	if reported.InputTokens > 0 {
+		s.usage.InputTokens = reported.InputTokens
+	}
+	if reported.OutputTokens > 0 {
+		s.usage.OutputTokens = reported.OutputTokens
+	}
+	if reported.CostUSD > 0 {
+		s.usage.CostUSD = reported.CostUSD
+	}
+}
+
+// tokenPrice returns the prices configured for the provider, used to cost
+// turns the agent did not report a cost for.
+func (p acpProvider) tokenPrice() TokenPrice {
+	return TokenPrice{InputPerMTok: p.cfg.InputUSDPerMTok, OutputPerMTok: p.cfg.OutputUSDPerMTok}
+}
+
 func (p acpProvider) extractContentFromPromptResult(raw json.RawMessage) string {
 	if len(raw) == 0 {
 		return ""
@@ -654,38 +820,16 @@ func extractContentBlocksText(blocks []acpContentBlock) string {
 	return strings.TrimSpace(sb.String())
 }
 
-func (p acpProvider) buildPromptWithContext(workDir, prompt string, paths []string) (string, error) {
-	fullPrompt := prompt
-	if len(paths) == 0 {
-		return fullPrompt, nil
-	}
-	contextContent, err := p.readContextFiles(workDir, paths)
-	if err != nil {
-		return "", err
-	}
-	if strings.TrimSpace(contextContent) == "" {
-		return fullPrompt, nil
+// buildPromptBlocks loads the context files, fits them into the provider's
+// budget, and builds the prompt's content blocks. Skipped and cut files are
+// reported on events.
+func (p acpProvider) buildPromptBlocks(workDir, prompt string, paths []string, embedded bool, events chan Event) []acpContentBlock {
+	files, warnings := loadContextFiles(workDir, paths)
+	warnings = append(warnings, applyContextBudget(files, p.contextBudget)...)
+	for _, warning := range warnings {
+		pushProviderEvent(events, EventContextWarning, warning)
 	}
-	return fmt.Sprintf("%s\n\n--- Context Files ---\n%s", prompt, contextContent), nil
-}
-
-func (p acpProvider) readContextFiles(workDir string, paths []string) (string, error) {
-	var builder strings.Builder
-	for _, path := range paths {
-		resolved := path
-		if !filepath.IsAbs(path) && strings.TrimSpace(workDir) != "" {
-			resolved = filepath.Join(workDir, path)
-		}
-		data, err := os.ReadFile(resolved)
-		if err != nil {
-			continue
-		}
-		builder.WriteString("\n--- ")
-		builder.WriteString(path)
-		builder.WriteString(" ---\n")
-		builder.Write(data)
-	}
-	return builder.String(), nil
+	return buildContextBlocks(prompt, files, embedded)
 }
 
 func (p acpProvider) invalidateSession(sessionKey string, session *acpSessionState) {
@@ -734,6 +878,18 @@ func (s *acpSessionState) getCapabilities() Capabilities {
 	return s.capabilities
 }
 
+func (s *acpSessionState) isAlive() bool {
+	if s.done != nil {
+		select {
+		case <-s.done:
+			return false
+		default:
+			return true
+		}
+	}
+	return isProcessAlive(s.Cmd)
+}
+
 func (s *acpSessionState) isExpired(now time.Time) bool {
 	s.useMu.Lock()
 	lastUsed := s.lastUsedAt
@@ -753,6 +909,7 @@ func closeACPSession(session *acpSessionState) {
 	if session == nil {
 		return
 	}
+	session.releaseAllTerminals()
 	if session.Stdin != nil {
 		_ = session.Stdin.Close()
 	}
@@ -941,6 +1098,17 @@ func parseInitializeCapabilities(raw json.RawMessage, fallback Capabilities) (Ca
 		updated = true
 	}
 
+	// ACP agents advertise embedded resource support under
+	// agentCapabilities.promptCapabilities.
+	promptNode, _ := capabilityNode["promptCapabilities"].(map[string]interface{})
+	if agentNode, ok := root["agentCapabilities"].(map[string]interface{}); ok && promptNode == nil {
+		promptNode, _ = agentNode["promptCapabilities"].(map[string]interface{})
+	}
+	if value, ok := boolFromAny(promptNode["embeddedContext"]); ok {
+		parsed.EmbeddedContext = value
+		updated = true
+	}
+
 	return parsed, updated
 }
 
@@ -1285,6 +1453,19 @@ type acpPromptParams struct {
 type acpContentBlock struct {
 	Type string `json:"type"`
 	Text string `json:"text,omitempty"`
+	// Resource holds the file of a "resource" block.
+	Resource *acpEmbeddedResource `json:"resource,omitempty"`
+	// URI, Name, MimeType, and Size describe a "resource_link" block.
+	URI      string `json:"uri,omitempty"`
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+type acpEmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
 }
 
 type acpSessionParams struct {
@@ -1305,6 +1486,18 @@ type acpPromptResult struct {
 	Message    string            `json:"message,omitempty"`
 	Output     []acpContentBlock `json:"output,omitempty"`
 	Content    []acpContentBlock `json:"content,omitempty"`
+	Usage      *acpUsage         `json:"usage,omitempty"`
+}
+
+type acpUsage struct {
+	InputTokens  int      `json:"inputTokens,omitempty"`
+	OutputTokens int      `json:"outputTokens,omitempty"`
+	Cost         *acpCost `json:"cost,omitempty"`
+}
+
+type acpCost struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
 }
 
 type acpSessionResult struct {
@@ -0,0 +1,525 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client-side handlers for requests the agent sends to Daedalus. Initialize
+// advertises fs and terminal support, so the agent may read and write files and
+// run commands through us instead of doing it itself. Everything is confined to
+// the session work dir.
+
+const (
+	acpErrorMethodNotFound = -32601
+	acpErrorInvalidParams  = -32602
+	acpErrorInternal       = -32603
+
+	acpTerminalDefaultOutputLimit = 1024 * 1024
+	// acpTerminalWaitDelay bounds how long a killed command's children may
+	// keep its output open before the terminal counts as exited.
+	acpTerminalWaitDelay = 5 * time.Second
+	// acpTerminalEventLimit caps the command output copied into events.
+	acpTerminalEventLimit = 4000
+)
+
+// acpAgentRequest is a request from the agent. IDs are kept raw because agents
+// may use strings or start numbering at zero.
+type acpAgentRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type acpAgentResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *acpError       `json:"error,omitempty"`
+}
+
+// parseAgentRequest reports whether line is a request from the agent (it has
+// both a method and an id) rather than a notification or a response.
+func parseAgentRequest(line string) (acpAgentRequest, bool) {
+	var req acpAgentRequest
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return acpAgentRequest{}, false
+	}
+	id := strings.TrimSpace(string(req.ID))
+	if req.Method == "" || id == "" || id == "null" {
+		return acpAgentRequest{}, false
+	}
+	return req, true
+}
+
+type acpReadTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Line      int    `json:"line,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+type acpReadTextFileResult struct {
+	Content string `json:"content"`
+}
+
+type acpWriteTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+}
+
+type acpEnvVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type acpCreateTerminalParams struct {
+	SessionID       string           `json:"sessionId"`
+	Command         string           `json:"command"`
+	Args            []string         `json:"args,omitempty"`
+	Env             []acpEnvVariable `json:"env,omitempty"`
+	Cwd             string           `json:"cwd,omitempty"`
+	OutputByteLimit int              `json:"outputByteLimit,omitempty"`
+}
+
+type acpCreateTerminalResult struct {
+	TerminalID string `json:"terminalId"`
+}
+
+type acpTerminalParams struct {
+	SessionID  string `json:"sessionId"`
+	TerminalID string `json:"terminalId"`
+}
+
+type acpTerminalExitStatus struct {
+	ExitCode *int    `json:"exitCode"`
+	Signal   *string `json:"signal"`
+}
+
+type acpTerminalOutputResult struct {
+	Output     string                 `json:"output"`
+	Truncated  bool                   `json:"truncated"`
+	ExitStatus *acpTerminalExitStatus `json:"exitStatus,omitempty"`
+}
+
+// acpTerminal is a command started on behalf of the agent.
+type acpTerminal struct {
+	label string
+	cmd   *exec.Cmd
+	done  chan struct{}
+
+	mu        sync.Mutex
+	output    []byte
+	limit     int
+	truncated bool
+	exit      *acpTerminalExitStatus
+}
+
+// Write keeps at most limit bytes, dropping the oldest output first.
+func (t *acpTerminal) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.output = append(t.output, data...)
+	if overflow := len(t.output) - t.limit; overflow > 0 {
+		t.output = append([]byte(nil), t.output[overflow:]...)
+		t.truncated = true
+	}
+	return len(data), nil
+}
+
+func (t *acpTerminal) snapshot() acpTerminalOutputResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return acpTerminalOutputResult{
+		Output:     string(t.output),
+		Truncated:  t.truncated,
+		ExitStatus: t.exit,
+	}
+}
+
+func (t *acpTerminal) kill() {
+	select {
+	case <-t.done:
+	default:
+		if t.cmd.Process != nil {
+			_ = t.cmd.Process.Kill()
+		}
+	}
+}
+
+// acpClientError is returned to the agent as a JSON-RPC error.
+type acpClientError struct {
+	code    int
+	message string
+}
+
+func (e acpClientError) Error() string {
+	return e.message
+}
+
+// handleAgentRequest answers a request the agent sent while a client request
+// is in flight and reports it as tool events.
+func (p acpProvider) handleAgentRequest(ctx context.Context, session *acpSessionState, req acpAgentRequest, events chan Event) {
+	result, label, err := p.dispatchAgentRequest(ctx, session, req, events)
+	if label != "" {
+		if err != nil {
+			pushProviderEvent(events, EventToolFinished, label+" failed: "+err.Error())
+		} else {
+			pushProviderEvent(events, EventToolFinished, label)
+		}
+	}
+
+	response := acpAgentResponse{JSONRPC: "2.0", ID: req.ID}
+	if err != nil {
+		clientErr := acpClientError{code: acpErrorInternal, message: err.Error()}
+		var typed acpClientError
+		if errors.As(err, &typed) {
+			clientErr = typed
+		}
+		response.Error = &acpError{Code: clientErr.code, Message: clientErr.message}
+	} else {
+		response.Result = mustMarshalJSON(result)
+	}
+	_ = p.sendJSON(session, response)
+}
+
+// blocksOnCommand reports whether an agent request waits for a command to
+// finish.
+func blocksOnCommand(method string) bool {
+	return method == "terminal/wait_for_exit"
+}
+
+func (p acpProvider) dispatchAgentRequest(ctx context.Context, session *acpSessionState, req acpAgentRequest, events chan Event) (interface{}, string, error) {
+	switch req.Method {
+	case "fs/read_text_file":
+		var params acpReadTextFileParams
+		if err := decodeAgentParams(req.Params, &params); err != nil {
+			return nil, "", err
+		}
+		label := "read_text_file " + params.Path
+		pushProviderEvent(events, EventToolStarted, label)
+		content, err := readWorkDirTextFile(session.workDir(), params)
+		if err != nil {
+			return nil, label, err
+		}
+		return acpReadTextFileResult{Content: content}, label, nil
+	case "fs/write_text_file":
+		var params acpWriteTextFileParams
+		if err := decodeAgentParams(req.Params, &params); err != nil {
+			return nil, "", err
+		}
+		label := "write_text_file " + params.Path
+		pushProviderEvent(events, EventToolStarted, label)
+		if err := writeWorkDirTextFile(session.workDir(), params); err != nil {
+			return nil, label, err
+		}
+		return struct{}{}, label, nil
+	case "terminal/create":
+		var params acpCreateTerminalParams
+		if err := decodeAgentParams(req.Params, &params); err != nil {
+			return nil, "", err
+		}
+		terminalID, terminal, err := session.createTerminal(params)
+		if err != nil {
+			return nil, "terminal " + strings.TrimSpace(params.Command), err
+		}
+		pushProviderEvent(events, EventToolStarted, terminal.label)
+		return acpCreateTerminalResult{TerminalID: terminalID}, "", nil
+	case "terminal/output":
+		terminal, err := session.terminalFromParams(req.Params)
+		if err != nil {
+			return nil, "", err
+		}
+		return terminal.snapshot(), "", nil
+	case "terminal/wait_for_exit":
+		terminal, err := session.terminalFromParams(req.Params)
+		if err != nil {
+			return nil, "", err
+		}
+		select {
+		case <-terminal.done:
+		case <-ctx.Done():
+			terminal.kill()
+			<-terminal.done
+		}
+		output := terminal.snapshot()
+		pushProviderEvent(events, EventCommandOutput, tailText(output.Output, acpTerminalEventLimit))
+		return output.ExitStatus, terminal.label + " (" + describeTerminalExit(output.ExitStatus) + ")", nil
+	case "terminal/kill":
+		terminal, err := session.terminalFromParams(req.Params)
+		if err != nil {
+			return nil, "", err
+		}
+		terminal.kill()
+		return struct{}{}, "", nil
+	case "terminal/release":
+		var params acpTerminalParams
+		if err := decodeAgentParams(req.Params, &params); err != nil {
+			return nil, "", err
+		}
+		session.releaseTerminal(params.TerminalID)
+		return struct{}{}, "", nil
+	case "session/request_permission":
+		request, err := parsePermissionRequest(req.Params, session.workDir())
+		if err != nil {
+			return nil, "", err
+		}
+		return acpPermissionResult{Outcome: session.requestPermission(ctx, request)}, "", nil
+	default:
+		return nil, "", acpClientError{code: acpErrorMethodNotFound, message: "method not found: " + req.Method}
+	}
+}
+
+func decodeAgentParams(raw json.RawMessage, target interface{}) error {
+	if err := json.Unmarshal(raw, target); err != nil {
+		return acpClientError{code: acpErrorInvalidParams, message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func readWorkDirTextFile(workDir string, params acpReadTextFileParams) (string, error) {
+	path, err := resolveWorkDirPath(workDir, params.Path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	content := string(data)
+	if params.Line <= 0 && params.Limit <= 0 {
+		return content, nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	start := 0
+	if params.Line > 0 {
+		start = min(params.Line-1, len(lines))
+	}
+	end := len(lines)
+	if params.Limit > 0 {
+		end = min(start+params.Limit, len(lines))
+	}
+	return strings.Join(lines[start:end], ""), nil
+}
+
+func writeWorkDirTextFile(workDir string, params acpWriteTextFileParams) error {
+	path, err := resolveWorkDirPath(workDir, params.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(params.Content), 0o644)
+}
+
+// resolveWorkDirPath resolves path against workDir and rejects anything that
+// lands outside it, including through symlinked directories.
+func resolveWorkDirPath(workDir, path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", acpClientError{code: acpErrorInvalidParams, message: "path is required"}
+	}
+	root, err := filepath.Abs(workDir)
+	if err != nil {
+		return "", err
+	}
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(root, resolved)
+	}
+	resolved = filepath.Clean(resolved)
+
+	outside := acpClientError{code: acpErrorInvalidParams, message: fmt.Sprintf("path %q is outside the work dir", path)}
+	if !pathWithin(root, resolved) {
+		return "", outside
+	}
+
+	// Compare real paths so a symlink inside the work dir cannot point out of it.
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	existing := resolved
+	for {
+		if _, statErr := os.Lstat(existing); statErr == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	realExisting, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if !pathWithin(realRoot, realExisting) {
+		return "", outside
+	}
+	return resolved, nil
+}
+
+func pathWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func (s *acpSessionState) workDir() string {
+	if strings.TrimSpace(s.Cwd) != "" {
+		return s.Cwd
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return cwd
+	}
+	return "."
+}
+
+func (s *acpSessionState) createTerminal(params acpCreateTerminalParams) (string, *acpTerminal, error) {
+	command := strings.TrimSpace(params.Command)
+	if command == "" {
+		return "", nil, acpClientError{code: acpErrorInvalidParams, message: "command is required"}
+	}
+	dir := s.workDir()
+	if strings.TrimSpace(params.Cwd) != "" {
+		resolved, err := resolveWorkDirPath(dir, params.Cwd)
+		if err != nil {
+			return "", nil, err
+		}
+		dir = resolved
+	}
+
+	args := params.Args
+	label := strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
+	if len(args) == 0 && strings.ContainsAny(command, " \t") {
+		// Agents often send a whole shell line as the command.
+		args = []string{"-c", command}
+		command = "sh"
+	}
+
+	limit := params.OutputByteLimit
+	if limit <= 0 {
+		limit = acpTerminalDefaultOutputLimit
+	}
+	terminal := &acpTerminal{
+		label: "terminal " + label,
+		done:  make(chan struct{}),
+		limit: limit,
+	}
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for _, variable := range params.Env {
+		cmd.Env = append(cmd.Env, variable.Name+"="+variable.Value)
+	}
+	cmd.Stdout = terminal
+	cmd.Stderr = terminal
+	cmd.WaitDelay = acpTerminalWaitDelay
+	terminal.cmd = cmd
+
+	if err := cmd.Start(); err != nil {
+		return "", nil, err
+	}
+	go func() {
+		waitErr := cmd.Wait()
+		terminal.mu.Lock()
+		terminal.exit = terminalExitStatus(cmd, waitErr)
+		terminal.mu.Unlock()
+		close(terminal.done)
+	}()
+
+	s.terminalMu.Lock()
+	defer s.terminalMu.Unlock()
+	if s.terminals == nil {
+		s.terminals = make(map[string]*acpTerminal)
+	}
+	s.terminalSeq++
+	id := "term-" + strconv.Itoa(s.terminalSeq)
+	s.terminals[id] = terminal
+	return id, terminal, nil
+}
+
+func (s *acpSessionState) terminalFromParams(raw json.RawMessage) (*acpTerminal, error) {
+	var params acpTerminalParams
+	if err := decodeAgentParams(raw, &params); err != nil {
+		return nil, err
+	}
+	s.terminalMu.Lock()
+	defer s.terminalMu.Unlock()
+	terminal, ok := s.terminals[params.TerminalID]
+	if !ok {
+		return nil, acpClientError{code: acpErrorInvalidParams, message: fmt.Sprintf("unknown terminal %q", params.TerminalID)}
+	}
+	return terminal, nil
+}
+
+func (s *acpSessionState) releaseTerminal(id string) {
+	s.terminalMu.Lock()
+	terminal, ok := s.terminals[id]
+	delete(s.terminals, id)
+	s.terminalMu.Unlock()
+	if ok {
+		terminal.kill()
+	}
+}
+
+func (s *acpSessionState) releaseAllTerminals() {
+	s.terminalMu.Lock()
+	terminals := s.terminals
+	s.terminals = nil
+	s.terminalMu.Unlock()
+	for _, terminal := range terminals {
+		terminal.kill()
+	}
+}
+
+func terminalExitStatus(cmd *exec.Cmd, waitErr error) *acpTerminalExitStatus {
+	state := cmd.ProcessState
+	if state == nil {
+		message := "unknown"
+		if waitErr != nil {
+			message = waitErr.Error()
+		}
+		return &acpTerminalExitStatus{Signal: &message}
+	}
+	if code := state.ExitCode(); code >= 0 {
+		return &acpTerminalExitStatus{ExitCode: &code}
+	}
+	signal := strings.TrimPrefix(state.String(), "signal: ")
+	return &acpTerminalExitStatus{Signal: &signal}
+}
+
+func describeTerminalExit(status *acpTerminalExitStatus) string {
+	switch {
+	case status == nil:
+		return "still running"
+	case status.ExitCode != nil:
+		return "exit " + strconv.Itoa(*status.ExitCode)
+	case status.Signal != nil:
+		return "signal " + *status.Signal
+	default:
+		return "exited"
+	}
+}
+
+// tailText keeps the last limit bytes of text, where errors usually are.
+func tailText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return "..." + text[len(text)-limit:]
+}
@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestACPProviderServesAgentFileAndTerminalRequests(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "README.md"), []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	provider := NewMockProvider(MockScenario{
+		Name: "client-requests",
+		Prompts: []MockPrompt{{
+			Updates: []MockUpdate{
+				{Call: &MockCall{Method: "fs/read_text_file", Params: map[string]interface{}{"path": "README.md", "line": 2, "limit": 1}}},
+				{Call: &MockCall{Method: "fs/write_text_file", Params: map[string]interface{}{"path": filepath.Join(workDir, "out", "notes.txt"), "content": "written by agent"}}},
+				{Call: &MockCall{Method: "fs/read_text_file", Params: map[string]interface{}{"path": "../outside.txt"}}},
+				{Call: &MockCall{Method: "terminal/create", Params: map[string]interface{}{"command": "echo terminal-ok"}}},
+				{Call: &MockCall{Method: "terminal/wait_for_exit"}},
+				{Call: &MockCall{Method: "terminal/release"}},
+				{Call: &MockCall{Method: "session/unknown"}},
+			},
+			Response: "done",
+		}},
+	})
+
+	events, _, err := provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "go"})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	collected := collectEvents(events)
+
+	written, err := os.ReadFile(filepath.Join(workDir, "out", "notes.txt"))
+	if err != nil || string(written) != "written by agent" {
+		t.Fatalf("expected fs/write_text_file to land in the work dir, got %q (%v)", written, err)
+	}
+
+	want := []Event{
+		{Type: EventToolStarted, Message: "read_text_file README.md"},
+		{Type: EventToolFinished, Message: "read_text_file README.md"},
+		{Type: EventToolFinished, Message: "write_text_file " + filepath.Join(workDir, "out", "notes.txt")},
+		{Type: EventToolStarted, Message: "terminal echo terminal-ok"},
+		{Type: EventCommandOutput, Message: "terminal-ok"},
+		{Type: EventToolFinished, Message: "terminal echo terminal-ok (exit 0)"},
+		{Type: EventAssistantText, Message: "done"},
+	}
+	for _, event := range want {
+		if !containsEvent(collected, event) {
+			t.Fatalf("expected event %+v, got %+v", event, collected)
+		}
+	}
+
+	var rejected bool
+	for _, event := range collected {
+		if event.Type == EventToolFinished && strings.HasPrefix(event.Message, "read_text_file ../outside.txt failed") {
+			rejected = strings.Contains(event.Message, "outside the work dir")
+		}
+	}
+	if !rejected {
+		t.Fatalf("expected read outside the work dir to be rejected, got %+v", collected)
+	}
+}
+
+func TestACPProviderServesTerminalKillWhileWaitingForExit(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name: "kill-hung-command",
+		Prompts: []MockPrompt{{
+			Updates: []MockUpdate{
+				{Call: &MockCall{Method: "terminal/create", Params: map[string]interface{}{"command": "sleep", "args": []interface{}{"30"}}}},
+				{Call: &MockCall{Method: "terminal/wait_for_exit", NoWait: true}},
+				{Text: "The command hangs; killing it."},
+				{Call: &MockCall{Method: "terminal/kill"}},
+			},
+			Response: "done",
+		}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	started := time.Now()
+	events, _, err := provider.RunIteration(ctx, IterationRequest{WorkDir: t.TempDir(), Prompt: "go"})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	collected := collectEvents(events)
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("expected the kill to end the wait, took %s", elapsed)
+	}
+
+	want := []Event{
+		{Type: EventAssistantText, Message: "The command hangs; killing it."},
+		{Type: EventToolFinished, Message: "terminal sleep 30 (signal killed)"},
+	}
+	for _, event := range want {
+		if !containsEvent(collected, event) {
+			t.Fatalf("expected event %+v, got %+v", event, collected)
+		}
+	}
+}
+
+func TestReadWorkDirTextFileSlicesLines(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "a.txt"), []byte("1\n2\n3\n4\n"), 0o644); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+	got, err := readWorkDirTextFile(workDir, acpReadTextFileParams{Path: "a.txt", Line: 2, Limit: 2})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got != "2\n3\n" {
+		t.Fatalf("unexpected slice %q", got)
+	}
+}
+
+func TestResolveWorkDirPathRejectsSymlinkEscape(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(workDir, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if _, err := resolveWorkDirPath(workDir, "link/secret.txt"); err == nil {
+		t.Fatal("expected symlinked escape to be rejected")
+	}
+	if _, err := resolveWorkDirPath(workDir, "nested/new.txt"); err != nil {
+		t.Fatalf("expected new path inside the work dir to resolve, got %v", err)
+	}
+}
+
+func TestParseAgentRequestAcceptsZeroAndStringIDs(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{
+		`{"jsonrpc":"2.0","id":0,"method":"fs/read_text_file","params":{}}`,
+		`{"jsonrpc":"2.0","id":"req-1","method":"terminal/create","params":{}}`,
+	} {
+		if _, ok := parseAgentRequest(line); !ok {
+			t.Fatalf("expected agent request: %s", line)
+		}
+	}
+	for _, line := range []string{
+		`{"jsonrpc":"2.0","method":"session/update","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"result":{}}`,
+	} {
+		if _, ok := parseAgentRequest(line); ok {
+			t.Fatalf("expected non-request: %s", line)
+		}
+	}
+}
+
+func containsEvent(events []Event, target Event) bool {
+	for _, event := range events {
+		if event == target {
+			return true
+		}
+	}
+	return false
+}
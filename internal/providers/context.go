@@ -0,0 +1,272 @@
+package providers
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+)
+
+// bytesPerToken is the rough size of one token, used to turn a token budget
+// into bytes without a tokenizer.
+const bytesPerToken = 4
+
+// ContextBudget caps how much context file content is sent with one prompt.
+// Zero values mean no limit; when both are set the smaller one wins.
+type ContextBudget struct {
+	MaxBytes  int
+	MaxTokens int
+}
+
+// ContextBudgetFromConfig converts the [context] config section.
+func ContextBudgetFromConfig(cfg config.ContextConfig) ContextBudget {
+	return ContextBudget{MaxBytes: cfg.MaxBytes, MaxTokens: cfg.MaxTokens}
+}
+
+// limit returns the budget in bytes, or 0 when it is unlimited.
+func (b ContextBudget) limit() int {
+	limit := 0
+	if b.MaxBytes > 0 {
+		limit = b.MaxBytes
+	}
+	if b.MaxTokens > 0 {
+		tokens := b.MaxTokens * bytesPerToken
+		if limit == 0 || tokens < limit {
+			limit = tokens
+		}
+	}
+	return limit
+}
+
+// Context file priorities. Lower priorities are cut first when the budget is
+// exceeded.
+const (
+	contextPriorityProgress = iota
+	contextPriorityLearnings
+	contextPriorityDefault
+)
+
+// contextFile is one context file as it will be sent to the agent.
+type contextFile struct {
+	Path     string
+	Resolved string
+	Content  string
+	Size     int
+	// Truncated is set when Content holds only part of the file.
+	Truncated bool
+	// Dropped is set when none of the file fits the budget; it is sent as a
+	// link instead.
+	Dropped  bool
+	priority int
+}
+
+// loadContextFiles reads paths relative to workDir. Unreadable files are
+// skipped and reported as warnings.
+func loadContextFiles(workDir string, paths []string) ([]contextFile, []string) {
+	files := make([]contextFile, 0, len(paths))
+	var warnings []string
+	for _, path := range paths {
+		resolved := path
+		if !filepath.IsAbs(path) && strings.TrimSpace(workDir) != "" {
+			resolved = filepath.Join(workDir, path)
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipping context file %s: %v", path, err))
+			continue
+		}
+		files = append(files, contextFile{
+			Path:     path,
+			Resolved: resolved,
+			Content:  string(data),
+			Size:     len(data),
+			priority: contextFilePriority(path),
+		})
+	}
+	return files, warnings
+}
+
+// contextFilePriority ranks progress.md and learnings below everything else:
+// both grow with every iteration and their older entries matter least.
+func contextFilePriority(path string) int {
+	base := strings.ToLower(filepath.Base(path))
+	switch {
+	case base == "progress.md":
+		return contextPriorityProgress
+	case strings.Contains(base, "learnings"):
+		return contextPriorityLearnings
+	default:
+		return contextPriorityDefault
+	}
+}
+
+// applyContextBudget shrinks files until their content fits budget. Files
+// are cut lowest priority first, and among equal priorities the later file
+// in the list goes first. progress.md and learnings keep their tail, where
+// the newest entries are; other files keep their head. It returns a warning
+// for every file it cut.
+func applyContextBudget(files []contextFile, budget ContextBudget) []string {
+	limit := budget.limit()
+	if limit == 0 {
+		return nil
+	}
+	total := 0
+	for _, file := range files {
+		total += len(file.Content)
+	}
+	over := total - limit
+	if over <= 0 {
+		return nil
+	}
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = len(files) - 1 - i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return files[order[a]].priority < files[order[b]].priority
+	})
+
+	var warnings []string
+	for _, index := range order {
+		if over <= 0 {
+			break
+		}
+		file := &files[index]
+		marker := truncationMarker(file.Size)
+		keep := len(file.Content) - over - len(marker)
+		if keep <= 0 {
+			over -= len(file.Content)
+			file.Content = ""
+			file.Dropped = true
+			warnings = append(warnings, fmt.Sprintf("context file %s (%d bytes) omitted to fit the context budget of %d bytes", file.Path, file.Size, limit))
+			continue
+		}
+		keepTail := file.priority < contextPriorityDefault
+		file.Content = truncateContext(file.Content, keep, keepTail, marker)
+		file.Truncated = true
+		over = 0
+		warnings = append(warnings, fmt.Sprintf("context file %s truncated to %d of %d bytes to fit the context budget of %d bytes", file.Path, keep, file.Size, limit))
+	}
+	return warnings
+}
+
+func truncationMarker(size int) string {
+	return fmt.Sprintf("\n[... truncated by daedalus; the full file is %d bytes ...]\n", size)
+}
+
+// truncateContext keeps at most keep bytes of content, cut at a line
+// boundary when possible, and marks where the rest was removed.
+func truncateContext(content string, keep int, keepTail bool, marker string) string {
+	if keepTail {
+		kept := content[len(content)-keep:]
+		if newline := strings.IndexByte(kept, '\n'); newline >= 0 && newline < len(kept)-1 {
+			kept = kept[newline+1:]
+		}
+		for len(kept) > 0 && !utf8.RuneStart(kept[0]) {
+			kept = kept[1:]
+		}
+		return strings.TrimLeft(marker, "\n") + kept
+	}
+	kept := content[:keep]
+	if newline := strings.LastIndexByte(kept, '\n'); newline > 0 {
+		kept = kept[:newline+1]
+	}
+	return trimPartialRune(kept) + strings.TrimRight(marker, "\n")
+}
+
+// trimPartialRune drops a multi-byte character cut off at the end of s.
+func trimPartialRune(s string) string {
+	for i := 0; i < utf8.UTFMax-1 && len(s) > 0; i++ {
+		r, size := utf8.DecodeLastRuneInString(s)
+		if r != utf8.RuneError || size != 1 {
+			return s
+		}
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// contextURI returns the file URI the agent sees for a context file.
+func contextURI(file contextFile) string {
+	path := file.Resolved
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// contextMimeType guesses a text mime type from the file extension.
+func contextMimeType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return "text/markdown"
+	case ".json", ".jsonl":
+		return "application/json"
+	case ".yaml", ".yml":
+		return "application/yaml"
+	case ".toml":
+		return "application/toml"
+	case ".txt", "":
+		return "text/plain"
+	}
+	if guessed := mime.TypeByExtension(filepath.Ext(path)); guessed != "" {
+		if semicolon := strings.IndexByte(guessed, ';'); semicolon >= 0 {
+			guessed = guessed[:semicolon]
+		}
+		return strings.TrimSpace(guessed)
+	}
+	return "text/plain"
+}
+
+// buildContextBlocks turns the prompt and its context files into ACP content
+// blocks. When the agent supports embedded context each file is sent as a
+// resource block; otherwise the files are inlined after the prompt text.
+// Files dropped by the budget are sent as resource links either way, since
+// every ACP agent accepts those.
+func buildContextBlocks(prompt string, files []contextFile, embedded bool) []acpContentBlock {
+	var links []acpContentBlock
+	var resources []acpContentBlock
+	var inline strings.Builder
+	for _, file := range files {
+		uri := contextURI(file)
+		mimeType := contextMimeType(file.Path)
+		if file.Dropped {
+			links = append(links, acpContentBlock{
+				Type:     "resource_link",
+				URI:      uri,
+				Name:     filepath.Base(file.Path),
+				MimeType: mimeType,
+				Size:     int64(file.Size),
+			})
+			continue
+		}
+		if embedded {
+			resources = append(resources, acpContentBlock{
+				Type:     "resource",
+				Resource: &acpEmbeddedResource{URI: uri, MimeType: mimeType, Text: file.Content},
+			})
+			continue
+		}
+		inline.WriteString("\n--- ")
+		inline.WriteString(file.Path)
+		inline.WriteString(" ---\n")
+		inline.WriteString(file.Content)
+	}
+
+	text := prompt
+	if strings.TrimSpace(inline.String()) != "" {
+		text = fmt.Sprintf("%s\n\n--- Context Files ---\n%s", prompt, inline.String())
+	}
+	blocks := make([]acpContentBlock, 0, 1+len(resources)+len(links))
+	blocks = append(blocks, acpContentBlock{Type: "text", Text: text})
+	blocks = append(blocks, resources...)
+	blocks = append(blocks, links...)
+	return blocks
+}
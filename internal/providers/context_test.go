@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyContextBudgetCutsProgressAndLearningsFirst(t *testing.T) {
+	t.Parallel()
+
+	prdText := strings.Repeat("requirement\n", 50)
+	progress := strings.Repeat("old progress\n", 40)
+	learnings := strings.Repeat("old learning\n", 40) + "newest learning\n"
+	files := []contextFile{
+		{Path: "prd.md", Content: prdText, Size: len(prdText), priority: contextFilePriority("prd.md")},
+		{Path: "progress.md", Content: progress, Size: len(progress), priority: contextFilePriority("progress.md")},
+		{Path: "learnings.md", Content: learnings, Size: len(learnings), priority: contextFilePriority("learnings.md")},
+	}
+
+	budget := ContextBudget{MaxBytes: len(prdText) + 300}
+	warnings := applyContextBudget(files, budget)
+
+	if files[0].Content != prdText || files[0].Truncated || files[0].Dropped {
+		t.Fatalf("expected the PRD to be kept whole, got %+v", files[0])
+	}
+	if !files[1].Dropped || files[1].Content != "" {
+		t.Fatalf("expected progress.md to be dropped first, got %+v", files[1])
+	}
+	if !files[2].Truncated || !strings.HasSuffix(files[2].Content, "newest learning\n") || !strings.HasPrefix(files[2].Content, "[... truncated") {
+		t.Fatalf("expected learnings to keep their newest entries, got %q", files[2].Content)
+	}
+	total := 0
+	for _, file := range files {
+		total += len(file.Content)
+	}
+	if total > budget.limit() {
+		t.Fatalf("expected content to fit %d bytes, got %d", budget.limit(), total)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected a warning per cut file, got %v", warnings)
+	}
+}
+
+func TestContextBudgetLimitUsesSmallerBound(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		budget ContextBudget
+		want   int
+	}{
+		{budget: ContextBudget{}, want: 0},
+		{budget: ContextBudget{MaxBytes: 1000}, want: 1000},
+		{budget: ContextBudget{MaxTokens: 100}, want: 400},
+		{budget: ContextBudget{MaxBytes: 1000, MaxTokens: 100}, want: 400},
+		{budget: ContextBudget{MaxBytes: 300, MaxTokens: 100}, want: 300},
+	}
+	for _, tt := range tests {
+		if got := tt.budget.limit(); got != tt.want {
+			t.Fatalf("limit(%+v) = %d, want %d", tt.budget, got, tt.want)
+		}
+	}
+}
+
+func TestBuildContextBlocksEmbedsOrInlinesFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []contextFile{
+		{Path: "prd.md", Resolved: "/work/prd.md", Content: "# PRD\n"},
+		{Path: "progress.md", Resolved: "/work/progress.md", Size: 2048, Dropped: true},
+	}
+
+	embedded := buildContextBlocks("Implement US-001", files, true)
+	if len(embedded) != 3 || embedded[0].Text != "Implement US-001" {
+		t.Fatalf("expected prompt, resource, and link blocks, got %+v", embedded)
+	}
+	resource := embedded[1]
+	if resource.Type != "resource" || resource.Resource == nil || resource.Resource.URI != "file:///work/prd.md" ||
+		resource.Resource.MimeType != "text/markdown" || resource.Resource.Text != "# PRD\n" {
+		t.Fatalf("unexpected resource block: %+v", resource)
+	}
+	link := embedded[2]
+	if link.Type != "resource_link" || link.URI != "file:///work/progress.md" || link.Name != "progress.md" || link.Size != 2048 {
+		t.Fatalf("unexpected resource link: %+v", link)
+	}
+
+	inline := buildContextBlocks("Implement US-001", files, false)
+	if len(inline) != 2 || !strings.Contains(inline[0].Text, "--- prd.md ---\n# PRD\n") || inline[1].Type != "resource_link" {
+		t.Fatalf("expected inlined PRD plus link, got %+v", inline)
+	}
+}
+
+func TestParseInitializeCapabilitiesReadsEmbeddedContext(t *testing.T) {
+	t.Parallel()
+
+	raw := json.RawMessage(`{"protocolVersion":1,"agentCapabilities":{"promptCapabilities":{"embeddedContext":true,"image":false}}}`)
+	parsed, ok := parseInitializeCapabilities(raw, Capabilities{})
+	if !ok || !parsed.EmbeddedContext {
+		t.Fatalf("expected embedded context support, got %+v (%v)", parsed, ok)
+	}
+}
+
+func TestMockProviderReceivesContextAsResources(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name:    "context",
+		Prompts: []MockPrompt{{Match: "from the PRD file", Response: "saw the PRD"}},
+	})
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "prd.md"), []byte("Text from the PRD file\n"), 0o644); err != nil {
+		t.Fatalf("write prd: %v", err)
+	}
+
+	events, _, err := provider.RunIteration(context.Background(), IterationRequest{
+		WorkDir:      workDir,
+		Prompt:       "Implement US-001",
+		ContextFiles: []string{"prd.md", "missing.md"},
+	})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	collected := collectEvents(events)
+	if !containsAssistantText(collected, "saw the PRD") {
+		t.Fatalf("expected the agent to receive the PRD, got %+v", collected)
+	}
+	var warning string
+	for _, event := range collected {
+		if event.Type == EventContextWarning {
+			warning = event.Message
+		}
+	}
+	if !strings.Contains(warning, "missing.md") {
+		t.Fatalf("expected a warning for the unreadable file, got %q", warning)
+	}
+}
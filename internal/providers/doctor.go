@@ -52,10 +52,19 @@ func RunACPDoctor(ctx context.Context, cfg config.Config, targets []string) (ACP
 		workingDir = "."
 	}
 
+	registry := NewRegistry()
 	for _, key := range keys {
-		providerCfg := getProviderConfig(cfg, key)
 		enabled := providerEnabled(cfg, key)
-		command := resolveACPCommand(key, providerCfg.ACPCommand)
+		provider, ok := registry.builders[key](cfg).(acpProvider)
+		if !ok {
+			report.Checks = append(report.Checks, ACPDoctorCheck{
+				ProviderKey: key,
+				Enabled:     enabled,
+				Message:     "internal error: expected acp provider",
+			})
+			continue
+		}
+		command := provider.command
 		commandText := strings.TrimSpace(strings.Join(append([]string{command.Binary}, command.Args...), " "))
 
 		check := ACPDoctorCheck{
@@ -70,19 +79,16 @@ func RunACPDoctor(ctx context.Context, cfg config.Config, targets []string) (ACP
 			continue
 		}
 
-		binaryPath, lookupErr := exec.LookPath(command.Binary)
-		if lookupErr != nil {
-			check.Message = fmt.Sprintf("acp binary %q not found in PATH", command.Binary)
-			report.Checks = append(report.Checks, check)
-			continue
-		}
-		check.BinaryPath = binaryPath
-
-		provider, ok := newACPProvider(cfg, key).(acpProvider)
-		if !ok {
-			check.Message = "internal error: expected acp provider"
-			report.Checks = append(report.Checks, check)
-			continue
+		if provider.agent != nil {
+			check.BinaryPath = "(in-process)"
+		} else {
+			binaryPath, lookupErr := exec.LookPath(command.Binary)
+			if lookupErr != nil {
+				check.Message = fmt.Sprintf("acp binary %q not found in PATH", command.Binary)
+				report.Checks = append(report.Checks, check)
+				continue
+			}
+			check.BinaryPath = binaryPath
 		}
 
 		session, startErr := provider.startSession(workingDir)
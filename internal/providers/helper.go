@@ -38,7 +38,7 @@ func SynthesizeReviewSummary(reviews []PerspectiveReview) string {
 			b.WriteString("):\n")
 			for _, f := range r.Findings {
 				b.WriteString("  - ")
-				b.WriteString(f)
+				b.WriteString(f.String())
 				b.WriteString("\n")
 			}
 		}
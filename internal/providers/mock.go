@@ -0,0 +1,626 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+)
+
+const (
+	mockProviderKey      = "mock"
+	mockDefaultSessionID = "mock-session"
+	mockDefaultResponse  = "mock iteration completed"
+)
+
+// MockScenario scripts the built-in mock ACP agent. The agent runs in-process
+// behind the regular ACP client, so a scenario exercises the same transport,
+// event mapping, and error handling as a real provider.
+//
+// Scenarios are loaded from YAML or JSON:
+//
+//	name: happy-path
+//	prompts:
+//	  - match: "Implement story"
+//	    updates:
+//	      - text: "Adding the handler"
+//	      - tool: edit
+//	        status: started
+//	      - write:
+//	          path: handler.go
+//	          content: "package main\n"
+//	      - tool: edit
+//	        status: completed
+//	    response: "Story implemented"
+//	  - error:
+//	      code: -32000
+//	      message: "rate limit exceeded"
+type MockScenario struct {
+	Name string `json:"name" yaml:"name"`
+	// Capabilities is returned as serverCapabilities from initialize.
+	Capabilities map[string]interface{} `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	// SessionID is returned from session/new. Defaults to "mock-session".
+	SessionID string `json:"session_id,omitempty" yaml:"session_id,omitempty"`
+	// SessionError makes session/new fail with this message.
+	SessionError string `json:"session_error,omitempty" yaml:"session_error,omitempty"`
+	// Prompts answer session/prompt requests. Each prompt is used once, in
+	// order; when all matching prompts are used up the last one repeats.
+	Prompts []MockPrompt `json:"prompts" yaml:"prompts"`
+}
+
+// MockPrompt is the scripted answer to one session/prompt request.
+type MockPrompt struct {
+	// Match limits the prompt to requests whose text contains it.
+	Match string `json:"match,omitempty" yaml:"match,omitempty"`
+	// Updates are sent as session/update notifications before the response.
+	Updates    []MockUpdate `json:"updates,omitempty" yaml:"updates,omitempty"`
+	Response   string       `json:"response,omitempty" yaml:"response,omitempty"`
+	StopReason string       `json:"stop_reason,omitempty" yaml:"stop_reason,omitempty"`
+	// Error answers the prompt with a JSON-RPC error instead of a result.
+	Error *MockError `json:"error,omitempty" yaml:"error,omitempty"`
+	// Delay holds the response back. A session/cancel received meanwhile ends
+	// the turn with stop reason "cancelled".
+	Delay string `json:"delay,omitempty" yaml:"delay,omitempty"`
+	// Disconnect hangs up without answering, as if the agent crashed.
+	Disconnect bool `json:"disconnect,omitempty" yaml:"disconnect,omitempty"`
+	// Usage is reported in the prompt result. Without it the client
+	// estimates usage from the prompt and response length.
+	Usage *MockUsage `json:"usage,omitempty" yaml:"usage,omitempty"`
+}
+
+// MockUsage is the token usage and cost reported for one prompt.
+type MockUsage struct {
+	InputTokens  int     `json:"input_tokens,omitempty" yaml:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty" yaml:"output_tokens,omitempty"`
+	CostUSD      float64 `json:"cost_usd,omitempty" yaml:"cost_usd,omitempty"`
+}
+
+// MockUpdate is one step of a scripted turn. Text is streamed as agent
+// output; Tool reports a tool call (both start and finish when Status is
+// empty); Write creates or replaces a file in the work dir directly; Call sends
+// a request to the client and waits for its answer. When a
+// session/request_permission call is not granted, the next update is skipped,
+// the way an agent drops a tool call the user rejected.
+type MockUpdate struct {
+	Text   string         `json:"text,omitempty" yaml:"text,omitempty"`
+	Tool   string         `json:"tool,omitempty" yaml:"tool,omitempty"`
+	Status string         `json:"status,omitempty" yaml:"status,omitempty"`
+	Write  *MockFileWrite `json:"write,omitempty" yaml:"write,omitempty"`
+	Call   *MockCall      `json:"call,omitempty" yaml:"call,omitempty"`
+}
+
+// MockCall is an agent-to-client request such as fs/read_text_file or
+// terminal/create. sessionId is filled in automatically, and terminal/*
+// calls without a terminalId use the terminal created most recently.
+type MockCall struct {
+	Method string                 `json:"method" yaml:"method"`
+	Params map[string]interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+	// NoWait moves on to the next update without waiting for the answer,
+	// the way an agent kills a command it is still waiting on. The turn
+	// ends once every answer has come in.
+	NoWait bool `json:"no_wait,omitempty" yaml:"no_wait,omitempty"`
+}
+
+// MockFileWrite is a file written by the mock agent, relative to the work dir.
+type MockFileWrite struct {
+	Path    string `json:"path" yaml:"path"`
+	Content string `json:"content" yaml:"content"`
+}
+
+// MockError is a JSON-RPC error returned by the mock agent.
+type MockError struct {
+	Code    int    `json:"code,omitempty" yaml:"code,omitempty"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// NewMockProvider returns a provider backed by the in-process mock agent
+// replaying scenario. Every work dir has its own replay state, which survives
+// reconnects so a scripted disconnect is not replayed on retry.
+func NewMockProvider(scenario MockScenario) Provider {
+	replays := &mockReplays{byWorkDir: map[string]*mockReplay{}}
+	return acpProvider{
+		providerKey: mockProviderKey,
+		command:     acpCommand{Binary: mockProviderKey, Args: []string{"scenario:" + scenario.Name}},
+		agent: func(workDir string) (acpAgent, error) {
+			return newMockAgent(scenario, workDir, replays.forWorkDir(workDir, len(scenario.Prompts))), nil
+		},
+	}
+}
+
+func newMockProvider(cfg config.Config) Provider {
+	providerCfg := cfg.Providers.Mock
+	scenarioPath := strings.TrimSpace(providerCfg.Scenario)
+	command := acpCommand{Binary: mockProviderKey}
+	if scenarioPath != "" {
+		command.Args = []string{scenarioPath}
+	}
+
+	replays := &mockReplays{byWorkDir: map[string]*mockReplay{}}
+	return acpProvider{
+		cfg:           providerCfg,
+		providerKey:   mockProviderKey,
+		command:       command,
+		contextBudget: ContextBudgetFromConfig(cfg.Context),
+		agent: func(workDir string) (acpAgent, error) {
+			scenario := defaultMockScenario()
+			if scenarioPath != "" {
+				loaded, err := LoadMockScenario(scenarioPath)
+				if err != nil {
+					return nil, NewConfigurationError("failed to load mock scenario", err)
+				}
+				scenario = loaded
+			}
+			return newMockAgent(scenario, workDir, replays.forWorkDir(workDir, len(scenario.Prompts))), nil
+		},
+	}
+}
+
+func defaultMockScenario() MockScenario {
+	return MockScenario{
+		Name:    "default",
+		Prompts: []MockPrompt{{Response: mockDefaultResponse}},
+	}
+}
+
+// LoadMockScenario reads a scenario file. Files ending in .json are parsed as
+// JSON; anything else as YAML. Unknown fields are rejected so typos surface.
+func LoadMockScenario(path string) (MockScenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return MockScenario{}, fmt.Errorf("failed to read mock scenario %s: %w", path, err)
+	}
+
+	var scenario MockScenario
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&scenario)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&scenario)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		return MockScenario{}, fmt.Errorf("failed to parse mock scenario %s: %w", path, err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return MockScenario{}, fmt.Errorf("invalid mock scenario %s: %w", path, err)
+	}
+	if strings.TrimSpace(scenario.Name) == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return scenario, nil
+}
+
+// Validate checks delays, errors, and file writes before the scenario runs.
+func (s MockScenario) Validate() error {
+	for index, prompt := range s.Prompts {
+		if strings.TrimSpace(prompt.Delay) != "" {
+			if _, err := time.ParseDuration(prompt.Delay); err != nil {
+				return fmt.Errorf("prompts[%d].delay: %w", index, err)
+			}
+		}
+		if prompt.Error != nil && strings.TrimSpace(prompt.Error.Message) == "" {
+			return fmt.Errorf("prompts[%d].error.message is required", index)
+		}
+		for updateIndex, update := range prompt.Updates {
+			if update.Call != nil && strings.TrimSpace(update.Call.Method) == "" {
+				return fmt.Errorf("prompts[%d].updates[%d].call.method is required", index, updateIndex)
+			}
+			if update.Write == nil {
+				continue
+			}
+			if _, err := mockWritePath(".", update.Write.Path); err != nil {
+				return fmt.Errorf("prompts[%d].updates[%d].write: %w", index, updateIndex, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mockReplay tracks which prompts of a scenario have been used.
+type mockReplay struct {
+	mu   sync.Mutex
+	used []bool
+}
+
+type mockReplays struct {
+	mu        sync.Mutex
+	byWorkDir map[string]*mockReplay
+}
+
+// forWorkDir returns the replay state for workDir, starting over when the
+// scenario's prompt count changed since the last session.
+func (r *mockReplays) forWorkDir(workDir string, prompts int) *mockReplay {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	replay, ok := r.byWorkDir[workDir]
+	if !ok || len(replay.used) != prompts {
+		replay = &mockReplay{used: make([]bool, prompts)}
+		r.byWorkDir[workDir] = replay
+	}
+	return replay
+}
+
+// mockAgent serves one ACP session from a scenario.
+type mockAgent struct {
+	scenario MockScenario
+	workDir  string
+	replay   *mockReplay
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	callID         int
+	lastTerminalID string
+	// unanswered holds the IDs of NoWait calls still waiting for an answer.
+	unanswered map[int]bool
+}
+
+func newMockAgent(scenario MockScenario, workDir string, replay *mockReplay) *mockAgent {
+	return &mockAgent{
+		scenario: scenario,
+		workDir:  workDir,
+		replay:   replay,
+	}
+}
+
+func (a *mockAgent) Serve(in io.Reader, out io.Writer) {
+	a.out = out
+
+	stop := make(chan struct{})
+	defer close(stop)
+	messages := make(chan acpJSONRPC, 16)
+	go func() {
+		defer close(messages)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var message acpJSONRPC
+			if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+				continue
+			}
+			select {
+			case messages <- message:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for message := range messages {
+		if !a.handle(message, messages) {
+			return
+		}
+	}
+}
+
+// handle answers one client message. It returns false when the agent hangs up.
+func (a *mockAgent) handle(message acpJSONRPC, messages <-chan acpJSONRPC) bool {
+	switch message.Method {
+	case "initialize":
+		result := map[string]interface{}{
+			"protocolVersion": 1,
+			"agentInfo":       map[string]string{"name": "daedalus-mock", "version": "1.0.0"},
+			"agentCapabilities": map[string]interface{}{
+				"promptCapabilities": map[string]interface{}{"embeddedContext": true},
+			},
+		}
+		if len(a.scenario.Capabilities) > 0 {
+			result["serverCapabilities"] = a.scenario.Capabilities
+		}
+		a.reply(message.ID, result)
+	case "session/new":
+		if strings.TrimSpace(a.scenario.SessionError) != "" {
+			a.fail(message.ID, -32000, a.scenario.SessionError)
+			return true
+		}
+		a.reply(message.ID, acpSessionResult{SessionID: a.sessionID()})
+	case "session/resume", "session/load":
+		resumeID := parseSessionIDFromResult(message.Params)
+		if resumeID == "" {
+			resumeID = a.sessionID()
+		}
+		a.reply(message.ID, acpSessionResult{SessionID: resumeID})
+	case "session/prompt":
+		return a.prompt(message, messages)
+	case "session/cancel":
+		// Nothing is in flight outside prompt; see wait.
+	default:
+		if message.ID != 0 {
+			a.fail(message.ID, -32601, "method not found")
+		}
+	}
+	return true
+}
+
+func (a *mockAgent) prompt(message acpJSONRPC, messages <-chan acpJSONRPC) bool {
+	var params acpPromptParams
+	_ = json.Unmarshal(message.Params, &params)
+	var text strings.Builder
+	for _, block := range params.Prompt {
+		text.WriteString(block.Text)
+		if block.Resource != nil {
+			text.WriteString(block.Resource.Text)
+		}
+		text.WriteString(block.URI)
+	}
+
+	step, ok := a.nextPrompt(text.String())
+	if !ok {
+		a.fail(message.ID, -32000, fmt.Sprintf("mock scenario %q has no response for this prompt", a.scenario.Name))
+		return true
+	}
+
+	skipNext := false
+	for _, update := range step.Updates {
+		if skipNext {
+			skipNext = false
+			continue
+		}
+		if err := a.sendUpdate(params.SessionID, update); err != nil {
+			a.fail(message.ID, -32000, err.Error())
+			return true
+		}
+		if update.Call == nil {
+			continue
+		}
+		result, open := a.call(params.SessionID, *update.Call, messages)
+		if !open {
+			return false
+		}
+		if update.Call.Method == "session/request_permission" {
+			skipNext = !mockPermissionGranted(*update.Call, result)
+		}
+	}
+	if !a.awaitUnanswered(messages) {
+		return false
+	}
+
+	if delay, _ := time.ParseDuration(strings.TrimSpace(step.Delay)); delay > 0 {
+		cancelled, open := a.wait(delay, messages)
+		if !open {
+			return false
+		}
+		if cancelled {
+			a.reply(message.ID, acpPromptResult{StopReason: "cancelled"})
+			return true
+		}
+	}
+
+	if step.Disconnect {
+		return false
+	}
+	if step.Error != nil {
+		code := step.Error.Code
+		if code == 0 {
+			code = -32000
+		}
+		a.fail(message.ID, code, step.Error.Message)
+		return true
+	}
+
+	stopReason := strings.TrimSpace(step.StopReason)
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	result := acpPromptResult{StopReason: stopReason}
+	if step.Response != "" {
+		result.Output = []acpContentBlock{{Type: "text", Text: step.Response}}
+	}
+	if step.Usage != nil {
+		result.Usage = &acpUsage{InputTokens: step.Usage.InputTokens, OutputTokens: step.Usage.OutputTokens}
+		if step.Usage.CostUSD > 0 {
+			result.Usage.Cost = &acpCost{Amount: step.Usage.CostUSD, Currency: "USD"}
+		}
+	}
+	a.reply(message.ID, result)
+	return true
+}
+
+// nextPrompt returns the first unused prompt matching text, or the last
+// matching prompt once all of them have been used.
+func (a *mockAgent) nextPrompt(text string) (MockPrompt, bool) {
+	a.replay.mu.Lock()
+	defer a.replay.mu.Unlock()
+	for index, prompt := range a.scenario.Prompts {
+		if !a.replay.used[index] && mockPromptMatches(prompt, text) {
+			a.replay.used[index] = true
+			return prompt, true
+		}
+	}
+	for index := len(a.scenario.Prompts) - 1; index >= 0; index-- {
+		if mockPromptMatches(a.scenario.Prompts[index], text) {
+			return a.scenario.Prompts[index], true
+		}
+	}
+	return MockPrompt{}, false
+}
+
+func mockPromptMatches(prompt MockPrompt, text string) bool {
+	return prompt.Match == "" || strings.Contains(text, prompt.Match)
+}
+
+// call sends a request to the client and waits for the matching response. It
+// returns the result, or open=false when the client hangs up first. Numbering
+// starts at zero, as the reference ACP SDK does.
+func (a *mockAgent) call(sessionID string, call MockCall, messages <-chan acpJSONRPC) (result json.RawMessage, open bool) {
+	params := map[string]interface{}{"sessionId": sessionID}
+	for key, value := range call.Params {
+		params[key] = value
+	}
+	if strings.HasPrefix(call.Method, "terminal/") && call.Method != "terminal/create" {
+		if _, ok := params["terminalId"]; !ok {
+			params["terminalId"] = a.lastTerminalID
+		}
+	}
+
+	id := a.callID
+	a.callID++
+	a.writeValue(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": call.Method, "params": params})
+	if call.NoWait {
+		if a.unanswered == nil {
+			a.unanswered = map[int]bool{}
+		}
+		a.unanswered[id] = true
+		return nil, true
+	}
+
+	for message := range messages {
+		if message.Method != "" || (message.Result == nil && message.Error == nil) {
+			continue
+		}
+		if message.ID != id {
+			delete(a.unanswered, message.ID)
+			continue
+		}
+		if message.Error == nil && call.Method == "terminal/create" {
+			var created acpCreateTerminalResult
+			if err := json.Unmarshal(message.Result, &created); err == nil {
+				a.lastTerminalID = created.TerminalID
+			}
+		}
+		return message.Result, true
+	}
+	return nil, false
+}
+
+// awaitUnanswered waits for the answers to NoWait calls. It returns false
+// when the client hangs up first.
+func (a *mockAgent) awaitUnanswered(messages <-chan acpJSONRPC) bool {
+	for len(a.unanswered) > 0 {
+		message, ok := <-messages
+		if !ok {
+			return false
+		}
+		if message.Method == "" && (message.Result != nil || message.Error != nil) {
+			delete(a.unanswered, message.ID)
+		}
+	}
+	return true
+}
+
+// mockPermissionGranted reports whether the client picked one of the call's
+// allow options.
+func mockPermissionGranted(call MockCall, result json.RawMessage) bool {
+	var answer acpPermissionResult
+	if err := json.Unmarshal(result, &answer); err != nil || answer.Outcome.Outcome != "selected" {
+		return false
+	}
+	options, _ := call.Params["options"].([]interface{})
+	for _, raw := range options {
+		option, _ := raw.(map[string]interface{})
+		if option["optionId"] == answer.Outcome.OptionID {
+			kind, _ := option["kind"].(string)
+			return strings.HasPrefix(kind, "allow")
+		}
+	}
+	return false
+}
+
+// wait sleeps for delay, returning early when the client cancels the turn.
+// Other messages are not expected while a turn is in flight and are dropped.
+func (a *mockAgent) wait(delay time.Duration, messages <-chan acpJSONRPC) (cancelled bool, open bool) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return false, true
+		case message, ok := <-messages:
+			if !ok {
+				return false, false
+			}
+			if message.Method == "session/cancel" {
+				return true, true
+			}
+		}
+	}
+}
+
+func (a *mockAgent) sendUpdate(sessionID string, update MockUpdate) error {
+	if update.Write != nil {
+		path, err := mockWritePath(a.workDir, update.Write.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("mock write %s: %w", update.Write.Path, err)
+		}
+		if err := os.WriteFile(path, []byte(update.Write.Content), 0o644); err != nil {
+			return fmt.Errorf("mock write %s: %w", update.Write.Path, err)
+		}
+	}
+	if update.Text != "" {
+		a.notify(map[string]string{"sessionId": sessionID, "content": update.Text})
+	}
+	if tool := strings.TrimSpace(update.Tool); tool != "" {
+		statuses := []string{update.Status}
+		if strings.TrimSpace(update.Status) == "" {
+			statuses = []string{"started", "completed"}
+		}
+		for _, status := range statuses {
+			a.notify(map[string]string{"sessionId": sessionID, "toolName": tool, "status": status})
+		}
+	}
+	return nil
+}
+
+// mockWritePath resolves a scenario path inside workDir, rejecting absolute
+// paths and paths that climb out of it.
+func mockWritePath(workDir, path string) (string, error) {
+	cleaned := filepath.Clean(strings.TrimSpace(path))
+	if cleaned == "." || cleaned == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q must stay inside the work dir", path)
+	}
+	return filepath.Join(workDir, cleaned), nil
+}
+
+func (a *mockAgent) sessionID() string {
+	if id := strings.TrimSpace(a.scenario.SessionID); id != "" {
+		return id
+	}
+	return mockDefaultSessionID
+}
+
+func (a *mockAgent) reply(id int, result interface{}) {
+	a.write(acpJSONRPC{JSONRPC: "2.0", ID: id, Result: mustMarshalJSON(result)})
+}
+
+func (a *mockAgent) fail(id int, code int, message string) {
+	a.write(acpJSONRPC{JSONRPC: "2.0", ID: id, Error: &acpError{Code: code, Message: message}})
+}
+
+func (a *mockAgent) notify(params interface{}) {
+	a.write(acpJSONRPC{JSONRPC: "2.0", Method: "session/update", Params: mustMarshalJSON(params)})
+}
+
+func (a *mockAgent) write(message acpJSONRPC) {
+	a.writeValue(message)
+}
+
+func (a *mockAgent) writeValue(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	_, _ = a.out.Write(append(data, '\n'))
+}
@@ -0,0 +1,242 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+)
+
+const mockScenarioYAML = `name: story
+prompts:
+  - match: "Implement"
+    updates:
+      - text: "Adding the handler. "
+      - tool: edit
+        status: started
+      - write:
+          path: pkg/handler.go
+          content: "package pkg\n"
+      - tool: edit
+        status: completed
+    response: "Story implemented"
+  - match: "Review"
+    error:
+      code: -32000
+      message: "rate limit exceeded"
+`
+
+func writeMockScenario(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write scenario: %v", err)
+	}
+	return path
+}
+
+func mockConfig(scenarioPath string) config.Config {
+	cfg := config.Defaults()
+	cfg.Provider.Default = "mock"
+	cfg.Providers.Mock.Enabled = true
+	cfg.Providers.Mock.Scenario = scenarioPath
+	return cfg
+}
+
+func TestMockProviderReplaysScenario(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	cfg := mockConfig(writeMockScenario(t, "story.yaml", mockScenarioYAML))
+	provider, err := NewRegistry().Resolve("", cfg)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	workDir := t.TempDir()
+	events, result, err := provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "Implement story US-001"})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	collected := collectEvents(events)
+	for _, want := range []EventType{EventIterationStarted, EventToolStarted, EventToolFinished, EventIterationDone} {
+		if !containsEventType(collected, want) {
+			t.Fatalf("expected %s event, got %v", want, eventTypes(collected))
+		}
+	}
+	if !containsAssistantText(collected, "Adding the handler. Story implemented") {
+		t.Fatalf("expected streamed text plus response, got %+v", collected)
+	}
+
+	written, err := os.ReadFile(filepath.Join(workDir, "pkg", "handler.go"))
+	if err != nil || string(written) != "package pkg\n" {
+		t.Fatalf("expected scripted file write, got %q (%v)", written, err)
+	}
+
+	events, _, err = provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "Review the diff"})
+	if err != nil {
+		t.Fatalf("run review iteration: %v", err)
+	}
+	var errorMessage string
+	for _, event := range collectEvents(events) {
+		if event.Type == EventError {
+			errorMessage = event.Message
+		}
+	}
+	if !strings.Contains(errorMessage, "rate limit exceeded") {
+		t.Fatalf("expected scripted error event, got %q", errorMessage)
+	}
+}
+
+func TestMockProviderRepeatsLastMatchingPrompt(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name: "repeat",
+		Prompts: []MockPrompt{
+			{Response: "first"},
+			{Response: "second"},
+		},
+	})
+	workDir := t.TempDir()
+
+	var replies []string
+	for range 3 {
+		events, _, err := provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "go"})
+		if err != nil {
+			t.Fatalf("run iteration: %v", err)
+		}
+		for _, event := range collectEvents(events) {
+			if event.Type == EventAssistantText {
+				replies = append(replies, event.Message)
+			}
+		}
+	}
+	if strings.Join(replies, ",") != "first,second,second" {
+		t.Fatalf("unexpected replies: %v", replies)
+	}
+}
+
+func TestMockProviderDisconnectIsNotReplayedOnRetry(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name: "flaky",
+		Prompts: []MockPrompt{
+			{Disconnect: true},
+			{Response: "recovered"},
+		},
+	})
+	workDir := t.TempDir()
+
+	events, _, err := provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "go"})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	if !containsEventType(collectEvents(events), EventError) {
+		t.Fatal("expected disconnect to surface as an error event")
+	}
+
+	events, _, err = provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "go"})
+	if err != nil {
+		t.Fatalf("retry iteration: %v", err)
+	}
+	if !containsAssistantText(collectEvents(events), "recovered") {
+		t.Fatal("expected retry to reach the next scripted prompt")
+	}
+}
+
+func TestMockProviderCancelsDelayedPrompt(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name:    "slow",
+		Prompts: []MockPrompt{{Delay: "1m", Response: "too late"}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	events, _, err := provider.RunIteration(ctx, IterationRequest{WorkDir: t.TempDir(), Prompt: "go"})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	if !containsEventType(collectEvents(events), EventError) {
+		t.Fatal("expected timeout error event")
+	}
+	if time.Since(started) > 10*time.Second {
+		t.Fatal("expected the delayed prompt to be abandoned on cancel")
+	}
+}
+
+func TestMockProviderResumesRequestedSession(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name:    "resume",
+		Prompts: []MockPrompt{{Response: "continued"}},
+	})
+	events, result, err := provider.RunIteration(context.Background(), IterationRequest{
+		WorkDir:         t.TempDir(),
+		Prompt:          "go on",
+		ResumeSessionID: "sess-from-checkpoint",
+	})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	collectEvents(events)
+	if result.ProviderRunID != "sess-from-checkpoint" {
+		t.Fatalf("expected the requested session to be resumed, got %q", result.ProviderRunID)
+	}
+}
+
+func TestLoadMockScenarioParsesJSONAndRejectsUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	path := writeMockScenario(t, "errors.json", `{"session_id":"s-1","prompts":[{"response":"ok","delay":"10ms"}]}`)
+	scenario, err := LoadMockScenario(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if scenario.Name != "errors" || scenario.SessionID != "s-1" || len(scenario.Prompts) != 1 {
+		t.Fatalf("unexpected scenario: %+v", scenario)
+	}
+
+	if _, err := LoadMockScenario(writeMockScenario(t, "typo.yaml", "prompts:\n  - respnse: ok\n")); err == nil {
+		t.Fatal("expected unknown field to be rejected")
+	}
+	if _, err := LoadMockScenario(writeMockScenario(t, "escape.yaml", "prompts:\n  - updates:\n      - write: {path: ../outside.txt, content: x}\n")); err == nil {
+		t.Fatal("expected write outside the work dir to be rejected")
+	}
+}
+
+func TestRunACPDoctorProbesMockProvider(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	cfg := mockConfig("")
+	report, err := RunACPDoctor(context.Background(), cfg, []string{"mock"})
+	if err != nil {
+		t.Fatalf("run doctor: %v", err)
+	}
+	if len(report.Checks) != 1 || !report.Checks[0].Healthy {
+		t.Fatalf("expected healthy mock check, got %+v", report.Checks)
+	}
+	if report.Checks[0].BinaryPath != "(in-process)" {
+		t.Fatalf("unexpected binary path: %q", report.Checks[0].BinaryPath)
+	}
+
+	cfg = mockConfig(writeMockScenario(t, "broken.yaml", "session_error: \"not logged in\"\n"))
+	report, err = RunACPDoctor(context.Background(), cfg, []string{"mock"})
+	if err != nil {
+		t.Fatalf("run doctor: %v", err)
+	}
+	if report.Healthy() || !strings.Contains(report.Checks[0].Message, "not logged in") {
+		t.Fatalf("expected scripted session failure, got %+v", report.Checks[0])
+	}
+}
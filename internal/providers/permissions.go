@@ -0,0 +1,394 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/config"
+)
+
+// Permission decision sources.
+const (
+	PermissionSourcePolicy     = "policy"
+	PermissionSourceDefault    = "default"
+	PermissionSourceUser       = "user"
+	PermissionSourceRemembered = "remembered"
+)
+
+// PermissionOption is one of the answers the agent offers. Kind is one of
+// allow_once, allow_always, reject_once, or reject_always.
+type PermissionOption struct {
+	ID   string
+	Name string
+	Kind string
+}
+
+// PermissionRequest is an ACP session/request_permission call from the agent.
+type PermissionRequest struct {
+	SessionID  string
+	WorkDir    string
+	ToolCallID string
+	// Title is the agent's description of the tool call, e.g. "Edit main.go".
+	Title string
+	// Kind is the ACP tool kind: read, edit, delete, move, search, execute,
+	// think, fetch, or other.
+	Kind    string
+	Command string
+	Paths   []string
+	Options []PermissionOption
+}
+
+// Subject is a short human-readable description of what is being requested.
+func (r PermissionRequest) Subject() string {
+	subject := firstNonEmpty(r.Title, r.Command, r.Kind, "tool call")
+	if r.Command != "" && r.Title != "" && !strings.Contains(r.Title, r.Command) {
+		subject += ": " + r.Command
+	}
+	return subject
+}
+
+// ResolvedPaths returns Paths resolved against WorkDir, as the policy sees
+// them.
+func (r PermissionRequest) ResolvedPaths() []string {
+	resolved := make([]string, 0, len(r.Paths))
+	for _, path := range r.Paths {
+		resolved = append(resolved, resolvePermissionPath(r.WorkDir, path))
+	}
+	return resolved
+}
+
+// PermissionDecision is the answer to a PermissionRequest.
+type PermissionDecision struct {
+	Allow bool
+	// Remember applies the decision to matching requests for the rest of the
+	// session.
+	Remember bool
+	Source   string
+	Reason   string
+}
+
+// Approver answers permission requests from agents. Implementations may block
+// (e.g. waiting for a user) but must return when ctx is done.
+type Approver interface {
+	RequestPermission(ctx context.Context, request PermissionRequest) (PermissionDecision, error)
+}
+
+// PermissionPolicy auto-answers permission requests.
+//
+// A request is denied when any deny pattern matches its title, command, or one
+// of its paths, or when a path lies outside the work dir (relative paths are
+// resolved against it first). It is allowed when it is allow-listed: its kind
+// or title matches AllowTools (if set) and all of its paths match AllowPaths
+// (if set). Everything else gets Default.
+type PermissionPolicy struct {
+	AllowTools   []string
+	AllowPaths   []string
+	DenyPatterns []string
+	// Default is "allow" or "deny". Empty means allow.
+	Default string
+}
+
+// Evaluate applies the deny and allow rules. matched is false when neither
+// applies and the request would fall through to the default.
+func (p PermissionPolicy) Evaluate(request PermissionRequest) (decision PermissionDecision, matched bool) {
+	for _, pattern := range p.DenyPatterns {
+		if permissionMatches(pattern, request) {
+			return PermissionDecision{Allow: false, Source: PermissionSourcePolicy, Reason: "matches deny pattern " + pattern}, true
+		}
+	}
+	for _, path := range request.Paths {
+		if request.WorkDir != "" && !pathWithin(permissionRoot(request.WorkDir), resolvePermissionPath(request.WorkDir, path)) {
+			return PermissionDecision{Allow: false, Source: PermissionSourcePolicy, Reason: "path " + path + " is outside the work dir"}, true
+		}
+	}
+
+	if len(p.AllowTools) == 0 && len(p.AllowPaths) == 0 {
+		return PermissionDecision{}, false
+	}
+	if len(p.AllowTools) > 0 && !p.toolAllowed(request) {
+		return PermissionDecision{}, false
+	}
+	if len(p.AllowPaths) > 0 {
+		if len(request.Paths) == 0 {
+			return PermissionDecision{}, false
+		}
+		for _, path := range request.Paths {
+			if !matchesAnyPath(p.AllowPaths, relativeToWorkDir(request.WorkDir, path)) {
+				return PermissionDecision{}, false
+			}
+		}
+	}
+	return PermissionDecision{Allow: true, Source: PermissionSourcePolicy, Reason: "allow-listed"}, true
+}
+
+// Decide evaluates the policy and falls back to Default.
+func (p PermissionPolicy) Decide(request PermissionRequest) PermissionDecision {
+	if decision, matched := p.Evaluate(request); matched {
+		return decision
+	}
+	if strings.EqualFold(strings.TrimSpace(p.Default), "deny") {
+		return PermissionDecision{Allow: false, Source: PermissionSourceDefault, Reason: "not allow-listed"}
+	}
+	return PermissionDecision{Allow: true, Source: PermissionSourceDefault, Reason: "default allow"}
+}
+
+func (p PermissionPolicy) toolAllowed(request PermissionRequest) bool {
+	for _, tool := range p.AllowTools {
+		tool = strings.TrimSpace(tool)
+		if strings.EqualFold(tool, request.Kind) || globMatch(tool, request.Title, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyApprover answers every request from a PermissionPolicy. It is used for
+// headless runs.
+type PolicyApprover struct {
+	Policy PermissionPolicy
+}
+
+func NewPolicyApprover(policy PermissionPolicy) PolicyApprover {
+	return PolicyApprover{Policy: policy}
+}
+
+func (a PolicyApprover) RequestPermission(_ context.Context, request PermissionRequest) (PermissionDecision, error) {
+	return a.Policy.Decide(request), nil
+}
+
+func permissionMatches(pattern string, request PermissionRequest) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+	if globMatch(pattern, request.Command, false) || globMatch(pattern, request.Title, false) {
+		return true
+	}
+	for _, path := range request.Paths {
+		if globMatch(pattern, relativeToWorkDir(request.WorkDir, path), true) || globMatch(pattern, path, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyPath(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if globMatch(strings.TrimSpace(pattern), path, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func relativeToWorkDir(workDir, path string) string {
+	if workDir == "" {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	rel, err := filepath.Rel(permissionRoot(workDir), resolvePermissionPath(workDir, path))
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// resolvePermissionPath resolves path against workDir, as resolveWorkDirPath
+// does for file requests, so "../" cannot step out of the work dir unseen.
+func resolvePermissionPath(workDir, path string) string {
+	if workDir == "" {
+		return filepath.Clean(path)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(permissionRoot(workDir), path)
+	}
+	return filepath.Clean(path)
+}
+
+func permissionRoot(workDir string) string {
+	if root, err := filepath.Abs(workDir); err == nil {
+		return root
+	}
+	return filepath.Clean(workDir)
+}
+
+// globMatch matches value against a shell-style pattern. In path mode "*"
+// stops at "/" and "**" crosses directories; otherwise "*" matches anything.
+// A path pattern without "/" also matches the base name.
+func globMatch(pattern, value string, pathMode bool) bool {
+	if pattern == "" || value == "" {
+		return false
+	}
+	var builder strings.Builder
+	builder.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if pathMode && strings.HasPrefix(pattern[i:], "**/") {
+				// "**/" also matches no directories at all.
+				builder.WriteString("(.*/)?")
+				i += 2
+			} else if pathMode && strings.HasPrefix(pattern[i:], "**") {
+				builder.WriteString(".*")
+				i++
+			} else if pathMode {
+				builder.WriteString("[^/]*")
+			} else {
+				builder.WriteString(".*")
+			}
+		case '?':
+			if pathMode {
+				builder.WriteString("[^/]")
+			} else {
+				builder.WriteString(".")
+			}
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	builder.WriteString("$")
+	expression, err := regexp.Compile(builder.String())
+	if err != nil {
+		return false
+	}
+	if expression.MatchString(value) {
+		return true
+	}
+	if pathMode && !strings.Contains(pattern, "/") {
+		return expression.MatchString(filepath.Base(value))
+	}
+	return false
+}
+
+// ACP session/request_permission wire types.
+
+type acpPermissionParams struct {
+	SessionID string              `json:"sessionId"`
+	ToolCall  acpPermissionTool   `json:"toolCall"`
+	Options   []acpPermissionOpts `json:"options"`
+}
+
+type acpPermissionTool struct {
+	ToolCallID string                  `json:"toolCallId"`
+	Title      string                  `json:"title"`
+	Kind       string                  `json:"kind"`
+	Locations  []acpPermissionLocation `json:"locations"`
+	RawInput   json.RawMessage         `json:"rawInput"`
+}
+
+type acpPermissionLocation struct {
+	Path string `json:"path"`
+}
+
+type acpPermissionOpts struct {
+	OptionID string `json:"optionId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+}
+
+type acpPermissionResult struct {
+	Outcome acpPermissionOutcome `json:"outcome"`
+}
+
+type acpPermissionOutcome struct {
+	Outcome  string `json:"outcome"`
+	OptionID string `json:"optionId,omitempty"`
+}
+
+func parsePermissionRequest(raw json.RawMessage, workDir string) (PermissionRequest, error) {
+	var params acpPermissionParams
+	if err := decodeAgentParams(raw, &params); err != nil {
+		return PermissionRequest{}, err
+	}
+	request := PermissionRequest{
+		SessionID:  params.SessionID,
+		WorkDir:    workDir,
+		ToolCallID: params.ToolCall.ToolCallID,
+		Title:      strings.TrimSpace(params.ToolCall.Title),
+		Kind:       strings.ToLower(strings.TrimSpace(params.ToolCall.Kind)),
+		Command:    commandFromRawInput(params.ToolCall.RawInput),
+	}
+	for _, location := range params.ToolCall.Locations {
+		if path := strings.TrimSpace(location.Path); path != "" {
+			request.Paths = append(request.Paths, path)
+		}
+	}
+	for _, option := range params.Options {
+		request.Options = append(request.Options, PermissionOption{ID: option.OptionID, Name: option.Name, Kind: option.Kind})
+	}
+	return request, nil
+}
+
+// commandFromRawInput extracts a shell command from the tool's raw input,
+// which agents send as {"command": "..."} or {"command": ["go", "test"]}.
+func commandFromRawInput(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var input map[string]interface{}
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return ""
+	}
+	switch command := firstKnownKey(input, "command", "cmd").(type) {
+	case string:
+		return strings.TrimSpace(command)
+	case []interface{}:
+		parts := make([]string, 0, len(command))
+		for _, part := range command {
+			if text, ok := part.(string); ok {
+				parts = append(parts, text)
+			}
+		}
+		return strings.TrimSpace(strings.Join(parts, " "))
+	}
+	return ""
+}
+
+// requestPermission asks the iteration's approver. Approver errors, including
+// cancellation, are answered as cancelled so the agent stops the tool call.
+func (s *acpSessionState) requestPermission(ctx context.Context, request PermissionRequest) acpPermissionOutcome {
+	approver := s.approver
+	if approver == nil {
+		approver = NewPolicyApprover(PermissionPolicy{})
+	}
+	decision, err := approver.RequestPermission(ctx, request)
+	if err != nil {
+		return acpPermissionOutcome{Outcome: "cancelled"}
+	}
+	return permissionOutcome(request, decision)
+}
+
+// permissionOutcome picks the offered option that matches decision. A denial
+// with no reject option is answered as cancelled.
+func permissionOutcome(request PermissionRequest, decision PermissionDecision) acpPermissionOutcome {
+	preferred := []string{"allow_once", "allow_always"}
+	if decision.Remember {
+		preferred = []string{"allow_always", "allow_once"}
+	}
+	if !decision.Allow {
+		preferred = []string{"reject_once", "reject_always"}
+		if decision.Remember {
+			preferred = []string{"reject_always", "reject_once"}
+		}
+	}
+	for _, kind := range preferred {
+		for _, option := range request.Options {
+			if option.Kind == kind {
+				return acpPermissionOutcome{Outcome: "selected", OptionID: option.ID}
+			}
+		}
+	}
+	return acpPermissionOutcome{Outcome: "cancelled"}
+}
+
+// PermissionPolicyFromConfig builds the policy from the [permissions] section.
+func PermissionPolicyFromConfig(cfg config.PermissionsConfig) PermissionPolicy {
+	return PermissionPolicy{
+		AllowTools:   cfg.AllowTools,
+		AllowPaths:   cfg.AllowPaths,
+		DenyPatterns: cfg.DenyPatterns,
+		Default:      cfg.Default,
+	}
+}
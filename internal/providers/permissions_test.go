@@ -0,0 +1,239 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPermissionPolicyDecide(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	policy := PermissionPolicy{
+		AllowTools:   []string{"read", "edit"},
+		AllowPaths:   []string{"internal/**", "*.md"},
+		DenyPatterns: []string{"git push --force*", "**/.env"},
+		Default:      "deny",
+	}
+
+	tests := []struct {
+		name    string
+		request PermissionRequest
+		allow   bool
+		source  string
+	}{
+		{
+			name:    "allow-listed tool and path",
+			request: PermissionRequest{Kind: "edit", Paths: []string{filepath.Join(workDir, "internal", "app", "app.go")}},
+			allow:   true,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "base name pattern",
+			request: PermissionRequest{Kind: "read", Paths: []string{filepath.Join(workDir, "docs", "README.md")}},
+			allow:   true,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "deny pattern on command",
+			request: PermissionRequest{Kind: "execute", Command: "git push --force origin main"},
+			allow:   false,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "deny pattern beats allow list",
+			request: PermissionRequest{Kind: "edit", Paths: []string{filepath.Join(workDir, "internal", ".env")}},
+			allow:   false,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "outside the work dir",
+			request: PermissionRequest{Kind: "read", Paths: []string{"/etc/passwd"}},
+			allow:   false,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "relative path escaping the work dir",
+			request: PermissionRequest{Kind: "read", Paths: []string{"../../.ssh/id_rsa"}},
+			allow:   false,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "relative path climbing out through an allowed directory",
+			request: PermissionRequest{Kind: "edit", Paths: []string{"internal/../../x/internal/a.go"}},
+			allow:   false,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "relative path inside the work dir",
+			request: PermissionRequest{Kind: "edit", Paths: []string{"internal/app/../loop/manager.go"}},
+			allow:   true,
+			source:  PermissionSourcePolicy,
+		},
+		{
+			name:    "tool not allow-listed falls to default",
+			request: PermissionRequest{Kind: "execute", Command: "go test ./..."},
+			allow:   false,
+			source:  PermissionSourceDefault,
+		},
+		{
+			name:    "path not allow-listed falls to default",
+			request: PermissionRequest{Kind: "edit", Paths: []string{filepath.Join(workDir, "go.mod")}},
+			allow:   false,
+			source:  PermissionSourceDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := tt.request
+			request.WorkDir = workDir
+			decision := policy.Decide(request)
+			if decision.Allow != tt.allow || decision.Source != tt.source {
+				t.Fatalf("expected allow=%v source=%s, got %+v", tt.allow, tt.source, decision)
+			}
+		})
+	}
+
+	if decision := (PermissionPolicy{}).Decide(PermissionRequest{Kind: "execute", Command: "make"}); !decision.Allow {
+		t.Fatalf("expected empty policy to allow by default, got %+v", decision)
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		value   string
+		path    bool
+		want    bool
+	}{
+		{"internal/*", "internal/app.go", true, true},
+		{"internal/*", "internal/app/app.go", true, false},
+		{"internal/**", "internal/app/app.go", true, true},
+		{"**/*.go", "main.go", true, true},
+		{"**/*.go", "cmd/daedalus/main.go", true, true},
+		{"**/.env", "config.env", true, false},
+		{"rm -rf *", "rm -rf /", false, true},
+		{"rm -rf *", "rm -f a", false, false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.value, tt.path); got != tt.want {
+			t.Errorf("globMatch(%q, %q, %v) = %v, want %v", tt.pattern, tt.value, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPermissionOutcomePicksMatchingOption(t *testing.T) {
+	t.Parallel()
+
+	request := PermissionRequest{Options: []PermissionOption{
+		{ID: "once", Kind: "allow_once"},
+		{ID: "always", Kind: "allow_always"},
+		{ID: "no", Kind: "reject_once"},
+	}}
+	tests := []struct {
+		decision PermissionDecision
+		want     acpPermissionOutcome
+	}{
+		{PermissionDecision{Allow: true}, acpPermissionOutcome{Outcome: "selected", OptionID: "once"}},
+		{PermissionDecision{Allow: true, Remember: true}, acpPermissionOutcome{Outcome: "selected", OptionID: "always"}},
+		{PermissionDecision{Remember: true}, acpPermissionOutcome{Outcome: "selected", OptionID: "no"}},
+	}
+	for _, tt := range tests {
+		if got := permissionOutcome(request, tt.decision); got != tt.want {
+			t.Errorf("permissionOutcome(%+v) = %+v, want %+v", tt.decision, got, tt.want)
+		}
+	}
+
+	allowOnly := PermissionRequest{Options: []PermissionOption{{ID: "once", Kind: "allow_once"}}}
+	if got := permissionOutcome(allowOnly, PermissionDecision{}); got.Outcome != "cancelled" {
+		t.Fatalf("expected denial without reject option to cancel, got %+v", got)
+	}
+}
+
+type recordingApprover struct {
+	mu       sync.Mutex
+	requests []PermissionRequest
+	decide   func(PermissionRequest) PermissionDecision
+}
+
+func (a *recordingApprover) RequestPermission(_ context.Context, request PermissionRequest) (PermissionDecision, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = append(a.requests, request)
+	return a.decide(request), nil
+}
+
+func mockPermissionCall(title, kind, path string) *MockCall {
+	return &MockCall{Method: "session/request_permission", Params: map[string]interface{}{
+		"toolCall": map[string]interface{}{
+			"toolCallId": "call-" + path,
+			"title":      title,
+			"kind":       kind,
+			"locations":  []interface{}{map[string]interface{}{"path": path}},
+		},
+		"options": []interface{}{
+			map[string]interface{}{"optionId": "allow", "name": "Allow", "kind": "allow_once"},
+			map[string]interface{}{"optionId": "reject", "name": "Reject", "kind": "reject_once"},
+		},
+	}}
+}
+
+func TestACPProviderRoutesPermissionRequestsToApprover(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name: "permissions",
+		Prompts: []MockPrompt{{
+			Updates: []MockUpdate{
+				{Call: mockPermissionCall("Edit allowed.txt", "edit", "allowed.txt")},
+				{Write: &MockFileWrite{Path: "allowed.txt", Content: "ok"}},
+				{Call: mockPermissionCall("Edit secret.txt", "edit", "secret.txt")},
+				{Write: &MockFileWrite{Path: "secret.txt", Content: "leaked"}},
+			},
+			Response: "done",
+		}},
+	})
+
+	approver := &recordingApprover{decide: func(request PermissionRequest) PermissionDecision {
+		return PermissionDecision{Allow: request.Paths[0] != "secret.txt", Source: PermissionSourceUser}
+	}}
+	workDir := t.TempDir()
+	events, _, err := provider.RunIteration(context.Background(), IterationRequest{WorkDir: workDir, Prompt: "go", Approver: approver})
+	if err != nil {
+		t.Fatalf("run iteration: %v", err)
+	}
+	collectEvents(events)
+
+	if len(approver.requests) != 2 {
+		t.Fatalf("expected 2 permission requests, got %+v", approver.requests)
+	}
+	first := approver.requests[0]
+	if first.Title != "Edit allowed.txt" || first.Kind != "edit" || first.WorkDir != workDir || len(first.Options) != 2 {
+		t.Fatalf("unexpected parsed request: %+v", first)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "allowed.txt")); err != nil {
+		t.Fatalf("expected approved write to happen: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "secret.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected denied write to be skipped, got %v", err)
+	}
+}
+
+func TestCommandFromRawInput(t *testing.T) {
+	t.Parallel()
+
+	if got := commandFromRawInput([]byte(`{"command":"go test ./..."}`)); got != "go test ./..." {
+		t.Fatalf("unexpected string command %q", got)
+	}
+	if got := commandFromRawInput([]byte(`{"command":["git","status"]}`)); got != "git status" {
+		t.Fatalf("unexpected argv command %q", got)
+	}
+	if got := commandFromRawInput(nil); got != "" {
+		t.Fatalf("expected empty command, got %q", got)
+	}
+}
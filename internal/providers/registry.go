@@ -19,6 +19,7 @@ var knownProviderKeys = []string{
 	"copilot",
 	"qwen",
 	"pi",
+	"mock",
 }
 
 // KnownProviderKeys returns the canonical provider keys supported by Daedalus.
@@ -36,6 +37,7 @@ func NewRegistry() Registry {
 			"copilot":  func(cfg config.Config) Provider { return newACPProvider(cfg, "copilot") },
 			"qwen":     func(cfg config.Config) Provider { return newACPProvider(cfg, "qwen") },
 			"pi":       func(cfg config.Config) Provider { return newACPProvider(cfg, "pi") },
+			"mock":     newMockProvider,
 		},
 	}
 }
@@ -73,6 +75,8 @@ func providerEnabled(cfg config.Config, key string) bool {
 		return cfg.Providers.Qwen.Enabled
 	case "pi":
 		return cfg.Providers.Pi.Enabled
+	case "mock":
+		return cfg.Providers.Mock.Enabled
 	default:
 		return false
 	}
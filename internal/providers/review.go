@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Review finding severities, from most to least severe.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+// ReviewSeverities lists the known severities from most to least severe.
+var ReviewSeverities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// ReviewFinding is a single issue reported by a review perspective.
+type ReviewFinding struct {
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String renders the finding on one line, e.g.
+// "[high] store/query.go:42 sql-injection: query built with string concatenation".
+func (f ReviewFinding) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("[")
+	builder.WriteString(NormalizeSeverity(f.Severity))
+	builder.WriteString("] ")
+	if f.File != "" {
+		builder.WriteString(f.File)
+		if f.Line > 0 {
+			builder.WriteString(":")
+			builder.WriteString(strconv.Itoa(f.Line))
+		}
+		builder.WriteString(" ")
+	}
+	if f.Rule != "" {
+		builder.WriteString(f.Rule)
+		builder.WriteString(": ")
+	}
+	builder.WriteString(f.Message)
+	return strings.TrimSpace(builder.String())
+}
+
+// NormalizeSeverity maps the many spellings agents use for severity onto the
+// five known levels. Unknown or empty values are treated as medium.
+func NormalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "blocker", "fatal":
+		return SeverityCritical
+	case "high", "error", "major", "severe":
+		return SeverityHigh
+	case "medium", "moderate", "warning", "warn":
+		return SeverityMedium
+	case "low", "minor":
+		return SeverityLow
+	case "info", "informational", "note", "nit", "suggestion", "style":
+		return SeverityInfo
+	default:
+		return SeverityMedium
+	}
+}
+
+// IsReviewSeverity reports whether severity is one of the known levels.
+func IsReviewSeverity(severity string) bool {
+	return severityRank(strings.ToLower(strings.TrimSpace(severity))) >= 0
+}
+
+// SeverityAtLeast reports whether severity is as severe as threshold or more.
+// An unknown threshold blocks on every finding.
+func SeverityAtLeast(severity, threshold string) bool {
+	thresholdRank := severityRank(strings.ToLower(strings.TrimSpace(threshold)))
+	if thresholdRank < 0 {
+		return true
+	}
+	return severityRank(NormalizeSeverity(severity)) <= thresholdRank
+}
+
+// severityRank returns 0 for critical through 4 for info, or -1 when unknown.
+func severityRank(severity string) int {
+	for i, known := range ReviewSeverities {
+		if severity == known {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,38 @@
+package providers
+
+import "testing"
+
+func TestSeverityAtLeastRanksSeverities(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		severity  string
+		threshold string
+		want      bool
+	}{
+		{"critical", "high", true},
+		{"high", "high", true},
+		{"medium", "high", false},
+		{"warning", "medium", true},
+		{"nit", "low", false},
+		{"info", "info", true},
+		{"low", "bogus", true},
+	}
+	for _, tc := range cases {
+		if got := SeverityAtLeast(tc.severity, tc.threshold); got != tc.want {
+			t.Fatalf("SeverityAtLeast(%q, %q) = %v, want %v", tc.severity, tc.threshold, got, tc.want)
+		}
+	}
+}
+
+func TestReviewFindingString(t *testing.T) {
+	t.Parallel()
+
+	finding := ReviewFinding{Severity: "error", File: "a.go", Line: 3, Rule: "nil-check", Message: "possible nil dereference"}
+	if got := finding.String(); got != "[high] a.go:3 nil-check: possible nil dereference" {
+		t.Fatalf("unexpected finding string: %q", got)
+	}
+	if got := (ReviewFinding{Message: "vague"}).String(); got != "[medium] vague" {
+		t.Fatalf("unexpected finding string without location: %q", got)
+	}
+}
@@ -12,6 +12,10 @@ const (
 	EventCommandOutput    EventType = "command_output"
 	EventIterationDone    EventType = "iteration_finished"
 	EventError            EventType = "error"
+	EventPermission       EventType = "permission_decision"
+	EventProviderFallback EventType = "provider_fallback"
+	EventContextWarning   EventType = "context_warning"
+	EventUsage            EventType = "usage"
 )
 
 type Event struct {
@@ -27,12 +31,23 @@ type IterationRequest struct {
 	SandboxPolicy  string
 	Model          string
 	Metadata       map[string]string
+	// Approver answers the agent's permission requests. Nil applies the
+	// default PermissionPolicy.
+	Approver Approver
+	// ResumeSessionID asks the provider to resume this session when it has
+	// no live session for WorkDir, e.g. after the process restarted.
+	ResumeSessionID string
 }
 
 type IterationResult struct {
-	Success       bool
-	Summary       string
+	Success bool
+	Summary string
+	// ProviderRunID identifies the provider session that ran the iteration;
+	// for ACP providers it is the session ID.
 	ProviderRunID string
+	// Usage is the iteration's token usage and cost. Providers stream it as
+	// an EventUsage event; the loop fills it in once the events are drained.
+	Usage Usage
 }
 
 type Capabilities struct {
@@ -43,6 +58,9 @@ type Capabilities struct {
 	ModelSelection  bool
 	SupportedModels []string
 	MaxContextHint  int
+	// EmbeddedContext reports that the agent accepts "resource" content
+	// blocks, so context files need not be inlined into the prompt text.
+	EmbeddedContext bool
 }
 
 type Provider interface {
@@ -54,7 +72,14 @@ type Provider interface {
 // PerspectiveReview is the result of a single review perspective (e.g., security, performance).
 type PerspectiveReview struct {
 	Perspective string
-	Findings    []string
-	Duration    string
-	Error       string
+	Findings    []ReviewFinding
+	// Blocked is true when at least one finding meets the review's fail_on
+	// severity. Findings below the threshold are reported but do not block.
+	Blocked  bool
+	Duration string
+	Error    string
+	// Provider, Model, and Usage describe the review iteration, when it ran.
+	Provider string
+	Model    string
+	Usage    *Usage
 }
@@ -0,0 +1,159 @@
+package providers
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Usage is the token usage and cost of one iteration.
+type Usage struct {
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	CostUSD      float64 `json:"costUSD"`
+	// Estimated is set when the agent did not report token counts and they
+	// were estimated from the prompt and response length.
+	Estimated bool `json:"estimated,omitempty"`
+	// CostEstimated is set when the agent did not report a cost and it was
+	// computed from the provider's configured prices.
+	CostEstimated bool `json:"costEstimated,omitempty"`
+}
+
+// TotalTokens returns input plus output tokens.
+func (u Usage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+// Add accumulates other into u. The result is estimated when either side is.
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CostUSD += other.CostUSD
+	u.Estimated = u.Estimated || other.Estimated
+	u.CostEstimated = u.CostEstimated || other.CostEstimated
+}
+
+// EncodeEventUsage formats usage as the message of an EventUsage event.
+func EncodeEventUsage(usage Usage) string {
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DecodeEventUsage parses the message of an EventUsage event.
+func DecodeEventUsage(message string) (Usage, bool) {
+	var usage Usage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(message)), &usage); err != nil {
+		return Usage{}, false
+	}
+	return usage, true
+}
+
+// TokenPrice is what a provider charges, in USD per million tokens.
+type TokenPrice struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+func (p TokenPrice) cost(usage Usage) float64 {
+	return (float64(usage.InputTokens)*p.InputPerMTok + float64(usage.OutputTokens)*p.OutputPerMTok) / 1e6
+}
+
+// estimateTokens approximates the token count of text from its length.
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + bytesPerToken - 1) / bytesPerToken
+}
+
+// promptBlocksText returns the text an agent reads from prompt blocks,
+// including embedded resources.
+func promptBlocksText(blocks []acpContentBlock) string {
+	var builder strings.Builder
+	for _, block := range blocks {
+		builder.WriteString(block.Text)
+		if block.Resource != nil {
+			builder.WriteString(block.Resource.Text)
+		}
+	}
+	return builder.String()
+}
+
+// parseReportedUsage reads usage from a session/prompt result or a
+// session/update payload. Agents report it in different shapes, so it looks
+// for a "usage" object (at the top level, under "update", or under "_meta")
+// with input/output token counts, and for a cost given either as a number or
+// as {"amount": n, "currency": "USD"}. ok is false when nothing was found.
+func parseReportedUsage(raw json.RawMessage) (usage Usage, ok bool) {
+	if len(raw) == 0 {
+		return Usage{}, false
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return Usage{}, false
+	}
+
+	nodes := []map[string]interface{}{root}
+	for _, key := range []string{"update", "_meta"} {
+		if nested, isMap := root[key].(map[string]interface{}); isMap {
+			nodes = append(nodes, nested)
+		}
+	}
+	for _, node := range nodes {
+		candidates := []map[string]interface{}{node}
+		if nested, isMap := node["usage"].(map[string]interface{}); isMap {
+			candidates = append(candidates, nested)
+		}
+		for _, candidate := range candidates {
+			if value, found := intFromAny(firstKnownKey(candidate, "inputTokens", "input_tokens", "promptTokens", "prompt_tokens")); found {
+				usage.InputTokens = value
+				ok = true
+			}
+			if value, found := intFromAny(firstKnownKey(candidate, "outputTokens", "output_tokens", "completionTokens", "completion_tokens")); found {
+				usage.OutputTokens = value
+				ok = true
+			}
+			if value, found := costFromAny(firstKnownKey(candidate, "cost", "costUSD", "cost_usd", "total_cost_usd")); found {
+				usage.CostUSD = value
+				ok = true
+			}
+		}
+	}
+	return usage, ok
+}
+
+func costFromAny(value interface{}) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case map[string]interface{}:
+		currency, _ := typed["currency"].(string)
+		if currency != "" && !strings.EqualFold(currency, "USD") {
+			return 0, false
+		}
+		amount, ok := typed["amount"].(float64)
+		return amount, ok
+	}
+	return 0, false
+}
+
+// iterationUsage returns the usage the agent reported for a turn, filling in
+// estimates for whatever it left out.
+func iterationUsage(reported *Usage, prompt, response string, price TokenPrice) Usage {
+	usage := Usage{}
+	if reported != nil {
+		usage = *reported
+	}
+	if usage.TotalTokens() == 0 {
+		usage.InputTokens = estimateTokens(prompt)
+		usage.OutputTokens = estimateTokens(response)
+		usage.Estimated = true
+	}
+	if usage.CostUSD == 0 && price != (TokenPrice{}) {
+		usage.CostUSD = price.cost(usage)
+		usage.CostEstimated = true
+	}
+	return usage
+}
@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParseReportedUsageAcceptsCommonShapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		raw  string
+		want Usage
+	}{
+		{name: "prompt result", raw: `{"stopReason":"end_turn","usage":{"inputTokens":1200,"outputTokens":300,"cost":{"amount":0.02,"currency":"USD"}}}`, want: Usage{InputTokens: 1200, OutputTokens: 300, CostUSD: 0.02}},
+		{name: "snake case", raw: `{"usage":{"input_tokens":10,"output_tokens":5},"total_cost_usd":0.5}`, want: Usage{InputTokens: 10, OutputTokens: 5, CostUSD: 0.5}},
+		{name: "session update", raw: `{"sessionId":"s","update":{"sessionUpdate":"usage_update","cost":{"amount":1.25,"currency":"USD"}}}`, want: Usage{CostUSD: 1.25}},
+		{name: "meta", raw: `{"_meta":{"usage":{"promptTokens":7,"completionTokens":3}}}`, want: Usage{InputTokens: 7, OutputTokens: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseReportedUsage(json.RawMessage(tt.raw))
+			if !ok || got != tt.want {
+				t.Fatalf("parseReportedUsage = %+v (%v), want %+v", got, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := parseReportedUsage(json.RawMessage(`{"cost":{"amount":3,"currency":"EUR"}}`)); ok {
+		t.Fatal("expected non-USD cost to be ignored")
+	}
+	if _, ok := parseReportedUsage(json.RawMessage(`{"stopReason":"end_turn"}`)); ok {
+		t.Fatal("expected no usage in a plain result")
+	}
+}
+
+func TestIterationUsageEstimatesMissingValues(t *testing.T) {
+	t.Parallel()
+
+	price := TokenPrice{InputPerMTok: 3, OutputPerMTok: 15}
+	estimated := iterationUsage(nil, "12345678", "1234", price)
+	if estimated.InputTokens != 2 || estimated.OutputTokens != 1 || !estimated.Estimated || !estimated.CostEstimated {
+		t.Fatalf("unexpected estimate: %+v", estimated)
+	}
+	if want := (2*3.0 + 1*15.0) / 1e6; math.Abs(estimated.CostUSD-want) > 1e-12 {
+		t.Fatalf("expected cost %v, got %v", want, estimated.CostUSD)
+	}
+
+	reported := iterationUsage(&Usage{InputTokens: 100, OutputTokens: 50, CostUSD: 0.1}, "prompt", "response", price)
+	if reported.Estimated || reported.CostEstimated || reported.CostUSD != 0.1 {
+		t.Fatalf("expected reported usage to be kept, got %+v", reported)
+	}
+}
+
+func TestMockProviderStreamsUsage(t *testing.T) {
+	t.Cleanup(CloseAllSessions)
+
+	provider := NewMockProvider(MockScenario{
+		Name: "usage",
+		Prompts: []MockPrompt{
+			{Match: "reported", Response: "done", Usage: &MockUsage{InputTokens: 900, OutputTokens: 100, CostUSD: 0.012}},
+			{Match: "estimated", Response: "done"},
+		},
+	})
+
+	usageOf := func(prompt string) Usage {
+		t.Helper()
+		events, _, err := provider.RunIteration(context.Background(), IterationRequest{WorkDir: t.TempDir(), Prompt: prompt})
+		if err != nil {
+			t.Fatalf("run iteration: %v", err)
+		}
+		for _, event := range collectEvents(events) {
+			if event.Type == EventUsage {
+				usage, ok := DecodeEventUsage(event.Message)
+				if !ok {
+					t.Fatalf("undecodable usage event: %q", event.Message)
+				}
+				return usage
+			}
+		}
+		t.Fatalf("expected a usage event for %q", prompt)
+		return Usage{}
+	}
+
+	if got := usageOf("reported usage"); got != (Usage{InputTokens: 900, OutputTokens: 100, CostUSD: 0.012}) {
+		t.Fatalf("unexpected reported usage: %+v", got)
+	}
+	if got := usageOf("estimated usage"); !got.Estimated || got.InputTokens == 0 || got.OutputTokens == 0 || got.CostEstimated {
+		t.Fatalf("unexpected estimated usage: %+v", got)
+	}
+}
@@ -0,0 +1,164 @@
+package quality
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Check is one quality command of the gate.
+type Check struct {
+	// Name identifies the check in depends_on and reports. It defaults to
+	// the command.
+	Name    string
+	Command string
+	// Group lets depends_on wait for several checks at once.
+	Group string
+	// DependsOn names the checks or groups that must finish first. When one
+	// of them fails, this check is skipped.
+	DependsOn []string
+	// Timeout overrides the runner's command timeout. Zero keeps it.
+	Timeout time.Duration
+	// AllowFailure reports a failure without failing the gate.
+	AllowFailure bool
+	// Format is the format of the command's test output (FormatGoTestJSON,
+	// FormatJUnit, or FormatTAP), parsed into per-test results. Empty keeps
+	// the raw output only.
+	Format string
+	// Report is the file, relative to the work dir, the command writes its
+	// test output to. When it is empty, or the command did not write it,
+	// stdout is parsed instead.
+	Report string
+	// Scope is ScopeAll (empty) or ScopeChanged; see ScopeChecks.
+	Scope string
+	// Unchanged is set by ScopeChecks when nothing the check covers
+	// changed. The check then passes without running.
+	Unchanged bool
+}
+
+// CommandChecks turns a plain command list into independent checks named
+// after their commands.
+func CommandChecks(commands []string) []Check {
+	checks := make([]Check, 0, len(commands))
+	seen := map[string]int{}
+	for _, command := range commands {
+		name := command
+		// Repeated commands still need distinct names.
+		if seen[command]++; seen[command] > 1 {
+			name = fmt.Sprintf("%s (%d)", command, seen[command])
+		}
+		checks = append(checks, Check{Name: name, Command: command})
+	}
+	return checks
+}
+
+// ValidateChecks reports empty or duplicate names and commands, unknown
+// formats and scopes, dependencies on unknown checks or groups, and dependency cycles.
+func ValidateChecks(checks []Check) error {
+	if len(checks) == 0 {
+		return fmt.Errorf("no quality commands configured")
+	}
+	names := map[string]struct{}{}
+	for _, check := range checks {
+		if strings.TrimSpace(check.Command) == "" {
+			return fmt.Errorf("quality command must not be empty")
+		}
+		if !ValidFormat(check.Format) {
+			return fmt.Errorf("quality check %q has unknown format %q", check.name(), check.Format)
+		}
+		if check.Report != "" && check.Format == "" {
+			return fmt.Errorf("quality check %q has a report but no format", check.name())
+		}
+		if !ValidScope(check.Scope) {
+			return fmt.Errorf("quality check %q has unknown scope %q", check.name(), check.Scope)
+		}
+		name := check.name()
+		if _, dup := names[name]; dup {
+			return fmt.Errorf("quality check %q is declared twice", name)
+		}
+		names[name] = struct{}{}
+	}
+	deps, err := resolveDependencies(checks)
+	if err != nil {
+		return err
+	}
+
+	// Depth-first search for a check that reaches itself.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(checks))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("quality check %q depends on itself", checks[i].name())
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, dep := range deps[i] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range checks {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Check) name() string {
+	if name := strings.TrimSpace(c.Name); name != "" {
+		return name
+	}
+	return c.Command
+}
+
+// resolveDependencies returns, for each check, the indexes of the checks it
+// waits for. A depends_on entry naming a group expands to every check in it.
+func resolveDependencies(checks []Check) ([][]int, error) {
+	byName := map[string]int{}
+	byGroup := map[string][]int{}
+	for i, check := range checks {
+		byName[check.name()] = i
+		if group := strings.TrimSpace(check.Group); group != "" {
+			byGroup[group] = append(byGroup[group], i)
+		}
+	}
+	deps := make([][]int, len(checks))
+	for i, check := range checks {
+		seen := map[int]struct{}{}
+		for _, dependency := range check.DependsOn {
+			dependency = strings.TrimSpace(dependency)
+			targets, ok := byGroup[dependency]
+			if index, named := byName[dependency]; named {
+				targets = append([]int{index}, targets...)
+				ok = true
+			}
+			if !ok {
+				return nil, fmt.Errorf("quality check %q depends on unknown check or group %q", check.name(), dependency)
+			}
+			for _, target := range targets {
+				if target == i {
+					// A check's own group does not make it wait for itself.
+					if _, named := byName[dependency]; !named {
+						continue
+					}
+				}
+				if _, dup := seen[target]; !dup {
+					seen[target] = struct{}{}
+					deps[i] = append(deps[i], target)
+				}
+			}
+		}
+	}
+	return deps, nil
+}
@@ -0,0 +1,139 @@
+package quality
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerRunsIndependentChecksConcurrently(t *testing.T) {
+	t.Parallel()
+
+	// Each check waits for the other's marker, so both pass only when they
+	// run at the same time.
+	wait := func(own, other string) string {
+		return "touch " + own + "; for i in $(seq 200); do [ -f " + other + " ] && exit 0; sleep 0.05; done; exit 1"
+	}
+	checks := []Check{
+		{Name: "left", Command: wait("left", "right")},
+		{Name: "right", Command: wait("right", "left")},
+	}
+	report, err := NewRunner().WithConcurrency(2).Run(context.Background(), t.TempDir(), checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !report.Passed {
+		t.Fatalf("expected both checks to run concurrently, got %+v", report.Results)
+	}
+	if report.Results[0].Name != "left" || report.Results[1].Name != "right" {
+		t.Fatalf("expected declared order, got %+v", report.Results)
+	}
+}
+
+func TestRunnerWaitsForDependenciesAndGroups(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{
+		{Name: "test", Command: "cat vet.out fmt.out", DependsOn: []string{"lint"}},
+		{Name: "vet", Command: "cat build.out > vet.out", Group: "lint", DependsOn: []string{"build"}},
+		{Name: "fmt", Command: "cat build.out > fmt.out", Group: "lint", DependsOn: []string{"build"}},
+		{Name: "build", Command: "echo built > build.out"},
+	}
+	report, err := NewRunner().WithConcurrency(4).Run(context.Background(), t.TempDir(), checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !report.Passed {
+		t.Fatalf("expected dependencies to run first, got %+v", report.Results)
+	}
+	for i, name := range []string{"test", "vet", "fmt", "build"} {
+		if report.Results[i].Name != name {
+			t.Fatalf("expected %s at %d, got %+v", name, i, report.Results)
+		}
+	}
+	if report.Results[0].Stdout != "built\nbuilt\n" || report.Results[1].Group != "lint" {
+		t.Fatalf("unexpected results %+v", report.Results)
+	}
+}
+
+func TestRunnerSkipsDependentsOfFailedChecksAndAllowsFailures(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{
+		{Name: "build", Command: "exit 3"},
+		{Name: "test", Command: "true", DependsOn: []string{"build"}},
+		{Name: "e2e", Command: "true", DependsOn: []string{"test"}},
+		{Name: "audit", Command: "exit 1", AllowFailure: true},
+		{Name: "docs", Command: "true", DependsOn: []string{"audit"}},
+	}
+	report, err := NewRunner().WithConcurrency(2).Run(context.Background(), "", checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if report.Passed {
+		t.Fatal("expected the failed build to fail the report")
+	}
+	build, test, e2e, audit, docs := report.Results[0], report.Results[1], report.Results[2], report.Results[3], report.Results[4]
+	if build.ExitCode != 3 || !build.Blocks() {
+		t.Fatalf("unexpected build result %+v", build)
+	}
+	if !test.Skipped || !strings.Contains(test.Stderr, `depends on "build"`) || !e2e.Skipped || !strings.Contains(e2e.Stderr, `depends on "test"`) {
+		t.Fatalf("expected dependents to be skipped, got %+v and %+v", test, e2e)
+	}
+	if audit.Passed() || audit.Blocks() || !docs.Passed() {
+		t.Fatalf("expected the allowed failure not to block, got %+v and %+v", audit, docs)
+	}
+
+	report, err = NewRunner().Run(context.Background(), "", checks[3:])
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !report.Passed {
+		t.Fatalf("expected only allowed failures to pass the report, got %+v", report.Results)
+	}
+}
+
+func TestRunnerUsesPerCheckTimeout(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{{Name: "slow", Command: "sleep 30", Timeout: time.Second}}
+	report, err := NewRunner().WithCommandTimeout(time.Hour).Run(context.Background(), "", checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !report.Results[0].TimedOut || !strings.Contains(report.Results[0].Stderr, "timed out after 1s") {
+		t.Fatalf("expected the check's own timeout, got %+v", report.Results[0])
+	}
+}
+
+func TestValidateChecks(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]Check{
+		"declared twice":       {{Name: "a", Command: "true"}, {Name: "a", Command: "false"}},
+		"unknown check":        {{Name: "a", Command: "true", DependsOn: []string{"b"}}},
+		"depends on itself":    {{Name: "a", Command: "true", DependsOn: []string{"b"}}, {Name: "b", Command: "true", Group: "g", DependsOn: []string{"a"}}},
+		"must not be empty":    {{Name: "a", Command: " "}},
+		"report but no format": {{Name: "a", Command: "true", Report: "junit.xml"}},
+		"no quality commands":  nil,
+	}
+	for want, checks := range cases {
+		err := ValidateChecks(checks)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q error, got %v", want, err)
+		}
+	}
+
+	// A check may depend on its own group; it waits for the other members.
+	grouped := []Check{
+		{Name: "a", Command: "true", Group: "g"},
+		{Name: "b", Command: "true", Group: "g", DependsOn: []string{"g"}},
+	}
+	if err := ValidateChecks(grouped); err != nil {
+		t.Fatalf("expected a group self-reference to be allowed: %v", err)
+	}
+	if got := CommandChecks([]string{"go test ./...", "go test ./..."}); got[1].Name != "go test ./... (2)" {
+		t.Fatalf("expected repeated commands to get distinct names, got %+v", got)
+	}
+}
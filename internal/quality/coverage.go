@@ -0,0 +1,241 @@
+package quality
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Coverage counts the statements (Go cover profiles) or lines (LCOV) a test
+// run covered.
+type Coverage struct {
+	Covered int
+	Total   int
+}
+
+// Percent returns the covered share, rounded to two decimals.
+func (c Coverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return roundPercent(float64(c.Covered) * 100 / float64(c.Total))
+}
+
+func roundPercent(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// CoverageGate fails the quality gate when total coverage is too low, or fell
+// too far since the story began.
+type CoverageGate struct {
+	// Profiles are the Go cover profiles and LCOV files a quality command
+	// writes, relative to the work dir. Glob patterns are allowed.
+	Profiles []string
+	// Min is the lowest total coverage, in percent. Zero disables it.
+	Min float64
+	// MaxDrop is how many percentage points coverage may fall below the
+	// baseline. Zero disables it.
+	MaxDrop float64
+}
+
+// Enabled reports whether the gate has profiles to read.
+func (g CoverageGate) Enabled() bool {
+	return len(g.Profiles) > 0
+}
+
+// CoverageResult is the outcome of the coverage gate.
+type CoverageResult struct {
+	Coverage Coverage
+	// Baseline is the coverage recorded before the story began, or nil when
+	// none was.
+	Baseline *Coverage
+	Min      float64
+	MaxDrop  float64
+	// Failure says why the gate failed; it is empty when it passed.
+	Failure string
+}
+
+// Passed reports whether the gate passed.
+func (r CoverageResult) Passed() bool {
+	return r.Failure == ""
+}
+
+// Delta returns the change from the baseline in percentage points, and false
+// when there is no baseline to compare with.
+func (r CoverageResult) Delta() (float64, bool) {
+	if r.Baseline == nil || r.Coverage.Total == 0 {
+		return 0, false
+	}
+	return roundPercent(r.Coverage.Percent() - r.Baseline.Percent()), true
+}
+
+// Check reads the gate's profiles in workDir and compares their coverage
+// with the minimum and with baseline. A profile not written since startedAt,
+// when the quality run began, fails the gate: it describes an older run.
+func (g CoverageGate) Check(workDir string, baseline *Coverage, startedAt time.Time) CoverageResult {
+	result := CoverageResult{Baseline: baseline, Min: g.Min, MaxDrop: g.MaxDrop}
+	coverage, err := ReadCoverage(workDir, g.Profiles, startedAt)
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	result.Coverage = coverage
+	percent := coverage.Percent()
+	if g.Min > 0 && percent < g.Min {
+		result.Failure = fmt.Sprintf("coverage %.2f%% is below the minimum of %.2f%%", percent, g.Min)
+		return result
+	}
+	if delta, ok := result.Delta(); ok && g.MaxDrop > 0 && -delta > g.MaxDrop {
+		result.Failure = fmt.Sprintf("coverage fell %.2f points from %.2f%% to %.2f%%, more than the allowed %.2f", -delta, baseline.Percent(), percent, g.MaxDrop)
+	}
+	return result
+}
+
+// ReadCoverage reads and merges the coverage profiles matching profiles in
+// workDir. Go cover profiles and LCOV files are told apart by their content,
+// so a repository can mix both. Unless startedAt is zero, a profile last
+// written before it is an error.
+func ReadCoverage(workDir string, profiles []string, startedAt time.Time) (Coverage, error) {
+	var paths []string
+	for _, profile := range profiles {
+		pattern := profile
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(workDir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return Coverage{}, fmt.Errorf("invalid coverage profile pattern %q: %w", profile, err)
+		}
+		if len(matches) == 0 {
+			return Coverage{}, fmt.Errorf("coverage profile %s not found", profile)
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+
+	// Blocks and lines are merged by location: a statement counts once, and
+	// as covered when any profile covered it.
+	covered := map[string]bool{}
+	weights := map[string]int{}
+	for _, path := range paths {
+		if !startedAt.IsZero() {
+			info, err := os.Stat(path)
+			if err != nil {
+				return Coverage{}, fmt.Errorf("read coverage profile: %w", err)
+			}
+			// File systems with coarse timestamps can round a fresh file down.
+			if info.ModTime().Before(startedAt.Truncate(time.Second)) {
+				return Coverage{}, fmt.Errorf("coverage profile %s was not written by this run", path)
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Coverage{}, fmt.Errorf("read coverage profile: %w", err)
+		}
+		parse := parseLCOV
+		if isGoCoverProfile(string(data)) {
+			parse = parseGoCoverProfile
+		}
+		if err := parse(string(data), covered, weights); err != nil {
+			return Coverage{}, fmt.Errorf("coverage profile %s: %w", path, err)
+		}
+	}
+
+	coverage := Coverage{}
+	for key, weight := range weights {
+		coverage.Total += weight
+		if covered[key] {
+			coverage.Covered += weight
+		}
+	}
+	if coverage.Total == 0 {
+		return Coverage{}, fmt.Errorf("coverage profiles %s cover no statements", strings.Join(profiles, ", "))
+	}
+	return coverage, nil
+}
+
+func isGoCoverProfile(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), "mode:")
+}
+
+// parseGoCoverProfile reads `go test -coverprofile` output: a mode line, then
+// "file:start,end statements count" per block.
+func parseGoCoverProfile(data string, covered map[string]bool, weights map[string]int) error {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "mode:") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return fmt.Errorf("line %d: malformed block %q", line, text)
+		}
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("line %d: malformed block %q", line, text)
+		}
+		key := "go:" + fields[0]
+		weights[key] = statements
+		if count > 0 {
+			covered[key] = true
+		}
+	}
+	return scanner.Err()
+}
+
+// parseLCOV reads LCOV tracefiles: per source file (SF), the hit count of
+// each line (DA). Records without DA lines fall back to their LF/LH totals.
+func parseLCOV(data string, covered map[string]bool, weights map[string]int) error {
+	file := ""
+	records := 0
+	sawLines := false
+	found, hit := 0, 0
+	for _, raw := range strings.Split(data, "\n") {
+		line := strings.TrimSpace(raw)
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "SF":
+			file, sawLines, found, hit = value, false, 0, 0
+			records++
+		case "DA":
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return fmt.Errorf("malformed line %q", line)
+			}
+			count, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return fmt.Errorf("malformed line %q", line)
+			}
+			sawLines = true
+			location := "lcov:" + file + ":" + fields[0]
+			weights[location] = 1
+			if count > 0 {
+				covered[location] = true
+			}
+		case "LF":
+			found, _ = strconv.Atoi(value)
+		case "LH":
+			hit, _ = strconv.Atoi(value)
+		case "end_of_record":
+			if !sawLines && found > 0 {
+				// Split the totals into a covered and an uncovered part.
+				weights["lcov:"+file+":hit"] += hit
+				covered["lcov:"+file+":hit"] = true
+				weights["lcov:"+file+":missed"] += found - hit
+			}
+		}
+	}
+	if records == 0 {
+		return fmt.Errorf("not a Go cover profile or LCOV file")
+	}
+	return nil
+}
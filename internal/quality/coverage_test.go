@@ -0,0 +1,133 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeProfile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+}
+
+func TestReadCoverageMergesGoProfileBlocks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// The same block appears once per package run with -coverpkg; it counts
+	// once, as covered when any run covered it.
+	writeProfile(t, dir, "cover.out", `mode: set
+example.com/app/add.go:3.24,5.2 2 1
+example.com/app/sub.go:3.24,5.2 2 0
+example.com/app/sub.go:3.24,5.2 2 1
+example.com/app/mul.go:3.24,6.2 4 0
+`)
+	coverage, err := ReadCoverage(dir, []string{"cover.out"}, time.Time{})
+	if err != nil {
+		t.Fatalf("read coverage: %v", err)
+	}
+	if coverage != (Coverage{Covered: 4, Total: 8}) || coverage.Percent() != 50 {
+		t.Fatalf("unexpected coverage %+v", coverage)
+	}
+}
+
+func TestReadCoverageCombinesGoAndLCOVProfiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProfile(t, dir, "cover.out", "mode: atomic\nexample.com/app/add.go:3.24,5.2 3 7\n")
+	writeProfile(t, dir, "web/coverage/lcov.info", `TN:
+SF:src/app.js
+DA:1,1
+DA:2,0
+DA:3,5,abcdef
+LF:3
+LH:2
+end_of_record
+SF:src/totals-only.js
+LF:4
+LH:1
+end_of_record
+`)
+	coverage, err := ReadCoverage(dir, []string{"cover.out", "web/*/lcov.info"}, time.Time{})
+	if err != nil {
+		t.Fatalf("read coverage: %v", err)
+	}
+	// 3 of 3 Go statements, 2 of 3 lines, and 1 of 4 lines from totals.
+	if coverage != (Coverage{Covered: 6, Total: 10}) {
+		t.Fatalf("unexpected coverage %+v", coverage)
+	}
+
+	if _, err := ReadCoverage(dir, []string{"missing.out"}, time.Time{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected missing profile error, got %v", err)
+	}
+	writeProfile(t, dir, "junk.txt", "not a profile\n")
+	if _, err := ReadCoverage(dir, []string{"junk.txt"}, time.Time{}); err == nil {
+		t.Fatal("expected unknown profile format to fail")
+	}
+}
+
+func TestCoverageGateChecksMinimumAndDrop(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// 3 of 4 statements: 75%.
+	writeProfile(t, dir, "cover.out", "mode: set\na.go:1.1,2.1 3 1\nb.go:1.1,2.1 1 0\n")
+
+	result := CoverageGate{Profiles: []string{"cover.out"}, Min: 70, MaxDrop: 2}.Check(dir, &Coverage{Covered: 76, Total: 100}, time.Time{})
+	if !result.Passed() {
+		t.Fatalf("expected a 1 point drop to pass, got %+v", result)
+	}
+	if delta, ok := result.Delta(); !ok || delta != -1 {
+		t.Fatalf("expected delta -1, got %v %v", delta, ok)
+	}
+
+	result = CoverageGate{Profiles: []string{"cover.out"}, MaxDrop: 2}.Check(dir, &Coverage{Covered: 80, Total: 100}, time.Time{})
+	if result.Passed() || !strings.Contains(result.Failure, "fell 5.00 points") {
+		t.Fatalf("expected a 5 point drop to fail, got %+v", result)
+	}
+
+	result = CoverageGate{Profiles: []string{"cover.out"}, Min: 80, MaxDrop: 2}.Check(dir, nil, time.Time{})
+	if result.Passed() || !strings.Contains(result.Failure, "below the minimum of 80.00%") {
+		t.Fatalf("expected coverage below the minimum to fail, got %+v", result)
+	}
+	if _, ok := result.Delta(); ok {
+		t.Fatal("expected no delta without a baseline")
+	}
+
+	result = CoverageGate{Profiles: []string{"missing.out"}}.Check(dir, nil, time.Time{})
+	if result.Passed() {
+		t.Fatal("expected a missing profile to fail the gate")
+	}
+}
+
+func TestCoverageGateFailsOnProfileOlderThanTheRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeProfile(t, dir, "cover.out", "mode: set\na.go:1.1,2.1 3 1\n")
+	startedAt := time.Now()
+	stale := startedAt.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "cover.out"), stale, stale); err != nil {
+		t.Fatalf("age profile: %v", err)
+	}
+
+	if _, err := ReadCoverage(dir, []string{"cover.out"}, startedAt); err == nil || !strings.Contains(err.Error(), "not written by this run") {
+		t.Fatalf("expected a stale profile to be rejected, got %v", err)
+	}
+	if result := (CoverageGate{Profiles: []string{"cover.out"}}).Check(dir, nil, startedAt); result.Passed() {
+		t.Fatalf("expected a stale profile to fail the gate, got %+v", result)
+	}
+	if _, err := ReadCoverage(dir, []string{"cover.out"}, time.Time{}); err != nil {
+		t.Fatalf("expected a zero start time to accept any profile, got %v", err)
+	}
+}
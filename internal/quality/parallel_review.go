@@ -21,15 +21,56 @@ type ReviewReport struct {
 	Reviews []providers.PerspectiveReview
 }
 
+// DefaultFailOn is the lowest finding severity that blocks a story when no
+// threshold is configured.
+const DefaultFailOn = providers.SeverityLow
+
+// ProviderResolver returns the provider registered under key. It is used for
+// perspectives that override the review provider.
+type ProviderResolver func(key string) (providers.Provider, error)
+
 // parallelReviewer runs multiple review perspectives in parallel via goroutines.
 type parallelReviewer struct {
-	provider providers.Provider
+	provider        providers.Provider
+	failOn          string
+	definitions     map[string]Perspective
+	resolveProvider ProviderResolver
 }
 
 // NewParallelReviewer creates a Reviewer that runs perspectives concurrently.
-// Pass the ACP provider that will be used to run review iterations.
-func NewParallelReviewer(provider providers.Provider) Reviewer {
-	return &parallelReviewer{provider: provider}
+// Pass the ACP provider that will be used to run review iterations, and the
+// lowest severity that should block the story (see providers.ReviewSeverities).
+// Findings below failOn are reported but do not fail the review.
+func NewParallelReviewer(provider providers.Provider, failOn string) Reviewer {
+	return NewPerspectiveReviewer(provider, failOn, nil, nil)
+}
+
+// NewPerspectiveReviewer is NewParallelReviewer with resolved perspective
+// definitions (see ResolvePerspectives). Perspectives without a definition use
+// the built-in focus text. resolve is called for perspectives that override
+// the provider; it may be nil when no overrides are used.
+func NewPerspectiveReviewer(provider providers.Provider, failOn string, definitions []Perspective, resolve ProviderResolver) Reviewer {
+	if strings.TrimSpace(failOn) == "" {
+		failOn = DefaultFailOn
+	}
+	byName := make(map[string]Perspective, len(definitions))
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+	return &parallelReviewer{
+		provider:        provider,
+		failOn:          providers.NormalizeSeverity(failOn),
+		definitions:     byName,
+		resolveProvider: resolve,
+	}
+}
+
+func (r *parallelReviewer) definition(name string) Perspective {
+	if definition, ok := r.definitions[name]; ok {
+		return definition
+	}
+	definition, _ := ResolvePerspective(PerspectiveDirs{}, name)
+	return definition
 }
 
 // RunReview executes each perspective as a separate agent iteration in parallel,
@@ -77,7 +118,7 @@ func (r *parallelReviewer) RunReview(
 
 	allPassed := true
 	for _, rev := range reviews {
-		if len(rev.Findings) > 0 || rev.Error != "" {
+		if rev.Blocked || rev.Error != "" {
 			allPassed = false
 			break
 		}
@@ -97,7 +138,29 @@ func (r *parallelReviewer) runPerspective(
 	baseOpts providers.IterationRequest,
 ) providers.PerspectiveReview {
 	startedAt := time.Now()
-	prompt := buildPerspectivePrompt(workDir, perspective)
+	definition := r.definition(perspective)
+	prompt := buildPerspectivePrompt(workDir, definition)
+
+	provider := r.provider
+	model := baseOpts.Model
+	review := providers.PerspectiveReview{Perspective: perspective}
+	if definition.Provider != "" {
+		if r.resolveProvider == nil {
+			review.Error = fmt.Sprintf("perspective %q overrides provider %q but no provider resolver is configured", perspective, definition.Provider)
+			return review
+		}
+		override, err := r.resolveProvider(definition.Provider)
+		if err != nil {
+			review.Error = fmt.Sprintf("perspective %q provider %q: %s", perspective, definition.Provider, err.Error())
+			return review
+		}
+		provider = override
+		// The base model belongs to the default provider; let the override pick its own.
+		model = "default"
+	}
+	if definition.Model != "" {
+		model = definition.Model
+	}
 
 	request := providers.IterationRequest{
 		WorkDir:        workDir,
@@ -105,7 +168,8 @@ func (r *parallelReviewer) runPerspective(
 		ContextFiles:   contextFiles,
 		ApprovalPolicy: baseOpts.ApprovalPolicy,
 		SandboxPolicy:  baseOpts.SandboxPolicy,
-		Model:          baseOpts.Model,
+		Model:          model,
+		Approver:       baseOpts.Approver,
 		Metadata: map[string]string{
 			"storyID":     baseOpts.Metadata["storyID"],
 			"phase":       "review",
@@ -113,39 +177,33 @@ func (r *parallelReviewer) runPerspective(
 		},
 	}
 
-	events, result, err := providers.RunIterationSimple(ctx, r.provider, request)
-	review := providers.PerspectiveReview{
-		Perspective: perspective,
-		Duration:    time.Since(startedAt).String(),
-	}
+	events, result, err := providers.RunIterationSimple(ctx, provider, request)
+	review.Duration = time.Since(startedAt).String()
 
 	if err != nil {
 		review.Error = err.Error()
 		return review
 	}
 
+	review.Provider = provider.Name()
+	review.Model = model
 	var summary strings.Builder
 	for event := range events {
-		if event.Type == providers.EventAssistantText {
+		switch event.Type {
+		case providers.EventAssistantText:
 			summary.WriteString(event.Message)
+		case providers.EventUsage:
+			if usage, ok := providers.DecodeEventUsage(event.Message); ok {
+				review.Usage = &usage
+			}
 		}
 	}
 
-	output := strings.TrimSpace(summary.String())
-	if output == "" {
-		return review
-	}
-
-	// Parse findings. Lines starting with "-" or "*" are treated as findings.
-	for _, line := range strings.Split(output, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
-			review.Findings = append(review.Findings, strings.TrimPrefix(strings.TrimPrefix(line, "- "), "* "))
-		} else {
-			review.Findings = append(review.Findings, line)
+	review.Findings = parseReviewFindings(summary.String())
+	for _, finding := range review.Findings {
+		if providers.SeverityAtLeast(finding.Severity, r.failOn) {
+			review.Blocked = true
+			break
 		}
 	}
 
@@ -154,22 +212,23 @@ func (r *parallelReviewer) runPerspective(
 }
 
 // buildPerspectivePrompt returns a review prompt for the given perspective.
-func buildPerspectivePrompt(workDir string, perspective string) string {
-	var focus string
-	switch perspective {
-	case "security":
-		focus = "common security issues: SQL injection, hardcoded secrets, unsafe shell commands, missing input validation, exposed credentials in logs"
-	case "performance":
-		focus = "common performance issues: N+1 queries, missing database indexes, unbounded loops over large datasets, unnecessary allocations, synchronous blocking I/O"
-	case "complexity":
-		focus = "over-engineering and unnecessary complexity: redundant abstractions, premature optimization, over-engineered patterns, bloated boilerplate, code that is harder to maintain than it needs to be"
-	default:
-		focus = "code quality issues"
+func buildPerspectivePrompt(workDir string, perspective Perspective) string {
+	var scope string
+	if perspective.Checklist != "" {
+		scope = "Review the modified files against this checklist:\n\n" + perspective.Checklist
+	} else {
+		scope = "Review the modified files and identify any issues related to: " + perspective.Focus + "."
 	}
 
 	return fmt.Sprintf(`You are reviewing code changes in %s for the "%s" perspective.
 
-Review the modified files and identify any issues related to: %s.
+%s
+
+Output format: respond with a single fenced JSON block and nothing else:
+
+`+"```json"+`
+{"findings": [{"severity": "high", "file": "path/to/file.go", "line": 42, "rule": "short-rule-id", "message": "what is wrong and how to fix it"}]}
+`+"```"+`
 
-Output format: Write each finding on its own line starting with "- ". If no issues are found, output nothing. Be specific and concise. Do not include header text, explanations, or wrapping text outside the bullet list.`, workDir, perspective, focus)
+Severity must be one of: critical, high, medium, low, info. Use "file" and "line" when the issue has a location, and a short kebab-case "rule". If no issues are found, respond with {"findings": []}. Be specific and concise.`, workDir, perspective.Name, scope)
 }
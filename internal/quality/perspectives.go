@@ -0,0 +1,198 @@
+package quality
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Perspective sources, from highest to lowest precedence.
+const (
+	PerspectiveSourceProject  = "project"
+	PerspectiveSourceUser     = "user"
+	PerspectiveSourceBuiltin  = "built-in"
+	PerspectiveSourceFallback = "fallback"
+)
+
+// builtinPerspectiveFocus holds the focus text for perspectives that ship with
+// daedalus. Markdown files with the same name take precedence.
+var builtinPerspectiveFocus = map[string]string{
+	"security":    "common security issues: SQL injection, hardcoded secrets, unsafe shell commands, missing input validation, exposed credentials in logs",
+	"performance": "common performance issues: N+1 queries, missing database indexes, unbounded loops over large datasets, unnecessary allocations, synchronous blocking I/O",
+	"complexity":  "over-engineering and unnecessary complexity: redundant abstractions, premature optimization, over-engineered patterns, bloated boilerplate, code that is harder to maintain than it needs to be",
+}
+
+const fallbackPerspectiveFocus = "code quality issues"
+
+// Perspective is a resolved review perspective.
+//
+// File-based perspectives live in <dir>/<name>.md. The body is used as the
+// review checklist; an optional front matter block sets overrides:
+//
+//	---
+//	description: WCAG 2.1 AA checks for UI changes
+//	provider: claude
+//	model: claude-haiku
+//	---
+//	- Interactive elements are reachable by keyboard.
+//	- Images have meaningful alt text.
+type Perspective struct {
+	Name        string
+	Description string
+	// Focus is the one-line focus used by built-in and fallback perspectives.
+	Focus string
+	// Checklist is the markdown body of a file-based perspective.
+	Checklist string
+	// Provider and Model override the review provider and model when set.
+	Provider string
+	Model    string
+	Source   string
+	Path     string
+}
+
+// PerspectiveDirs are the directories searched for <name>.md perspective files.
+type PerspectiveDirs struct {
+	// Project is usually .daedalus/review in the project root.
+	Project string
+	// User is usually the review directory next to the user's config file.
+	User string
+}
+
+// ResolvePerspective finds the definition for name, looking in the project
+// directory, then the user directory, then the built-ins. Unknown names fall
+// back to a generic code-quality focus.
+func ResolvePerspective(dirs PerspectiveDirs, name string) (Perspective, error) {
+	name = strings.TrimSpace(name)
+	for _, candidate := range []struct {
+		dir    string
+		source string
+	}{
+		{dirs.Project, PerspectiveSourceProject},
+		{dirs.User, PerspectiveSourceUser},
+	} {
+		if strings.TrimSpace(candidate.dir) == "" {
+			continue
+		}
+		path := filepath.Join(candidate.dir, name+".md")
+		perspective, found, err := loadPerspectiveFile(path, name, candidate.source)
+		if err != nil {
+			return Perspective{}, err
+		}
+		if found {
+			return perspective, nil
+		}
+	}
+
+	if focus, ok := builtinPerspectiveFocus[name]; ok {
+		return Perspective{Name: name, Focus: focus, Source: PerspectiveSourceBuiltin}, nil
+	}
+	return Perspective{Name: name, Focus: fallbackPerspectiveFocus, Source: PerspectiveSourceFallback}, nil
+}
+
+// ResolvePerspectives resolves each name in order.
+func ResolvePerspectives(dirs PerspectiveDirs, names []string) ([]Perspective, error) {
+	resolved := make([]Perspective, 0, len(names))
+	for _, name := range names {
+		perspective, err := ResolvePerspective(dirs, name)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, perspective)
+	}
+	return resolved, nil
+}
+
+// AvailablePerspectives returns every perspective that can be resolved without
+// falling back: built-ins plus any markdown files in the search directories,
+// sorted by name.
+func AvailablePerspectives(dirs PerspectiveDirs) ([]Perspective, error) {
+	names := make(map[string]struct{}, len(builtinPerspectiveFocus))
+	for name := range builtinPerspectiveFocus {
+		names[name] = struct{}{}
+	}
+	for _, dir := range []string{dirs.Project, dirs.User} {
+		if strings.TrimSpace(dir) == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read review perspectives in %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+				continue
+			}
+			names[strings.TrimSuffix(entry.Name(), ".md")] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return ResolvePerspectives(dirs, sorted)
+}
+
+func loadPerspectiveFile(path, name, source string) (Perspective, bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Perspective{}, false, nil
+		}
+		return Perspective{}, false, fmt.Errorf("failed to read review perspective %s: %w", path, err)
+	}
+
+	frontMatter, body := splitFrontMatter(string(raw))
+	perspective := Perspective{
+		Name:        name,
+		Description: frontMatter["description"],
+		Checklist:   strings.TrimSpace(body),
+		Provider:    strings.ToLower(frontMatter["provider"]),
+		Model:       frontMatter["model"],
+		Source:      source,
+		Path:        path,
+	}
+	if perspective.Checklist == "" {
+		return Perspective{}, false, fmt.Errorf("review perspective %s has no checklist", path)
+	}
+	return perspective, true, nil
+}
+
+// splitFrontMatter separates a leading "---" delimited block of "key: value"
+// lines from the rest of the document. Keys are lower-cased.
+func splitFrontMatter(text string) (map[string]string, string) {
+	values := map[string]string{}
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return values, text
+	}
+	rest := normalized[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return values, text
+	}
+	for _, line := range strings.Split(rest[:end], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if key != "" {
+			values[key] = value
+		}
+	}
+	body := rest[end+len("\n---"):]
+	if newline := strings.Index(body, "\n"); newline >= 0 {
+		body = body[newline+1:]
+	} else {
+		body = ""
+	}
+	return values, body
+}
@@ -0,0 +1,172 @@
+package quality
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+func writePerspective(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write perspective %s: %v", name, err)
+	}
+}
+
+func TestResolvePerspectivePrecedence(t *testing.T) {
+	t.Parallel()
+
+	dirs := PerspectiveDirs{Project: filepath.Join(t.TempDir(), "project"), User: filepath.Join(t.TempDir(), "user")}
+	writePerspective(t, dirs.Project, "security", "- Project-specific secret handling.\n")
+	writePerspective(t, dirs.User, "security", "- User security checklist.\n")
+	writePerspective(t, dirs.User, "accessibility", "---\ndescription: WCAG checks\nprovider: Claude\nmodel: \"claude-haiku\"\n---\n- Keyboard navigation works.\n")
+
+	security, err := ResolvePerspective(dirs, "security")
+	if err != nil {
+		t.Fatalf("resolve security: %v", err)
+	}
+	if security.Source != PerspectiveSourceProject || !strings.Contains(security.Checklist, "Project-specific") {
+		t.Fatalf("expected project file to win, got %+v", security)
+	}
+
+	accessibility, err := ResolvePerspective(dirs, "accessibility")
+	if err != nil {
+		t.Fatalf("resolve accessibility: %v", err)
+	}
+	if accessibility.Source != PerspectiveSourceUser || accessibility.Provider != "claude" || accessibility.Model != "claude-haiku" || accessibility.Description != "WCAG checks" {
+		t.Fatalf("expected user file with front matter overrides, got %+v", accessibility)
+	}
+	if accessibility.Checklist != "- Keyboard navigation works." {
+		t.Fatalf("expected front matter stripped from checklist, got %q", accessibility.Checklist)
+	}
+
+	performance, err := ResolvePerspective(dirs, "performance")
+	if err != nil {
+		t.Fatalf("resolve performance: %v", err)
+	}
+	if performance.Source != PerspectiveSourceBuiltin {
+		t.Fatalf("expected built-in performance, got %+v", performance)
+	}
+
+	unknown, err := ResolvePerspective(dirs, "api-compat")
+	if err != nil {
+		t.Fatalf("resolve unknown: %v", err)
+	}
+	if unknown.Source != PerspectiveSourceFallback || unknown.Focus != fallbackPerspectiveFocus {
+		t.Fatalf("expected fallback for unknown perspective, got %+v", unknown)
+	}
+}
+
+func TestResolvePerspectiveRejectsEmptyChecklist(t *testing.T) {
+	t.Parallel()
+
+	dirs := PerspectiveDirs{Project: t.TempDir()}
+	writePerspective(t, dirs.Project, "migrations", "---\nmodel: fast\n---\n")
+	if _, err := ResolvePerspective(dirs, "migrations"); err == nil {
+		t.Fatal("expected error for perspective without checklist")
+	}
+}
+
+func TestAvailablePerspectivesListsBuiltinsAndFiles(t *testing.T) {
+	t.Parallel()
+
+	dirs := PerspectiveDirs{Project: t.TempDir(), User: filepath.Join(t.TempDir(), "missing")}
+	writePerspective(t, dirs.Project, "migrations", "- Migrations are reversible.\n")
+
+	available, err := AvailablePerspectives(dirs)
+	if err != nil {
+		t.Fatalf("available perspectives: %v", err)
+	}
+	var names []string
+	for _, perspective := range available {
+		names = append(names, perspective.Name)
+	}
+	if got := strings.Join(names, ","); got != "complexity,migrations,performance,security" {
+		t.Fatalf("unexpected available perspectives: %s", got)
+	}
+}
+
+// routingProvider records the model and prompt it receives.
+type routingProvider struct {
+	name    string
+	models  chan string
+	prompts chan string
+}
+
+func (p routingProvider) Name() string {
+	return p.name
+}
+
+func (p routingProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p routingProvider) RunIteration(_ context.Context, request providers.IterationRequest) (<-chan providers.Event, providers.IterationResult, error) {
+	p.models <- request.Model
+	p.prompts <- request.Prompt
+	events := make(chan providers.Event, 1)
+	events <- providers.Event{Type: providers.EventAssistantText, Message: `{"findings": []}`}
+	close(events)
+	return events, providers.IterationResult{Success: true}, nil
+}
+
+func TestPerspectiveReviewerAppliesChecklistAndOverrides(t *testing.T) {
+	t.Parallel()
+
+	base := routingProvider{name: "codex", models: make(chan string, 1), prompts: make(chan string, 1)}
+	override := routingProvider{name: "claude", models: make(chan string, 1), prompts: make(chan string, 1)}
+	definitions := []Perspective{{
+		Name:      "accessibility",
+		Checklist: "- Buttons have accessible names.",
+		Provider:  "claude",
+		Model:     "claude-haiku",
+		Source:    PerspectiveSourceProject,
+	}}
+	resolve := func(key string) (providers.Provider, error) {
+		if key == "claude" {
+			return override, nil
+		}
+		return nil, fmt.Errorf("unknown provider %q", key)
+	}
+
+	reviewer := NewPerspectiveReviewer(base, "", definitions, resolve)
+	report, err := reviewer.RunReview(context.Background(), t.TempDir(), nil, []string{"accessibility"}, providers.IterationRequest{Model: "gpt-5"})
+	if err != nil {
+		t.Fatalf("run review: %v", err)
+	}
+	if !report.Passed {
+		t.Fatalf("expected review to pass, got %+v", report.Reviews)
+	}
+	if len(base.models) != 0 {
+		t.Fatal("expected base provider not to be used for overridden perspective")
+	}
+	if model := <-override.models; model != "claude-haiku" {
+		t.Fatalf("expected model override, got %q", model)
+	}
+	if prompt := <-override.prompts; !strings.Contains(prompt, "Buttons have accessible names.") {
+		t.Fatalf("expected checklist in prompt, got:\n%s", prompt)
+	}
+}
+
+func TestPerspectiveReviewerReportsUnresolvableProvider(t *testing.T) {
+	t.Parallel()
+
+	base := routingProvider{name: "codex", models: make(chan string, 1), prompts: make(chan string, 1)}
+	definitions := []Perspective{{Name: "api-compat", Checklist: "- No breaking changes.", Provider: "nope"}}
+	resolve := func(key string) (providers.Provider, error) {
+		return nil, fmt.Errorf("unknown provider %q", key)
+	}
+
+	report, _ := NewPerspectiveReviewer(base, "", definitions, resolve).RunReview(context.Background(), t.TempDir(), nil, []string{"api-compat"}, providers.IterationRequest{})
+	if report.Passed || report.Reviews[0].Error == "" {
+		t.Fatalf("expected provider resolution error to fail the review, got %+v", report.Reviews)
+	}
+}
@@ -0,0 +1,189 @@
+package quality
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+var (
+	fencedBlockPattern = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n(.*?)```")
+	locationPattern    = regexp.MustCompile(`^(.+?):(\d+)(?::\d+)?$`)
+	bulletPattern      = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+(.+)$`)
+	severityTagPattern = regexp.MustCompile(`^\[([a-zA-Z]+)\]\s*(.+)$`)
+)
+
+// parseReviewFindings extracts findings from a review perspective's output.
+//
+// The prompt asks for a JSON object with a "findings" array, but agents are not
+// always obedient, so the parser accepts the JSON fenced or bare, as an object
+// or a bare array, and with common alternative field names. When no JSON can be
+// found it falls back to bullet lines; prose such as "Looks good!" is never
+// treated as a finding. Findings that carry no severity at all, bullets without
+// a [severity] tag and bare JSON strings alike, are info, so an approval written
+// as a list does not block.
+func parseReviewFindings(output string) []providers.ReviewFinding {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil
+	}
+
+	for _, candidate := range jsonCandidates(output) {
+		if findings, ok := decodeFindings(candidate); ok {
+			return findings
+		}
+	}
+	return parseBulletFindings(output)
+}
+
+// jsonCandidates returns the snippets of output that may hold the findings
+// JSON, most specific first.
+func jsonCandidates(output string) []string {
+	var candidates []string
+	for _, match := range fencedBlockPattern.FindAllStringSubmatch(output, -1) {
+		candidates = append(candidates, strings.TrimSpace(match[1]))
+	}
+	candidates = append(candidates, output)
+	if start, end := strings.Index(output, "{"), strings.LastIndex(output, "}"); start >= 0 && end > start {
+		candidates = append(candidates, output[start:end+1])
+	}
+	if start, end := strings.Index(output, "["), strings.LastIndex(output, "]"); start >= 0 && end > start {
+		candidates = append(candidates, output[start:end+1])
+	}
+	return candidates
+}
+
+func decodeFindings(raw string) ([]providers.ReviewFinding, bool) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, false
+	}
+
+	var items []interface{}
+	switch typed := value.(type) {
+	case []interface{}:
+		items = typed
+	case map[string]interface{}:
+		list, found := firstPresent(typed, "findings", "issues", "results")
+		if !found {
+			if _, hasMessage := firstPresent(typed, "message", "description"); !hasMessage {
+				return nil, false
+			}
+			items = []interface{}{typed}
+			break
+		}
+		if list == nil {
+			return nil, true
+		}
+		asList, ok := list.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		items = asList
+	default:
+		return nil, false
+	}
+
+	findings := make([]providers.ReviewFinding, 0, len(items))
+	for _, item := range items {
+		finding, ok := decodeFinding(item)
+		if ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings, true
+}
+
+func decodeFinding(item interface{}) (providers.ReviewFinding, bool) {
+	switch typed := item.(type) {
+	case string:
+		message := strings.TrimSpace(typed)
+		if message == "" {
+			return providers.ReviewFinding{}, false
+		}
+		return providers.ReviewFinding{Severity: providers.SeverityInfo, Message: message}, true
+	case map[string]interface{}:
+		finding := providers.ReviewFinding{
+			Severity: providers.NormalizeSeverity(stringField(typed, "severity", "level", "priority")),
+			File:     stringField(typed, "file", "path", "filename", "location"),
+			Line:     intField(typed, "line", "line_number", "lineNumber", "start_line"),
+			Rule:     stringField(typed, "rule", "id", "rule_id", "ruleId", "category"),
+			Message:  stringField(typed, "message", "description", "text", "title", "issue"),
+		}
+		if finding.Line == 0 {
+			if match := locationPattern.FindStringSubmatch(finding.File); match != nil {
+				finding.File = match[1]
+				finding.Line, _ = strconv.Atoi(match[2])
+			}
+		}
+		if finding.Message == "" {
+			return providers.ReviewFinding{}, false
+		}
+		return finding, true
+	default:
+		return providers.ReviewFinding{}, false
+	}
+}
+
+func parseBulletFindings(output string) []providers.ReviewFinding {
+	var findings []providers.ReviewFinding
+	for _, line := range strings.Split(output, "\n") {
+		match := bulletPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		finding := providers.ReviewFinding{Severity: providers.SeverityInfo, Message: strings.TrimSpace(match[1])}
+		if tagged := severityTagPattern.FindStringSubmatch(finding.Message); tagged != nil && providers.IsReviewSeverity(tagged[1]) {
+			finding.Severity = providers.NormalizeSeverity(tagged[1])
+			finding.Message = strings.TrimSpace(tagged[2])
+		}
+		if finding.Message != "" {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func firstPresent(values map[string]interface{}, keys ...string) (interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func stringField(values map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch typed := values[key].(type) {
+		case string:
+			if text := strings.TrimSpace(typed); text != "" {
+				return text
+			}
+		case float64:
+			return strconv.FormatFloat(typed, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+func intField(values map[string]interface{}, keys ...string) int {
+	for _, key := range keys {
+		switch typed := values[key].(type) {
+		case float64:
+			return int(typed)
+		case string:
+			digits := strings.TrimSpace(typed)
+			if index := strings.IndexAny(digits, "-:,"); index > 0 {
+				digits = digits[:index]
+			}
+			if parsed, err := strconv.Atoi(digits); err == nil {
+				return parsed
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package quality
+
+import (
+	"context"
+	"testing"
+
+	"github.com/EstebanForge/daedalus/internal/providers"
+)
+
+func TestParseReviewFindingsReadsFencedJSON(t *testing.T) {
+	t.Parallel()
+
+	output := "Here is my review.\n\n```json\n" +
+		`{"findings": [{"severity": "High", "file": "store/query.go", "line": 42, "rule": "sql-injection", "message": "query built with string concatenation"},` +
+		`{"severity": "nit", "file": "store/query.go:7", "message": "unused import"}]}` +
+		"\n```\n"
+
+	findings := parseReviewFindings(output)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	first := findings[0]
+	if first.Severity != providers.SeverityHigh || first.File != "store/query.go" || first.Line != 42 || first.Rule != "sql-injection" {
+		t.Fatalf("unexpected first finding: %+v", first)
+	}
+	second := findings[1]
+	if second.Severity != providers.SeverityInfo || second.File != "store/query.go" || second.Line != 7 {
+		t.Fatalf("expected location split and severity normalized, got %+v", second)
+	}
+}
+
+func TestParseReviewFindingsToleratesAlternativeShapes(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int{
+		`[{"level": "error", "path": "a.go", "description": "nil deref"}]`:         1,
+		`Result: {"issues": [{"priority": "low", "text": "long function"}]} done.`: 1,
+		`{"findings": []}`:   0,
+		`{"findings": null}`: 0,
+		"Looks good!":        0,
+		"No issues found. Everything follows the project conventions.": 0,
+		"Findings:\n- [high] secret in config.go\n* missing timeout":   2,
+	}
+	for output, want := range cases {
+		if got := parseReviewFindings(output); len(got) != want {
+			t.Fatalf("parse %q: expected %d findings, got %+v", output, want, got)
+		}
+	}
+
+	bullets := parseReviewFindings("- [high] secret in config.go\n- missing timeout")
+	if bullets[0].Severity != providers.SeverityHigh || bullets[0].Message != "secret in config.go" {
+		t.Fatalf("expected severity tag to be parsed from bullet, got %+v", bullets[0])
+	}
+	if bullets[1].Severity != providers.SeverityInfo {
+		t.Fatalf("expected an untagged bullet to be info, got %+v", bullets[1])
+	}
+}
+
+func TestParseReviewFindingsGivesUntaggedFindingsOneSeverity(t *testing.T) {
+	t.Parallel()
+
+	bullets := parseReviewFindings("- missing timeout")
+	bare := parseReviewFindings(`{"findings": ["missing timeout"]}`)
+	if len(bullets) != 1 || len(bare) != 1 {
+		t.Fatalf("expected one finding from each shape, got %+v and %+v", bullets, bare)
+	}
+	if bullets[0] != bare[0] || bare[0].Severity != providers.SeverityInfo {
+		t.Fatalf("expected an untagged bullet and a bare string to agree on info, got %+v and %+v", bullets[0], bare[0])
+	}
+}
+
+// scriptedReviewProvider answers every review iteration with the same text.
+type scriptedReviewProvider struct {
+	output string
+}
+
+func (p scriptedReviewProvider) Name() string {
+	return "scripted"
+}
+
+func (p scriptedReviewProvider) Capabilities() providers.Capabilities {
+	return providers.Capabilities{}
+}
+
+func (p scriptedReviewProvider) RunIteration(_ context.Context, _ providers.IterationRequest) (<-chan providers.Event, providers.IterationResult, error) {
+	events := make(chan providers.Event, 1)
+	events <- providers.Event{Type: providers.EventAssistantText, Message: p.output}
+	close(events)
+	return events, providers.IterationResult{Success: true}, nil
+}
+
+func TestRunReviewOnlyBlocksOnFindingsAtOrAboveFailOn(t *testing.T) {
+	t.Parallel()
+
+	output := `{"findings": [{"severity": "medium", "message": "missing doc comment"}, {"severity": "low", "message": "long line"}]}`
+	request := providers.IterationRequest{Metadata: map[string]string{"storyID": "US-001"}}
+
+	lenient := NewParallelReviewer(scriptedReviewProvider{output: output}, "high")
+	report, err := lenient.RunReview(context.Background(), t.TempDir(), nil, []string{"complexity"}, request)
+	if err != nil {
+		t.Fatalf("run review: %v", err)
+	}
+	if !report.Passed {
+		t.Fatal("expected medium findings not to block with fail_on=high")
+	}
+	if len(report.Reviews) != 1 || len(report.Reviews[0].Findings) != 2 || report.Reviews[0].Blocked {
+		t.Fatalf("expected findings to be kept but not blocking, got %+v", report.Reviews)
+	}
+
+	strict := NewParallelReviewer(scriptedReviewProvider{output: output}, "")
+	report, err = strict.RunReview(context.Background(), t.TempDir(), nil, []string{"complexity"}, request)
+	if err != nil {
+		t.Fatalf("run review: %v", err)
+	}
+	if report.Passed || !report.Reviews[0].Blocked {
+		t.Fatalf("expected default fail_on to block on medium findings, got %+v", report)
+	}
+}
+
+func TestRunReviewPassesOnChattyApproval(t *testing.T) {
+	t.Parallel()
+
+	reviewer := NewParallelReviewer(scriptedReviewProvider{output: "Looks good! Nice work on this change."}, "")
+	report, err := reviewer.RunReview(context.Background(), t.TempDir(), nil, []string{"security"}, providers.IterationRequest{})
+	if err != nil {
+		t.Fatalf("run review: %v", err)
+	}
+	if !report.Passed {
+		t.Fatalf("expected prose without findings to pass, got %+v", report.Reviews)
+	}
+}
+
+func TestRunReviewPassesOnBulletedApprovalWithDefaultFailOn(t *testing.T) {
+	t.Parallel()
+
+	output := "Review:\n- Handler looks correct\n- Tests cover edge cases"
+	reviewer := NewParallelReviewer(scriptedReviewProvider{output: output}, DefaultFailOn)
+	report, err := reviewer.RunReview(context.Background(), t.TempDir(), nil, []string{"security"}, providers.IterationRequest{})
+	if err != nil {
+		t.Fatalf("run review: %v", err)
+	}
+	if !report.Passed || report.Reviews[0].Blocked {
+		t.Fatalf("expected untagged bullets not to block, got %+v", report.Reviews)
+	}
+}
@@ -5,62 +5,274 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
 type Result struct {
+	// Name and Group come from the check the result belongs to.
+	Name     string
+	Group    string
 	Command  string
 	ExitCode int
 	Stdout   string
 	Stderr   string
 	Duration time.Duration
+	// TimedOut is set when the command was killed for exceeding the
+	// runner's command timeout. ExitCode is then timeoutExitCode.
+	TimedOut bool
+	// Skipped is set when the command did not run because a check it
+	// depends on failed.
+	Skipped bool
+	// Unchanged is set when the command did not run because nothing in its
+	// scope changed. It counts as passed.
+	Unchanged bool
+	// AllowFailure is copied from the check: a failure does not fail the
+	// report.
+	AllowFailure bool
+	// Format is copied from the check. Tests holds the tests parsed from
+	// the check's report or Stdout in that format; it is nil when there is
+	// no format or the output could not be parsed.
+	Format string
+	Tests  []TestCase
 }
 
+// Passed reports whether the command ran and exited zero.
+func (r Result) Passed() bool {
+	return !r.Skipped && r.ExitCode == 0
+}
+
+// Blocks reports whether the result fails the report.
+func (r Result) Blocks() bool {
+	return !r.Passed() && !r.AllowFailure
+}
+
+// timeoutExitCode is reported for commands killed by the command timeout,
+// matching timeout(1).
+const timeoutExitCode = 124
+
+// commandWaitDelay bounds how long a killed command's children may keep its
+// output pipes open.
+const commandWaitDelay = 5 * time.Second
+
 type Report struct {
 	Passed  bool
 	Results []Result
+	// Coverage is the outcome of the coverage gate, when one ran. The
+	// runner does not set it.
+	Coverage *CoverageResult
 }
 
-type Runner struct{}
+type Runner struct {
+	commandTimeout time.Duration
+	concurrency    int
+}
 
+// NewRunner returns a runner that runs one command at a time.
 func NewRunner() Runner {
-	return Runner{}
+	return Runner{concurrency: 1}
 }
 
-func (Runner) Run(ctx context.Context, workDir string, commands []string) (Report, error) {
-	if len(commands) == 0 {
-		return Report{}, fmt.Errorf("no quality commands configured")
+// WithCommandTimeout returns a runner that kills each command after timeout
+// and reports it as failed. Zero means no timeout.
+func (r Runner) WithCommandTimeout(timeout time.Duration) Runner {
+	r.commandTimeout = timeout
+	return r
+}
+
+// WithConcurrency returns a runner that runs up to limit checks at once.
+func (r Runner) WithConcurrency(limit int) Runner {
+	if limit < 1 {
+		limit = 1
 	}
+	r.concurrency = limit
+	return r
+}
 
-	report := Report{
-		Passed:  true,
-		Results: make([]Result, 0, len(commands)),
+// Run runs checks, starting each once the checks it depends on have
+// finished, with at most the runner's concurrency running at once. Results
+// are reported in the order the checks are declared.
+func (r Runner) Run(ctx context.Context, workDir string, checks []Check) (Report, error) {
+	if err := ValidateChecks(checks); err != nil {
+		return Report{}, err
+	}
+	deps, err := resolveDependencies(checks)
+	if err != nil {
+		return Report{}, err
+	}
+	limit := r.concurrency
+	if limit < 1 {
+		limit = 1
 	}
 
-	for _, command := range commands {
-		if strings.TrimSpace(command) == "" {
-			return Report{}, fmt.Errorf("quality command must not be empty")
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type outcome struct {
+		index  int
+		result Result
+		err    error
+	}
+	done := make(chan outcome)
+	results := make([]Result, len(checks))
+	finished := make([]bool, len(checks))
+	started := make([]bool, len(checks))
+	running, remaining := 0, len(checks)
+	var runErr error
+
+	for remaining > 0 {
+		// Start or skip, in declared order, every check whose dependencies
+		// have finished.
+		for i, check := range checks {
+			if started[i] || runErr != nil {
+				continue
+			}
+			ready, failed := true, ""
+			for _, dep := range deps[i] {
+				if !finished[dep] {
+					ready = false
+					break
+				}
+				if failed == "" && results[dep].Blocks() {
+					failed = checks[dep].name()
+				}
+			}
+			if !ready {
+				continue
+			}
+			if failed != "" {
+				started[i], finished[i] = true, true
+				remaining--
+				results[i] = checkResult(check, Result{
+					Command: check.Command,
+					Skipped: true,
+					Stderr:  fmt.Sprintf("skipped: depends on %q, which failed\n", failed),
+				})
+				continue
+			}
+			if check.Unchanged {
+				started[i], finished[i] = true, true
+				remaining--
+				results[i] = checkResult(check, Result{
+					Command:   check.Command,
+					Unchanged: true,
+					Stdout:    "not run: nothing in scope changed\n",
+				})
+				continue
+			}
+			if running >= limit {
+				continue
+			}
+			started[i] = true
+			running++
+			go func(index int, check Check) {
+				timeout := r.commandTimeout
+				if check.Timeout > 0 {
+					timeout = check.Timeout
+				}
+				startedAt := time.Now()
+				result, err := runCommand(runCtx, workDir, check.Command, timeout)
+				if err == nil && check.Format != "" {
+					result = parseResultTests(check, workDir, startedAt, result)
+				}
+				done <- outcome{index: index, result: checkResult(check, result), err: err}
+			}(i, check)
+		}
+		if running == 0 {
+			if runErr != nil {
+				return Report{}, runErr
+			}
+			// Skipping a check can make others ready; look again.
+			continue
 		}
 
-		result, err := runCommand(ctx, workDir, command)
-		if err != nil {
-			return Report{}, fmt.Errorf("failed to run quality command %q: %w", command, err)
+		finishedCheck := <-done
+		running--
+		remaining--
+		finished[finishedCheck.index] = true
+		results[finishedCheck.index] = finishedCheck.result
+		if finishedCheck.err != nil && runErr == nil {
+			runErr = fmt.Errorf("failed to run quality command %q: %w", checks[finishedCheck.index].Command, finishedCheck.err)
+			// Stop the other commands; their results no longer matter.
+			cancel()
 		}
+	}
+	if runErr != nil {
+		return Report{}, runErr
+	}
 
-		report.Results = append(report.Results, result)
-		if result.ExitCode != 0 {
+	report := Report{Passed: true, Results: results}
+	for _, result := range results {
+		if result.Blocks() {
 			report.Passed = false
 		}
 	}
-
 	return report, nil
 }
 
-func runCommand(ctx context.Context, workDir string, command string) (Result, error) {
+func checkResult(check Check, result Result) Result {
+	result.Name = check.name()
+	result.Group = check.Group
+	result.AllowFailure = check.AllowFailure
+	result.Format = check.Format
+	return result
+}
+
+// parseResultTests parses the check's tests from its report file, when the
+// command wrote one since startedAt, or else from stdout. Output that cannot
+// be parsed is noted on Stderr and left for the reader.
+func parseResultTests(check Check, workDir string, startedAt time.Time, result Result) Result {
+	output := result.Stdout
+	if check.Report != "" {
+		report, err := readReport(workDir, check.Report, startedAt)
+		if err != nil {
+			result.Stderr = strings.TrimRight(result.Stderr, "\n") + fmt.Sprintf("\ncould not read report %s, parsing stdout: %v\n", check.Report, err)
+		} else {
+			output = report
+		}
+	}
+	tests, err := ParseTests(check.Format, output)
+	if err != nil {
+		result.Stderr = strings.TrimRight(result.Stderr, "\n") + fmt.Sprintf("\ncould not parse %s output: %v\n", check.Format, err)
+		return result
+	}
+	result.Tests = tests
+	return result
+}
+
+// readReport reads a check's report file. A file older than startedAt is
+// left over from an earlier run and is not read.
+func readReport(workDir, path string, startedAt time.Time) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// File systems with coarse timestamps can round a fresh file down.
+	if info.ModTime().Before(startedAt.Truncate(time.Second)) {
+		return "", fmt.Errorf("not written by this run")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func runCommand(ctx context.Context, workDir string, command string, timeout time.Duration) (Result, error) {
 	startedAt := time.Now()
-	cmd := exec.CommandContext(ctx, "bash", "-lc", command)
+	commandCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		commandCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(commandCtx, "bash", "-lc", command)
+	cmd.WaitDelay = commandWaitDelay
 	if strings.TrimSpace(workDir) != "" {
 		cmd.Dir = workDir
 	}
@@ -83,6 +295,13 @@ func runCommand(ctx context.Context, workDir string, command string) (Result, er
 		return result, nil
 	}
 
+	if errors.Is(commandCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		result.TimedOut = true
+		result.ExitCode = timeoutExitCode
+		result.Stderr = strings.TrimRight(result.Stderr, "\n") + fmt.Sprintf("\nquality command timed out after %s\n", timeout)
+		return result, nil
+	}
+
 	var exitErr *exec.ExitError
 	if errors.As(err, &exitErr) {
 		result.ExitCode = exitErr.ExitCode()
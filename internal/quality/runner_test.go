@@ -2,14 +2,16 @@ package quality
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestRunnerRunPassesWhenAllCommandsSucceed(t *testing.T) {
 	t.Parallel()
 
 	runner := NewRunner()
-	report, err := runner.Run(context.Background(), "", []string{"echo ok", "true"})
+	report, err := runner.Run(context.Background(), "", CommandChecks([]string{"echo ok", "true"}))
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -25,7 +27,7 @@ func TestRunnerRunFailsWhenAnyCommandFails(t *testing.T) {
 	t.Parallel()
 
 	runner := NewRunner()
-	report, err := runner.Run(context.Background(), "", []string{"true", "exit 9"})
+	report, err := runner.Run(context.Background(), "", CommandChecks([]string{"true", "exit 9"}))
 	if err != nil {
 		t.Fatalf("expected no runner error for non-zero exit, got %v", err)
 	}
@@ -48,3 +50,23 @@ func TestRunnerRunReturnsErrorWhenNoCommandsConfigured(t *testing.T) {
 		t.Fatal("expected error when no commands are configured")
 	}
 }
+
+func TestRunnerRunFailsCommandsThatTimeOut(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner().WithCommandTimeout(time.Second)
+	report, err := runner.Run(context.Background(), "", CommandChecks([]string{"sleep 30"}))
+	if err != nil {
+		t.Fatalf("expected a timeout to fail the check, not the runner: %v", err)
+	}
+	if report.Passed {
+		t.Fatal("expected report to fail")
+	}
+	first := report.Results[0]
+	if !first.TimedOut || first.ExitCode != 124 || !strings.Contains(first.Stderr, "timed out after 1s") {
+		t.Fatalf("unexpected timed out result: %+v", first)
+	}
+	if first.Duration > 10*time.Second {
+		t.Fatalf("expected the command to be killed promptly, took %s", first.Duration)
+	}
+}
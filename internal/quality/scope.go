@@ -0,0 +1,149 @@
+package quality
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Check scopes.
+const (
+	ScopeAll     = "all"
+	ScopeChanged = "changed"
+)
+
+// Placeholders a check's command can hold. Checks scoped to changes get the
+// story's changed files and Go packages; the full suite gets "." and "./...".
+const (
+	PlaceholderChangedFiles    = "{{changed_files}}"
+	PlaceholderChangedPackages = "{{changed_packages}}"
+)
+
+// ValidScope reports whether scope is empty or a known scope.
+func ValidScope(scope string) bool {
+	switch scope {
+	case "", ScopeAll, ScopeChanged:
+		return true
+	}
+	return false
+}
+
+// Changes are what a story changed in the work dir.
+type Changes struct {
+	// Files are the changed files that still exist, relative to the work
+	// dir, with forward slashes.
+	Files []string
+	// Packages are the Go packages holding changed files, as ./dir
+	// patterns.
+	Packages []string
+}
+
+// NewChanges collects the changes of files, given relative to workDir. A
+// changed file belongs to the package of the nearest directory holding .go
+// files, so testdata and embedded files count too. A changed go.mod or
+// go.sum affects every package.
+func NewChanges(workDir string, files []string) Changes {
+	changes := Changes{}
+	packages := map[string]struct{}{}
+	allPackages := false
+	for _, file := range files {
+		file = path.Clean(filepath.ToSlash(file))
+		if _, err := os.Stat(filepath.Join(workDir, filepath.FromSlash(file))); err == nil {
+			changes.Files = append(changes.Files, file)
+		}
+		if base := path.Base(file); base == "go.mod" || base == "go.sum" {
+			allPackages = true
+			continue
+		}
+		if dir, ok := goPackageDir(workDir, path.Dir(file)); ok {
+			packages[dir] = struct{}{}
+		}
+	}
+	sort.Strings(changes.Files)
+	if allPackages {
+		changes.Packages = []string{"./..."}
+		return changes
+	}
+	for dir := range packages {
+		if dir == "." {
+			changes.Packages = append(changes.Packages, ".")
+			continue
+		}
+		changes.Packages = append(changes.Packages, "./"+dir)
+	}
+	sort.Strings(changes.Packages)
+	return changes
+}
+
+// goPackageDir walks up from dir to the nearest directory holding .go files.
+func goPackageDir(workDir, dir string) (string, bool) {
+	for {
+		matches, _ := filepath.Glob(filepath.Join(workDir, filepath.FromSlash(dir), "*.go"))
+		if len(matches) > 0 {
+			return dir, true
+		}
+		if dir == "." || dir == "/" {
+			return "", false
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// HasScopedChecks reports whether any check is scoped to changes.
+func HasScopedChecks(checks []Check) bool {
+	for _, check := range checks {
+		if check.Scope == ScopeChanged {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeChecks returns checks with their placeholders filled in. With changes,
+// checks scoped to them get the changed files and packages, and are marked
+// Unchanged when a placeholder they use comes out empty, or, without
+// placeholders, when nothing changed. Other checks, and every check when
+// changes is nil, run on the whole work dir.
+func ScopeChecks(checks []Check, changes *Changes) []Check {
+	scoped := make([]Check, len(checks))
+	for i, check := range checks {
+		files, packages := ".", "./..."
+		if changes != nil && check.Scope == ScopeChanged {
+			files = shellJoin(changes.Files)
+			packages = shellJoin(changes.Packages)
+			usesFiles := strings.Contains(check.Command, PlaceholderChangedFiles)
+			usesPackages := strings.Contains(check.Command, PlaceholderChangedPackages)
+			switch {
+			case usesFiles && files == "", usesPackages && packages == "":
+				check.Unchanged = true
+			case !usesFiles && !usesPackages && len(changes.Files) == 0 && len(changes.Packages) == 0:
+				check.Unchanged = true
+			}
+		}
+		// The name stays that of the unexpanded command, so depends_on and
+		// reports keep referring to it.
+		check.Name = check.name()
+		check.Command = strings.ReplaceAll(check.Command, PlaceholderChangedFiles, files)
+		check.Command = strings.ReplaceAll(check.Command, PlaceholderChangedPackages, packages)
+		scoped[i] = check
+	}
+	return scoped
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./@:+=,-]+$`)
+
+// shellJoin quotes values for bash where needed and joins them with spaces.
+func shellJoin(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		if shellSafe.MatchString(value) {
+			quoted[i] = value
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
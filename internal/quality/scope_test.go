@@ -0,0 +1,131 @@
+package quality
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeScopeFile(t *testing.T, dir, name string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+}
+
+func TestNewChangesMapsFilesToPackages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeScopeFile(t, dir, "main.go")
+	writeScopeFile(t, dir, "internal/app/app.go")
+	writeScopeFile(t, dir, "internal/app/testdata/case.txt")
+	writeScopeFile(t, dir, "internal/my pkg/pkg.go")
+	writeScopeFile(t, dir, "docs/readme.md")
+
+	changes := NewChanges(dir, []string{
+		"internal/app/testdata/case.txt",
+		"main.go",
+		"internal/my pkg/pkg.go",
+		"internal/gone/gone.go",
+		"docs/readme.md",
+	})
+	wantFiles := []string{"docs/readme.md", "internal/app/testdata/case.txt", "internal/my pkg/pkg.go", "main.go"}
+	if !reflect.DeepEqual(changes.Files, wantFiles) {
+		t.Fatalf("unexpected files %v", changes.Files)
+	}
+	// docs has no Go files, so it falls back to the root package; a deleted
+	// package has none left to test.
+	wantPackages := []string{".", "./internal/app", "./internal/my pkg"}
+	if !reflect.DeepEqual(changes.Packages, wantPackages) {
+		t.Fatalf("unexpected packages %v", changes.Packages)
+	}
+
+	changes = NewChanges(dir, []string{"main.go", "go.sum"})
+	if !reflect.DeepEqual(changes.Packages, []string{"./..."}) {
+		t.Fatalf("expected a go.sum change to affect every package, got %v", changes.Packages)
+	}
+}
+
+func TestScopeChecksFillsPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{
+		{Command: "go test {{changed_packages}}", Scope: ScopeChanged},
+		{Command: "eslint {{changed_files}}", Scope: ScopeChanged},
+		{Name: "vet", Command: "go vet {{changed_packages}}"},
+		{Command: "make lint", Scope: ScopeChanged},
+	}
+	changes := Changes{Files: []string{"a.go", "my file.go"}, Packages: []string{"."}}
+	scoped := ScopeChecks(checks, &changes)
+
+	want := []string{"go test .", "eslint a.go 'my file.go'", "go vet ./...", "make lint"}
+	for i, check := range scoped {
+		if check.Command != want[i] {
+			t.Fatalf("check %d: expected %q, got %q", i, want[i], check.Command)
+		}
+		if check.Unchanged {
+			t.Fatalf("check %d: expected it to run", i)
+		}
+	}
+	if scoped[0].Name != "go test {{changed_packages}}" || scoped[2].Name != "vet" {
+		t.Fatalf("expected names to keep the unexpanded command, got %q and %q", scoped[0].Name, scoped[2].Name)
+	}
+
+	full := ScopeChecks(checks, nil)
+	if full[0].Command != "go test ./..." || full[1].Command != "eslint ." || full[0].Unchanged {
+		t.Fatalf("expected the full suite to run on everything, got %+v", full[:2])
+	}
+}
+
+func TestScopeChecksMarksChecksWithNothingInScope(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{
+		{Command: "go test {{changed_packages}}", Scope: ScopeChanged},
+		{Command: "eslint {{changed_files}}", Scope: ScopeChanged},
+		{Command: "make lint", Scope: ScopeChanged},
+		{Command: "go vet ./..."},
+	}
+	// Only a file outside any Go package changed.
+	scoped := ScopeChecks(checks, &Changes{Files: []string{"README.md"}})
+	got := []bool{scoped[0].Unchanged, scoped[1].Unchanged, scoped[2].Unchanged, scoped[3].Unchanged}
+	if !reflect.DeepEqual(got, []bool{true, false, false, false}) {
+		t.Fatalf("unexpected unchanged flags %v", got)
+	}
+
+	scoped = ScopeChecks(checks, &Changes{})
+	got = []bool{scoped[0].Unchanged, scoped[1].Unchanged, scoped[2].Unchanged, scoped[3].Unchanged}
+	if !reflect.DeepEqual(got, []bool{true, true, true, false}) {
+		t.Fatalf("unexpected unchanged flags without changes %v", got)
+	}
+}
+
+func TestRunnerPassesUnchangedChecksWithoutRunningThem(t *testing.T) {
+	t.Parallel()
+
+	checks := ScopeChecks([]Check{
+		{Command: "exit 3 {{changed_packages}}", Scope: ScopeChanged},
+		{Command: "true", DependsOn: []string{"exit 3 {{changed_packages}}"}},
+	}, &Changes{})
+	report, err := NewRunner().Run(context.Background(), "", checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !report.Passed {
+		t.Fatalf("expected unchanged checks to pass, got %+v", report.Results)
+	}
+	first := report.Results[0]
+	if !first.Unchanged || first.ExitCode != 0 || first.Duration != 0 {
+		t.Fatalf("unexpected unchanged result %+v", first)
+	}
+	if report.Results[1].Skipped || report.Results[1].Unchanged {
+		t.Fatalf("expected the dependent check to run, got %+v", report.Results[1])
+	}
+}
@@ -0,0 +1,483 @@
+package quality
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats a check's stdout or report can be parsed as to report per-test
+// results.
+const (
+	FormatGoTestJSON = "go-test-json"
+	FormatJUnit      = "junit"
+	FormatTAP        = "tap"
+)
+
+// ValidFormat reports whether format is empty or a known output format.
+func ValidFormat(format string) bool {
+	switch format {
+	case "", FormatGoTestJSON, FormatJUnit, FormatTAP:
+		return true
+	}
+	return false
+}
+
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "passed"
+	TestFailed  TestStatus = "failed"
+	TestSkipped TestStatus = "skipped"
+)
+
+// TestCase is one test parsed from a check's output.
+type TestCase struct {
+	// Suite is the Go package, the JUnit class or suite, or empty for TAP.
+	Suite    string
+	Name     string
+	Status   TestStatus
+	Duration time.Duration
+	// Message is the failure or skip message with the test's output. It is
+	// empty for passed tests.
+	Message string
+}
+
+// FullName returns "suite.name", or whichever of the two is set. A Go
+// package that failed outside its tests has no name.
+func (t TestCase) FullName() string {
+	switch {
+	case t.Suite == "":
+		return t.Name
+	case t.Name == "":
+		return t.Suite
+	}
+	return t.Suite + "." + t.Name
+}
+
+// TestCounts counts a result's tests by status.
+type TestCounts struct {
+	Passed  int
+	Failed  int
+	Skipped int
+}
+
+func (c TestCounts) Total() int {
+	return c.Passed + c.Failed + c.Skipped
+}
+
+// TestCounts counts the result's parsed tests.
+func (r Result) TestCounts() TestCounts {
+	counts := TestCounts{}
+	for _, test := range r.Tests {
+		switch test.Status {
+		case TestPassed:
+			counts.Passed++
+		case TestFailed:
+			counts.Failed++
+		case TestSkipped:
+			counts.Skipped++
+		}
+	}
+	return counts
+}
+
+// FailedTests returns the result's failed tests in output order.
+func (r Result) FailedTests() []TestCase {
+	var failed []TestCase
+	for _, test := range r.Tests {
+		if test.Status == TestFailed {
+			failed = append(failed, test)
+		}
+	}
+	return failed
+}
+
+// TestsExplain reports whether the parsed tests account for the result: it
+// passed, or at least one test failed. Otherwise, say after a build error,
+// only the raw output does.
+func (r Result) TestsExplain() bool {
+	if len(r.Tests) == 0 {
+		return false
+	}
+	return r.Passed() || len(r.FailedTests()) > 0
+}
+
+// ParseTests parses the per-test results in output, written in format.
+func ParseTests(format, output string) ([]TestCase, error) {
+	switch format {
+	case FormatGoTestJSON:
+		return parseGoTestJSON(output)
+	case FormatJUnit:
+		return parseJUnit(output)
+	case FormatTAP:
+		return parseTAP(output)
+	}
+	return nil, fmt.Errorf("unknown quality output format %q", format)
+}
+
+// goTestEvent is one line of `go test -json` (test2json) output.
+type goTestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+	// ImportPath and FailedBuild tie build-output events (Go 1.24+) to the
+	// package that failed to build.
+	ImportPath  string
+	FailedBuild string
+}
+
+func parseGoTestJSON(output string) ([]TestCase, error) {
+	type testKey struct{ pkg, test string }
+	var (
+		tests       []TestCase
+		index       = map[testKey]int{}
+		outputs     = map[testKey]*strings.Builder{}
+		buildOutput = map[string]*strings.Builder{}
+		pkgFailed   = map[string]bool{}
+		events      int
+	)
+	appendOutput := func(key testKey, text string) {
+		builder, ok := outputs[key]
+		if !ok {
+			builder = &strings.Builder{}
+			outputs[key] = builder
+		}
+		builder.WriteString(text)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var event goTestEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+		events++
+		key := testKey{pkg: event.Package, test: event.Test}
+
+		switch {
+		case event.Action == "build-output":
+			builder, ok := buildOutput[event.ImportPath]
+			if !ok {
+				builder = &strings.Builder{}
+				buildOutput[event.ImportPath] = builder
+			}
+			builder.WriteString(event.Output)
+			continue
+		case event.Test == "":
+			// Package-level events.
+			switch event.Action {
+			case "output":
+				appendOutput(key, event.Output)
+			case "fail":
+				pkgFailed[event.Package] = true
+				if event.FailedBuild != "" {
+					if builder, ok := buildOutput[event.FailedBuild]; ok {
+						appendOutput(key, builder.String())
+					}
+				}
+			}
+			continue
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(tests)
+			index[key] = i
+			tests = append(tests, TestCase{Suite: event.Package, Name: event.Test})
+		}
+		switch event.Action {
+		case "output":
+			if !isGoTestFrameLine(event.Output) {
+				appendOutput(key, event.Output)
+			}
+		case "pass":
+			tests[i].Status = TestPassed
+		case "fail":
+			tests[i].Status = TestFailed
+		case "skip":
+			tests[i].Status = TestSkipped
+		}
+		if event.Elapsed > 0 {
+			tests[i].Duration = time.Duration(event.Elapsed * float64(time.Second))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if events == 0 {
+		return nil, errors.New("no go test -json events found")
+	}
+
+	failedPackages := map[string]bool{}
+	for i := range tests {
+		test := &tests[i]
+		if test.Status == "" {
+			// The test never finished, as when the binary panics or is
+			// killed.
+			test.Status = TestFailed
+		}
+		if test.Status != TestPassed {
+			if builder, ok := outputs[testKey{pkg: test.Suite, test: test.Name}]; ok {
+				test.Message = strings.TrimSpace(builder.String())
+			}
+		}
+		if test.Status == TestFailed {
+			failedPackages[test.Suite] = true
+		}
+	}
+	// A package that failed without a failing test failed to build, or
+	// outside its tests; report the package itself.
+	var packages []string
+	for pkg := range pkgFailed {
+		if !failedPackages[pkg] {
+			packages = append(packages, pkg)
+		}
+	}
+	sort.Strings(packages)
+	for _, pkg := range packages {
+		test := TestCase{Suite: pkg, Status: TestFailed}
+		if builder, ok := outputs[testKey{pkg: pkg}]; ok {
+			test.Message = strings.TrimSpace(builder.String())
+		}
+		tests = append(tests, test)
+	}
+	return tests, nil
+}
+
+// isGoTestFrameLine reports whether line is one of the "=== RUN" or
+// "--- FAIL" lines go test frames test output with.
+func isGoTestFrameLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Cases  []junitCase  `xml:"testcase"`
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+	SystemErr string        `xml:"system-err"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (m junitMessage) String() string {
+	message := strings.TrimSpace(m.Message)
+	text := strings.TrimSpace(m.Text)
+	switch {
+	case message == "" || strings.Contains(text, message):
+		return text
+	case text == "":
+		return message
+	}
+	return message + "\n" + text
+}
+
+// parseJUnit reads the first <testsuites> or <testsuite> element of output;
+// anything the command printed before it is ignored.
+func parseJUnit(output string) ([]TestCase, error) {
+	decoder := xml.NewDecoder(strings.NewReader(output))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("no JUnit <testsuite> element found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JUnit XML: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || (start.Name.Local != "testsuites" && start.Name.Local != "testsuite") {
+			continue
+		}
+		var root junitSuite
+		if err := decoder.DecodeElement(&root, &start); err != nil {
+			return nil, fmt.Errorf("invalid JUnit XML: %w", err)
+		}
+		tests := []TestCase{}
+		collectJUnit(&tests, root)
+		return tests, nil
+	}
+}
+
+func collectJUnit(tests *[]TestCase, suite junitSuite) {
+	for _, c := range suite.Cases {
+		test := TestCase{Suite: c.Classname, Name: c.Name, Status: TestPassed}
+		if test.Suite == "" {
+			test.Suite = suite.Name
+		}
+		if seconds, err := strconv.ParseFloat(strings.ReplaceAll(c.Time, ",", ""), 64); err == nil {
+			test.Duration = time.Duration(seconds * float64(time.Second))
+		}
+		switch {
+		case c.Failure != nil:
+			test.Status = TestFailed
+			test.Message = joinNonEmpty(c.Failure.String(), strings.TrimSpace(c.SystemOut), strings.TrimSpace(c.SystemErr))
+		case c.Error != nil:
+			test.Status = TestFailed
+			test.Message = joinNonEmpty(c.Error.String(), strings.TrimSpace(c.SystemOut), strings.TrimSpace(c.SystemErr))
+		case c.Skipped != nil:
+			test.Status = TestSkipped
+			test.Message = c.Skipped.String()
+		}
+		*tests = append(*tests, test)
+	}
+	for _, nested := range suite.Suites {
+		collectJUnit(tests, nested)
+	}
+}
+
+func joinNonEmpty(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// parseTAP reads top-level TAP test lines. Indented lines and "#" comments
+// after a test line, such as YAML diagnostics, become its message; TODO
+// tests count as skipped.
+func parseTAP(output string) ([]TestCase, error) {
+	tests := []TestCase{}
+	var message []string
+	sawPlan := false
+	flush := func() {
+		if len(tests) > 0 && len(message) > 0 {
+			last := &tests[len(tests)-1]
+			if last.Status != TestPassed {
+				last.Message = strings.TrimSpace(strings.Join(message, "\n"))
+			}
+			if last.Duration == 0 {
+				last.Duration = tapDuration(message)
+			}
+		}
+		message = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case isTAPTestLine(line):
+			flush()
+			tests = append(tests, parseTAPTestLine(line, len(tests)+1))
+		case strings.HasPrefix(line, "Bail out!"):
+			flush()
+			reason := strings.TrimSpace(strings.TrimPrefix(line, "Bail out!"))
+			tests = append(tests, TestCase{Name: "Bail out!", Status: TestFailed, Message: reason})
+		case isTAPPlan(trimmed) && line == trimmed:
+			sawPlan = true
+		case strings.HasPrefix(trimmed, "TAP version"):
+		case line != trimmed || strings.HasPrefix(line, "#"):
+			message = append(message, line)
+		}
+	}
+	flush()
+	if len(tests) == 0 && !sawPlan {
+		return nil, errors.New("no TAP test lines found")
+	}
+	return tests, nil
+}
+
+func parseTAPTestLine(line string, number int) TestCase {
+	test := TestCase{Status: TestPassed}
+	rest := strings.TrimPrefix(line, "ok")
+	if strings.HasPrefix(line, "not ok") {
+		test.Status = TestFailed
+		rest = strings.TrimPrefix(line, "not ok")
+	}
+	rest = strings.TrimSpace(rest)
+	// Drop the test number.
+	if end := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' }); end != 0 {
+		if end < 0 {
+			end = len(rest)
+		}
+		rest = strings.TrimSpace(rest[end:])
+	}
+	description, directive, _ := strings.Cut(rest, " # ")
+	if strings.HasPrefix(rest, "# ") {
+		description, directive = "", strings.TrimPrefix(rest, "# ")
+	}
+	description = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(description), "- "))
+	directive = strings.TrimSpace(directive)
+	upper := strings.ToUpper(directive)
+	if strings.HasPrefix(upper, "SKIP") || strings.HasPrefix(upper, "TODO") {
+		test.Status = TestSkipped
+		test.Message = directive
+	}
+	test.Name = description
+	if test.Name == "" {
+		test.Name = fmt.Sprintf("test %d", number)
+	}
+	return test
+}
+
+func isTAPTestLine(line string) bool {
+	for _, prefix := range []string{"ok", "not ok"} {
+		if line == prefix || strings.HasPrefix(line, prefix+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func isTAPPlan(line string) bool {
+	plan, _, _ := strings.Cut(line, "#")
+	first, last, ok := strings.Cut(strings.TrimSpace(plan), "..")
+	if !ok {
+		return false
+	}
+	_, err1 := strconv.Atoi(first)
+	_, err2 := strconv.Atoi(last)
+	return err1 == nil && err2 == nil
+}
+
+// tapDuration reads the duration_ms field node-tap and others write in a
+// test's YAML diagnostics.
+func tapDuration(lines []string) time.Duration {
+	for _, line := range lines {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "duration_ms:")
+		if !ok {
+			continue
+		}
+		if ms, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	return 0
+}
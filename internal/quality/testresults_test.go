@@ -0,0 +1,221 @@
+package quality
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const goTestJSONOutput = `{"Action":"start","Package":"example.com/app"}
+{"Action":"run","Package":"example.com/app","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"--- PASS: TestAdd (0.00s)\n"}
+{"Action":"pass","Package":"example.com/app","Test":"TestAdd","Elapsed":0.01}
+{"Action":"run","Package":"example.com/app","Test":"TestSub"}
+{"Action":"output","Package":"example.com/app","Test":"TestSub","Output":"=== RUN   TestSub\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestSub","Output":"    app_test.go:12: got 1, want 2\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestSub","Output":"--- FAIL: TestSub (0.25s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestSub","Elapsed":0.25}
+{"Action":"run","Package":"example.com/app","Test":"TestNetwork"}
+{"Action":"output","Package":"example.com/app","Test":"TestNetwork","Output":"    app_test.go:20: no network\n"}
+{"Action":"skip","Package":"example.com/app","Test":"TestNetwork"}
+{"Action":"output","Package":"example.com/app","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.3}
+{"ImportPath":"example.com/broken [example.com/broken.test]","Action":"build-output","Output":"broken.go:3:1: syntax error\n"}
+{"ImportPath":"example.com/broken [example.com/broken.test]","Action":"build-fail"}
+{"Action":"start","Package":"example.com/broken"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0,"FailedBuild":"example.com/broken [example.com/broken.test]"}
+`
+
+func TestParseGoTestJSON(t *testing.T) {
+	t.Parallel()
+
+	tests, err := ParseTests(FormatGoTestJSON, "go: downloading example.com/dep\n"+goTestJSONOutput)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(tests) != 4 {
+		t.Fatalf("expected 4 tests, got %+v", tests)
+	}
+	if tests[0].FullName() != "example.com/app.TestAdd" || tests[0].Status != TestPassed || tests[0].Duration != 10*time.Millisecond || tests[0].Message != "" {
+		t.Fatalf("unexpected passed test %+v", tests[0])
+	}
+	if tests[1].Status != TestFailed || tests[1].Message != "app_test.go:12: got 1, want 2" || tests[1].Duration != 250*time.Millisecond {
+		t.Fatalf("unexpected failed test %+v", tests[1])
+	}
+	if tests[2].Status != TestSkipped || tests[2].Message != "app_test.go:20: no network" {
+		t.Fatalf("unexpected skipped test %+v", tests[2])
+	}
+	// The package that failed to build is reported with its build output.
+	if tests[3].FullName() != "example.com/broken" || tests[3].Status != TestFailed || tests[3].Message != "broken.go:3:1: syntax error" {
+		t.Fatalf("unexpected build failure %+v", tests[3])
+	}
+
+	if _, err := ParseTests(FormatGoTestJSON, "FAIL\texample.com/app [setup failed]\n"); err == nil {
+		t.Fatal("expected output without events to fail to parse")
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	t.Parallel()
+
+	output := `Running tests...
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="4" failures="1" errors="1">
+  <testsuite name="math" tests="4">
+    <testcase classname="math.AddTest" name="adds" time="0.012"></testcase>
+    <testcase classname="math.SubTest" name="subtracts" time="1,200.5">
+      <failure message="expected 2">AssertionError: expected 2, got 1
+    at sub.js:4</failure>
+      <system-out>debug line</system-out>
+    </testcase>
+    <testcase name="divides"><error message="division by zero"></error></testcase>
+    <testcase classname="math.MulTest" name="multiplies"><skipped message="not implemented"/></testcase>
+  </testsuite>
+</testsuites>`
+	tests, err := ParseTests(FormatJUnit, output)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(tests) != 4 {
+		t.Fatalf("expected 4 tests, got %+v", tests)
+	}
+	if tests[0].FullName() != "math.AddTest.adds" || tests[0].Status != TestPassed || tests[0].Duration != 12*time.Millisecond {
+		t.Fatalf("unexpected passed test %+v", tests[0])
+	}
+	if tests[1].Status != TestFailed || tests[1].Duration != 1200500*time.Millisecond {
+		t.Fatalf("unexpected failed test %+v", tests[1])
+	}
+	if want := "AssertionError: expected 2, got 1\n    at sub.js:4\ndebug line"; tests[1].Message != want {
+		t.Fatalf("expected message %q, got %q", want, tests[1].Message)
+	}
+	if tests[2].FullName() != "math.divides" || tests[2].Status != TestFailed || tests[2].Message != "division by zero" {
+		t.Fatalf("unexpected errored test %+v", tests[2])
+	}
+	if tests[3].Status != TestSkipped || tests[3].Message != "not implemented" {
+		t.Fatalf("unexpected skipped test %+v", tests[3])
+	}
+
+	if _, err := ParseTests(FormatJUnit, "no xml here"); err == nil {
+		t.Fatal("expected output without a test suite to fail to parse")
+	}
+}
+
+func TestParseTAP(t *testing.T) {
+	t.Parallel()
+
+	output := `TAP version 13
+1..5
+ok 1 - parses input
+not ok 2 - rejects empty input
+  ---
+  message: expected an error
+  duration_ms: 3.5
+  ...
+# extra diagnostics
+ok 3 - network # SKIP offline
+not ok 4 # TODO not written yet
+ok 5
+`
+	tests, err := ParseTests(FormatTAP, output)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(tests) != 5 {
+		t.Fatalf("expected 5 tests, got %+v", tests)
+	}
+	if tests[0].Name != "parses input" || tests[0].Status != TestPassed {
+		t.Fatalf("unexpected passed test %+v", tests[0])
+	}
+	if tests[1].Name != "rejects empty input" || tests[1].Status != TestFailed || tests[1].Duration != 3500*time.Microsecond {
+		t.Fatalf("unexpected failed test %+v", tests[1])
+	}
+	if !strings.Contains(tests[1].Message, "message: expected an error") || !strings.Contains(tests[1].Message, "# extra diagnostics") {
+		t.Fatalf("expected diagnostics in message, got %q", tests[1].Message)
+	}
+	if tests[2].Name != "network" || tests[2].Status != TestSkipped || tests[2].Message != "SKIP offline" {
+		t.Fatalf("unexpected skipped test %+v", tests[2])
+	}
+	if tests[3].Name != "test 4" || tests[3].Status != TestSkipped {
+		t.Fatalf("expected TODO test to count as skipped, got %+v", tests[3])
+	}
+	if tests[4].Name != "test 5" || tests[4].Status != TestPassed {
+		t.Fatalf("unexpected unnamed test %+v", tests[4])
+	}
+
+	tests, err = ParseTests(FormatTAP, "1..2\nok 1\nBail out! database down\n")
+	if err != nil || len(tests) != 2 || tests[1].Status != TestFailed || tests[1].Message != "database down" {
+		t.Fatalf("expected bail out to fail, got %+v (%v)", tests, err)
+	}
+	if _, err := ParseTests(FormatTAP, "plain output\n"); err == nil {
+		t.Fatal("expected output without TAP lines to fail to parse")
+	}
+}
+
+func TestRunnerParsesCheckOutput(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{
+		{Name: "tap", Command: "printf '1..2\\nok 1 - a\\nnot ok 2 - b\\n'; exit 1", Format: FormatTAP},
+		{Name: "garbled", Command: "echo not tap", Format: FormatTAP},
+	}
+	report, err := NewRunner().Run(context.Background(), t.TempDir(), checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	tap := report.Results[0]
+	if tap.Format != FormatTAP || tap.TestCounts() != (TestCounts{Passed: 1, Failed: 1}) || !tap.TestsExplain() {
+		t.Fatalf("unexpected parsed result %+v", tap)
+	}
+	if failed := tap.FailedTests(); len(failed) != 1 || failed[0].Name != "b" {
+		t.Fatalf("expected test b to fail, got %+v", failed)
+	}
+	garbled := report.Results[1]
+	if garbled.Tests != nil || !strings.Contains(garbled.Stderr, "could not parse tap output") {
+		t.Fatalf("expected unparsed output to be noted, got %+v", garbled)
+	}
+}
+
+func TestRunnerParsesCheckReportFiles(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	stale := filepath.Join(workDir, "old.xml")
+	if err := os.WriteFile(stale, []byte(`<testsuite><testcase name="old"/></testsuite>`), 0o644); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(stale, past, past); err != nil {
+		t.Fatalf("age report: %v", err)
+	}
+
+	checks := []Check{
+		{
+			Name:    "junit",
+			Command: `mkdir -p reports && printf '<testsuite><testcase name="a"/><testcase name="b"><failure message="boom"/></testcase></testsuite>' > reports/junit.xml && echo done && exit 1`,
+			Format:  FormatJUnit,
+			Report:  "reports/junit.xml",
+		},
+		{Name: "missing", Command: "printf '1..1\\nok 1 - a\\n'", Format: FormatTAP, Report: "missing.tap"},
+		{Name: "stale", Command: `echo '<testsuite><testcase name="fresh"/></testsuite>'`, Format: FormatJUnit, Report: "old.xml"},
+	}
+	report, err := NewRunner().Run(context.Background(), workDir, checks)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	junit := report.Results[0]
+	if failed := junit.FailedTests(); junit.TestCounts() != (TestCounts{Passed: 1, Failed: 1}) || len(failed) != 1 || failed[0].Message != "boom" {
+		t.Fatalf("expected the report file to be parsed, got %+v", junit)
+	}
+	missing := report.Results[1]
+	if missing.TestCounts() != (TestCounts{Passed: 1}) || !strings.Contains(missing.Stderr, "could not read report missing.tap, parsing stdout") {
+		t.Fatalf("expected stdout to be parsed without the report, got %+v", missing)
+	}
+	staleResult := report.Results[2]
+	if len(staleResult.Tests) != 1 || staleResult.Tests[0].Name != "fresh" || !strings.Contains(staleResult.Stderr, "not written by this run") {
+		t.Fatalf("expected a report from an earlier run to be ignored, got %+v", staleResult)
+	}
+}
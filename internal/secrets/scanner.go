@@ -0,0 +1,314 @@
+// Package secrets finds credentials and sensitive files in the changes a story
+// is about to commit. The scan is deterministic: regular expressions, an
+// entropy threshold, and path patterns, with no model involved.
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule finds one kind of secret in a line.
+type Rule struct {
+	ID          string
+	Description string
+	// Pattern matches the secret. When it has a capture group, the first
+	// group is the secret; otherwise the whole match is.
+	Pattern *regexp.Regexp
+	// MinEntropy is the Shannon entropy, in bits per character, the secret
+	// must reach. Zero accepts any match.
+	MinEntropy float64
+}
+
+// SensitiveFileRule is the rule ID of findings for blocked paths.
+const SensitiveFileRule = "sensitive-file"
+
+// DefaultRules covers common key formats, private keys, and generic
+// assignments of high-entropy values to secret-looking names.
+func DefaultRules() []Rule {
+	return []Rule{
+		{ID: "private-key", Description: "private key", Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+		{ID: "aws-access-key-id", Description: "AWS access key ID", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`)},
+		{ID: "aws-secret-access-key", Description: "AWS secret access key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*(?::=|=>|[:=])\s*["']?([A-Za-z0-9/+=]{40})\b`), MinEntropy: 3.5},
+		{ID: "github-token", Description: "GitHub token", Pattern: regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,})\b`)},
+		{ID: "github-fine-grained-token", Description: "GitHub fine-grained token", Pattern: regexp.MustCompile(`\b(github_pat_[A-Za-z0-9_]{60,})\b`)},
+		{ID: "gitlab-token", Description: "GitLab personal access token", Pattern: regexp.MustCompile(`\b(glpat-[A-Za-z0-9_\-]{20,})`)},
+		{ID: "slack-token", Description: "Slack token", Pattern: regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})`)},
+		{ID: "slack-webhook", Description: "Slack webhook URL", Pattern: regexp.MustCompile(`(https://hooks\.slack\.com/services/T[A-Za-z0-9_]+/B[A-Za-z0-9_]+/[A-Za-z0-9_]+)`)},
+		{ID: "stripe-secret-key", Description: "Stripe secret key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{24,})\b`)},
+		{ID: "google-api-key", Description: "Google API key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})`)},
+		{ID: "anthropic-api-key", Description: "Anthropic API key", Pattern: regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_\-]{32,})`)},
+		{ID: "openai-api-key", Description: "OpenAI API key", Pattern: regexp.MustCompile(`\b(sk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_\-]{32,})`), MinEntropy: 3.5},
+		{ID: "npm-token", Description: "npm token", Pattern: regexp.MustCompile(`\b(npm_[A-Za-z0-9]{36})\b`)},
+		{
+			ID:          "generic-secret",
+			Description: "high-entropy value assigned to a secret",
+			Pattern:     regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|access[_-]?key|private[_-]?key)[A-Za-z0-9_\-]*["']?\s*(?::=|=>|[:=])\s*["'` + "`" + `]?([A-Za-z0-9+/_\-.=~!@#%^*]{16,})`),
+			MinEntropy:  3.5,
+		},
+	}
+}
+
+// DefaultBlockedPaths are files that should not be committed whatever they
+// hold. Names ending in .example, .sample, .template, or .dist are exempt,
+// so .env.example can still be committed.
+var DefaultBlockedPaths = []string{
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.jks",
+	"*.keystore",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	".netrc",
+	".pgpass",
+	".htpasswd",
+}
+
+var exampleSuffixes = []string{".example", ".sample", ".template", ".dist"}
+
+// placeholder matches values that are obviously not real secrets.
+var placeholder = regexp.MustCompile(`(?i)example|placeholder|changeme|dummy|redacted|x{6,}|\*{4,}|\$\{|<[^>]*>`)
+
+// Allowlist suppresses findings.
+type Allowlist struct {
+	// Paths are path patterns whose files are not scanned or blocked.
+	Paths []string
+	// Patterns are matched against each secret and the line it is on.
+	Patterns []*regexp.Regexp
+}
+
+// ParseAllowlist compiles patterns into an Allowlist with paths.
+func ParseAllowlist(paths, patterns []string) (Allowlist, error) {
+	allowlist := Allowlist{Paths: paths}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return Allowlist{}, fmt.Errorf("invalid allow pattern %q: %w", pattern, err)
+		}
+		allowlist.Patterns = append(allowlist.Patterns, compiled)
+	}
+	return allowlist, nil
+}
+
+// Scanner finds secrets and sensitive files in changes.
+type Scanner struct {
+	rules        []Rule
+	blockedPaths []string
+	allowlist    Allowlist
+}
+
+// NewScanner returns a scanner with the default rules and blocked paths.
+func NewScanner() Scanner {
+	return Scanner{
+		rules:        DefaultRules(),
+		blockedPaths: append([]string(nil), DefaultBlockedPaths...),
+	}
+}
+
+// WithBlockedPaths adds path patterns to the blocked paths.
+func (s Scanner) WithBlockedPaths(patterns []string) Scanner {
+	s.blockedPaths = append(append([]string(nil), s.blockedPaths...), patterns...)
+	return s
+}
+
+// WithAllowlist sets the allowlist.
+func (s Scanner) WithAllowlist(allowlist Allowlist) Scanner {
+	s.allowlist = allowlist
+	return s
+}
+
+// Line is a line a change adds.
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Change is a file a commit adds or modifies, with the lines it adds. Paths
+// use forward slashes.
+type Change struct {
+	Path  string
+	Lines []Line
+}
+
+// Finding is a secret or sensitive file. It never holds the secret itself.
+type Finding struct {
+	Path string
+	// Line is zero for sensitive files.
+	Line        int
+	RuleID      string
+	Description string
+	// Redacted is the secret with all but its first characters masked, or
+	// the blocked path pattern the file matched.
+	Redacted string
+}
+
+// String describes the finding on one line.
+func (f Finding) String() string {
+	location := f.Path
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+	}
+	return fmt.Sprintf("%s: %s (%s)", location, f.Description, f.Redacted)
+}
+
+// Scan returns the findings in changes, ordered by path and line.
+func (s Scanner) Scan(changes []Change) []Finding {
+	var findings []Finding
+	for _, change := range changes {
+		if MatchPath(s.allowlist.Paths, change.Path) {
+			continue
+		}
+		if pattern, ok := s.blockedPattern(change.Path); ok {
+			findings = append(findings, Finding{
+				Path:        change.Path,
+				RuleID:      SensitiveFileRule,
+				Description: "sensitive file",
+				Redacted:    pattern,
+			})
+		}
+		for _, line := range change.Lines {
+			findings = append(findings, s.scanLine(change.Path, line)...)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+func (s Scanner) blockedPattern(filePath string) (string, bool) {
+	base := path.Base(filePath)
+	for _, suffix := range exampleSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return "", false
+		}
+	}
+	for _, pattern := range s.blockedPaths {
+		if MatchPath([]string{pattern}, filePath) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// scanLine reports each secret in line once, under the first rule that
+// finds it.
+func (s Scanner) scanLine(filePath string, line Line) []Finding {
+	var findings []Finding
+	seen := map[string]bool{}
+	for _, rule := range s.rules {
+		for _, match := range rule.Pattern.FindAllStringSubmatch(line.Text, -1) {
+			secret := match[0]
+			if len(match) > 1 && match[1] != "" {
+				secret = match[1]
+			}
+			if seen[secret] || placeholder.MatchString(secret) || entropy(secret) < rule.MinEntropy || s.allowed(secret, line.Text) {
+				continue
+			}
+			seen[secret] = true
+			findings = append(findings, Finding{
+				Path:        filePath,
+				Line:        line.Number,
+				RuleID:      rule.ID,
+				Description: rule.Description,
+				Redacted:    Redact(secret),
+			})
+		}
+	}
+	return findings
+}
+
+func (s Scanner) allowed(secret, line string) bool {
+	for _, pattern := range s.allowlist.Patterns {
+		if pattern.MatchString(secret) || pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath reports whether filePath matches any of patterns. A pattern
+// without a slash matches any path element, so "*.pem" matches
+// certs/server.pem; one with a slash matches the path or a leading
+// directory of it, so "testdata/keys" matches testdata/keys/a.pem.
+func MatchPath(patterns []string, filePath string) bool {
+	elements := strings.Split(strings.Trim(filePath, "/"), "/")
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+		if !strings.Contains(pattern, "/") {
+			for _, element := range elements {
+				if ok, _ := path.Match(pattern, element); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for i := range elements {
+			if ok, _ := path.Match(pattern, strings.Join(elements[:i+1], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Redact masks secret, keeping its first four characters when it is long
+// enough for them not to give it away.
+func Redact(secret string) string {
+	if len(secret) < 12 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// entropy returns the Shannon entropy of value in bits per character.
+func entropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	bits := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// BlockedError is returned when findings block a commit. Its message is the
+// report.
+type BlockedError struct {
+	Findings []Finding
+}
+
+func (e *BlockedError) Error() string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "commit blocked: %d possible secret(s) or sensitive file(s) found:", len(e.Findings))
+	for _, finding := range e.Findings {
+		builder.WriteString("\n  ")
+		builder.WriteString(finding.String())
+	}
+	builder.WriteString("\nRemove them from the change, or add them to [secrets] allow_paths or allow_patterns.")
+	return builder.String()
+}
@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test secrets are assembled at run time so the repository itself holds
+// nothing a scanner would flag.
+var (
+	awsKeyID    = "AK" + "IA" + "Q3EGT5XNVR7PLW2M"
+	githubToken = "gh" + "p_" + "R8fK2mQ9vLx4TzW7pN3bJ6hC1sD5gY0aE2uI"
+	privateKey  = "-----BEGIN " + "RSA PRIVATE KEY-----"
+)
+
+func lines(texts ...string) []Line {
+	result := make([]Line, len(texts))
+	for i, text := range texts {
+		result[i] = Line{Number: i + 1, Text: text}
+	}
+	return result
+}
+
+func TestScanFindsKnownKeyFormats(t *testing.T) {
+	t.Parallel()
+
+	findings := NewScanner().Scan([]Change{{
+		Path: "internal/app/config.go",
+		Lines: lines(
+			`const region = "us-east-1"`,
+			`var key = "`+awsKeyID+`"`,
+			`token := "`+githubToken+`" // same token twice: `+githubToken,
+			privateKey,
+		),
+	}})
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+	want := []struct {
+		line int
+		rule string
+	}{{2, "aws-access-key-id"}, {3, "github-token"}, {4, "private-key"}}
+	for i, finding := range findings {
+		if finding.Line != want[i].line || finding.RuleID != want[i].rule {
+			t.Fatalf("finding %d: expected %s on line %d, got %+v", i, want[i].rule, want[i].line, finding)
+		}
+	}
+	if findings[0].Redacted != "AKIA****" {
+		t.Fatalf("expected a redacted key, got %q", findings[0].Redacted)
+	}
+}
+
+func TestScanUsesEntropyForGenericSecrets(t *testing.T) {
+	t.Parallel()
+
+	findings := NewScanner().Scan([]Change{{
+		Path: "settings.yaml",
+		Lines: lines(
+			`api_key: "k9Qz4Lw8Rt2Vn6Yp3Hs7Df1G"`,
+			`password = "aaaaaaaaaaaaaaaaaaaa"`,
+			`client_secret: "your-client-secret-example"`,
+			`token = os.Getenv("API_TOKEN")`,
+		),
+	}})
+	if len(findings) != 1 || findings[0].Line != 1 || findings[0].RuleID != "generic-secret" {
+		t.Fatalf("expected only the high-entropy value, got %+v", findings)
+	}
+}
+
+func TestScanBlocksSensitivePaths(t *testing.T) {
+	t.Parallel()
+
+	scanner := NewScanner().WithBlockedPaths([]string{"config/prod/*"})
+	findings := scanner.Scan([]Change{
+		{Path: ".env"},
+		{Path: ".env.example"},
+		{Path: "deploy/certs/server.pem"},
+		{Path: "config/prod/settings.json"},
+		{Path: "config/dev/settings.json"},
+	})
+	var paths []string
+	for _, finding := range findings {
+		if finding.RuleID != SensitiveFileRule || finding.Line != 0 {
+			t.Fatalf("unexpected finding %+v", finding)
+		}
+		paths = append(paths, finding.Path+"="+finding.Redacted)
+	}
+	if got, want := strings.Join(paths, ","), ".env=.env,config/prod/settings.json=config/prod/*,deploy/certs/server.pem=*.pem"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestScanHonorsAllowlist(t *testing.T) {
+	t.Parallel()
+
+	allowlist, err := ParseAllowlist([]string{"testdata", "fixtures/*.pem"}, []string{`^AKIA`, `secrets:allow`})
+	if err != nil {
+		t.Fatalf("parse allowlist: %v", err)
+	}
+	findings := NewScanner().WithAllowlist(allowlist).Scan([]Change{
+		{Path: "internal/git/testdata/keys.txt", Lines: lines(githubToken)},
+		{Path: "fixtures/client.pem", Lines: lines(privateKey)},
+		{Path: "main.go", Lines: lines(
+			`id := "`+awsKeyID+`"`,
+			`token := "`+githubToken+`" // secrets:allow`,
+			`other := "`+githubToken+`"`,
+		)},
+	})
+	if len(findings) != 1 || findings[0].Path != "main.go" || findings[0].Line != 3 {
+		t.Fatalf("expected only the unlisted token, got %+v", findings)
+	}
+
+	if _, err := ParseAllowlist(nil, []string{"("}); err == nil {
+		t.Fatal("expected an invalid pattern to fail")
+	}
+}
+
+func TestBlockedErrorReportsRedactedFindings(t *testing.T) {
+	t.Parallel()
+
+	findings := NewScanner().Scan([]Change{
+		{Path: "id_rsa", Lines: lines(privateKey)},
+		{Path: "cmd/main.go", Lines: lines(``, `const token = "`+githubToken+`"`)},
+	})
+	report := (&BlockedError{Findings: findings}).Error()
+	for _, expected := range []string{
+		"commit blocked: 3 possible secret(s) or sensitive file(s) found:",
+		"cmd/main.go:2: GitHub token (ghp_****)",
+		"id_rsa: sensitive file (id_rsa)",
+		"id_rsa:1: private key (----****)",
+		"allow_paths or allow_patterns",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Fatalf("expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+	if strings.Contains(report, githubToken[4:12]) {
+		t.Fatalf("expected the report to leave the secret out, got:\n%s", report)
+	}
+}
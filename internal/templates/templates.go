@@ -39,3 +39,9 @@ var ArchitectureDesign string
 //
 //go:embed prd.md
 var PRD string
+
+// PRBody is the default text/template for pull request descriptions. It is
+// executed with loop.PRBodyData; completion.pr_body_template replaces it.
+//
+//go:embed pr-body.md
+var PRBody string
@@ -3,6 +3,7 @@ package templates_test
 import (
 	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/EstebanForge/daedalus/internal/templates"
 )
@@ -90,6 +91,22 @@ func TestPRDTemplate(t *testing.T) {
 
 // TestProjectSummaryPromptStructure verifies that the scan prompt embeds the
 // template so the LLM receives the exact section headings it must produce.
+func TestPRBodyTemplate(t *testing.T) {
+	t.Parallel()
+	if _, err := template.New("pr-body").Parse(templates.PRBody); err != nil {
+		t.Fatalf("PRBody template does not parse: %v", err)
+	}
+	// The PR body pulls these sections out of prd.md by heading.
+	for _, heading := range []string{"Problem Statement", "Goals"} {
+		if !strings.Contains(templates.PRD, "## "+heading) {
+			t.Errorf("PRD template: section %q used by PRBody not found", heading)
+		}
+		if !strings.Contains(templates.PRBody, `"`+heading+`"`) {
+			t.Errorf("PRBody template: section %q not referenced", heading)
+		}
+	}
+}
+
 func TestProjectSummaryTemplateHasPlaceholders(t *testing.T) {
 	t.Parallel()
 	// Every section must have a [...] placeholder so the LLM knows what to fill.
@@ -0,0 +1,133 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/EstebanForge/daedalus/internal/project"
+)
+
+// MergeConflictError reports that a story branch could not be merged cleanly.
+// The merge is aborted before the error is returned, so the target worktree is
+// left as it was.
+type MergeConflictError struct {
+	Branch string
+	Files  []string
+}
+
+func (e *MergeConflictError) Error() string {
+	if len(e.Files) == 0 {
+		return fmt.Sprintf("merge conflict merging %s", e.Branch)
+	}
+	return fmt.Sprintf("merge conflict merging %s: %s", e.Branch, strings.Join(e.Files, ", "))
+}
+
+// StoryBranch returns the branch used for a story in parallel runs.
+//
+// Git cannot hold both refs/heads/daedalus/<prd> and
+// refs/heads/daedalus/<prd>/<story>, so story branches use a "--" separator
+// to stay alongside the PRD branch created by Ensure.
+func StoryBranch(prdName, storyID string) string {
+	return "daedalus/" + prdName + "--" + storyID
+}
+
+// EnsureStory creates a fresh worktree for a single story, branched from the
+// current HEAD of fromDir. Any leftover worktree for the same story is removed
+// first and the story branch is reset, so every attempt starts from the PRD
+// branch as it is now.
+func (Manager) EnsureStory(ctx context.Context, baseDir, prdName, storyID, fromDir string) (SetupResult, error) {
+	name := strings.TrimSpace(prdName)
+	id := strings.TrimSpace(storyID)
+	if name == "" || id == "" {
+		return SetupResult{}, fmt.Errorf("PRD name and story ID are required for story worktrees")
+	}
+	if strings.TrimSpace(fromDir) == "" {
+		fromDir = baseDir
+	}
+
+	if err := ensureGitRepo(ctx, baseDir); err != nil {
+		return SetupResult{}, err
+	}
+
+	worktreePath := project.StoryWorktreePath(baseDir, name, id)
+	branch := StoryBranch(name, id)
+
+	if err := removeStoryWorktree(ctx, baseDir, worktreePath); err != nil {
+		return SetupResult{}, err
+	}
+	if err := os.MkdirAll(project.WorktreesPath(baseDir), 0o755); err != nil {
+		return SetupResult{}, fmt.Errorf("failed to create worktrees root: %w", err)
+	}
+
+	head, err := gitOutput(ctx, fromDir, "rev-parse", "HEAD")
+	if err != nil {
+		return SetupResult{}, err
+	}
+	if err := runGit(ctx, baseDir, "worktree", "add", "-B", branch, worktreePath, strings.TrimSpace(head)); err != nil {
+		return SetupResult{}, err
+	}
+
+	return SetupResult{Path: worktreePath, Branch: branch, Created: true}, nil
+}
+
+// MergeStory merges a story branch into the branch checked out in intoDir. On
+// conflict the merge is aborted and a *MergeConflictError is returned.
+func (Manager) MergeStory(ctx context.Context, intoDir, branch string) error {
+	message := "Merge " + branch
+	if _, err := gitOutput(ctx, intoDir, "merge", "--no-ff", "--no-edit", "-m", message, branch); err != nil {
+		conflicts, listErr := gitOutput(ctx, intoDir, "diff", "--name-only", "--diff-filter=U")
+		if listErr != nil || strings.TrimSpace(conflicts) == "" {
+			_ = runGit(ctx, intoDir, "merge", "--abort")
+			return err
+		}
+		_ = runGit(ctx, intoDir, "merge", "--abort")
+		return &MergeConflictError{Branch: branch, Files: strings.Fields(conflicts)}
+	}
+	return nil
+}
+
+// RemoveStory removes a story worktree. When deleteBranch is true the story
+// branch is deleted too; otherwise it is kept so failed work can be inspected.
+func (Manager) RemoveStory(ctx context.Context, baseDir, prdName, storyID string, deleteBranch bool) error {
+	worktreePath := project.StoryWorktreePath(baseDir, prdName, storyID)
+	if err := removeStoryWorktree(ctx, baseDir, worktreePath); err != nil {
+		return err
+	}
+	if !deleteBranch {
+		return nil
+	}
+
+	branch := StoryBranch(prdName, storyID)
+	exists, err := branchExists(ctx, baseDir, branch)
+	if err != nil || !exists {
+		return err
+	}
+	return runGit(ctx, baseDir, "branch", "-D", branch)
+}
+
+func removeStoryWorktree(ctx context.Context, baseDir, worktreePath string) error {
+	if _, err := os.Stat(worktreePath); err != nil {
+		if os.IsNotExist(err) {
+			return runGit(ctx, baseDir, "worktree", "prune")
+		}
+		return fmt.Errorf("failed to inspect worktree path %s: %w", worktreePath, err)
+	}
+
+	managed, err := isManagedWorktree(ctx, baseDir, worktreePath)
+	if err != nil {
+		return err
+	}
+	if !managed {
+		return fmt.Errorf("worktree path exists and is not managed by daedalus: %s", worktreePath)
+	}
+	return runGit(ctx, baseDir, "worktree", "remove", "--force", worktreePath)
+}
+
+// IsMergeConflict reports whether err is a *MergeConflictError.
+func IsMergeConflict(err error) bool {
+	var conflict *MergeConflictError
+	return errors.As(err, &conflict)
+}
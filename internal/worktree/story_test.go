@@ -0,0 +1,126 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureStoryCreatesBranchNextToPRDWorktree(t *testing.T) {
+	t.Parallel()
+
+	baseDir := initGitRepo(t)
+	manager := NewManager()
+
+	prdResult, err := manager.Ensure(context.Background(), baseDir, "main")
+	if err != nil {
+		t.Fatalf("ensure PRD worktree: %v", err)
+	}
+	storyResult, err := manager.EnsureStory(context.Background(), baseDir, "main", "US-001", prdResult.Path)
+	if err != nil {
+		t.Fatalf("ensure story worktree: %v", err)
+	}
+	if storyResult.Branch != "daedalus/main--US-001" {
+		t.Fatalf("unexpected story branch: %s", storyResult.Branch)
+	}
+	if !strings.HasSuffix(filepath.Clean(storyResult.Path), filepath.Join(".daedalus", "worktrees", "main--US-001")) {
+		t.Fatalf("unexpected story worktree path: %s", storyResult.Path)
+	}
+
+	// A second attempt replaces the leftover worktree instead of failing.
+	if _, err := manager.EnsureStory(context.Background(), baseDir, "main", "US-001", prdResult.Path); err != nil {
+		t.Fatalf("ensure story worktree again: %v", err)
+	}
+}
+
+func TestMergeStoryMergesCleanBranchAndRemovesIt(t *testing.T) {
+	t.Parallel()
+
+	baseDir := initGitRepo(t)
+	manager := NewManager()
+	ctx := context.Background()
+
+	prdResult, err := manager.Ensure(ctx, baseDir, "main")
+	if err != nil {
+		t.Fatalf("ensure PRD worktree: %v", err)
+	}
+	story, err := manager.EnsureStory(ctx, baseDir, "main", "US-001", prdResult.Path)
+	if err != nil {
+		t.Fatalf("ensure story worktree: %v", err)
+	}
+	commitFile(t, story.Path, "feature.txt", "feature\n")
+
+	if err := manager.MergeStory(ctx, prdResult.Path, story.Branch); err != nil {
+		t.Fatalf("merge story: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(prdResult.Path, "feature.txt")); err != nil {
+		t.Fatalf("expected merged file in PRD worktree: %v", err)
+	}
+
+	if err := manager.RemoveStory(ctx, baseDir, "main", "US-001", true); err != nil {
+		t.Fatalf("remove story: %v", err)
+	}
+	if _, err := os.Stat(story.Path); !os.IsNotExist(err) {
+		t.Fatalf("expected story worktree to be removed, stat err=%v", err)
+	}
+	exists, err := branchExists(ctx, baseDir, story.Branch)
+	if err != nil {
+		t.Fatalf("check branch: %v", err)
+	}
+	if exists {
+		t.Fatal("expected story branch to be deleted")
+	}
+}
+
+func TestMergeStoryReportsConflicts(t *testing.T) {
+	t.Parallel()
+
+	baseDir := initGitRepo(t)
+	manager := NewManager()
+	ctx := context.Background()
+
+	prdResult, err := manager.Ensure(ctx, baseDir, "main")
+	if err != nil {
+		t.Fatalf("ensure PRD worktree: %v", err)
+	}
+	first, err := manager.EnsureStory(ctx, baseDir, "main", "US-001", prdResult.Path)
+	if err != nil {
+		t.Fatalf("ensure first story: %v", err)
+	}
+	second, err := manager.EnsureStory(ctx, baseDir, "main", "US-002", prdResult.Path)
+	if err != nil {
+		t.Fatalf("ensure second story: %v", err)
+	}
+	commitFile(t, first.Path, "README.md", "first\n")
+	commitFile(t, second.Path, "README.md", "second\n")
+
+	if err := manager.MergeStory(ctx, prdResult.Path, first.Branch); err != nil {
+		t.Fatalf("merge first story: %v", err)
+	}
+	err = manager.MergeStory(ctx, prdResult.Path, second.Branch)
+	if !IsMergeConflict(err) {
+		t.Fatalf("expected merge conflict, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "README.md") {
+		t.Fatalf("expected conflicting file in error, got %v", err)
+	}
+
+	status, err := gitOutput(ctx, prdResult.Path, "status", "--porcelain")
+	if err != nil {
+		t.Fatalf("git status: %v", err)
+	}
+	if strings.TrimSpace(status) != "" {
+		t.Fatalf("expected aborted merge to leave a clean tree, got %q", status)
+	}
+}
+
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	runGitCmd(t, dir, "add", name)
+	runGitCmd(t, dir, "commit", "-m", "update "+name)
+}